@@ -0,0 +1,158 @@
+/* eslint-disable import/no-unresolved */
+import React from "react";
+import { Button } from "@/components/ui/button";
+import { Badge } from "@/components/ui/badge";
+import { Textarea } from "@/components/ui/textarea";
+import { Loader2, MessageSquare, Pencil, Trash } from "lucide-react";
+import { localApi } from "@/lib/localApi";
+import { useToast } from "@/hooks/use-toast";
+import { isAdmin } from "@/utils";
+
+type Props = {
+  requestId: number;
+  user: User;
+};
+
+const RequestComments = (props: Props) => {
+  const { requestId, user } = props;
+  const [comments, setComments] = React.useState<RequestComment[]>([]);
+  const [isLoading, setIsLoading] = React.useState(true);
+  const [isSaving, setIsSaving] = React.useState(false);
+  const [body, setBody] = React.useState("");
+  const [editingId, setEditingId] = React.useState<number | null>(null);
+
+  const { toast } = useToast();
+
+  React.useEffect(() => {
+    setIsLoading(true);
+    localApi
+      .getRequestComments(requestId)
+      .then(setComments)
+      .catch(() => setComments([]))
+      .finally(() => setIsLoading(false));
+  }, [requestId]);
+
+  const handleSubmit = async () => {
+    if (!body.trim()) return;
+
+    setIsSaving(true);
+    try {
+      if (editingId) {
+        const updated = await localApi.updateRequestComment(
+          requestId,
+          editingId,
+          body
+        );
+        setComments((prev) =>
+          prev.map((comment) => (comment.id === updated.id ? updated : comment))
+        );
+      } else {
+        const created = await localApi.createRequestComment(requestId, body);
+        setComments((prev) => [...prev, created]);
+      }
+      setBody("");
+      setEditingId(null);
+    } catch (err) {
+      toast({
+        title: "Error",
+        description: `Failed to save comment. ${err}`,
+        variant: "destructive",
+      });
+    } finally {
+      setIsSaving(false);
+    }
+  };
+
+  const handleEdit = (comment: RequestComment) => {
+    setEditingId(comment.id);
+    setBody(comment.body);
+  };
+
+  const handleDelete = async (comment: RequestComment) => {
+    try {
+      await localApi.deleteRequestComment(requestId, comment.id);
+      setComments((prev) => prev.filter((c) => c.id !== comment.id));
+    } catch (err) {
+      toast({
+        title: "Error",
+        description: `Failed to delete comment. ${err}`,
+        variant: "destructive",
+      });
+    }
+  };
+
+  return (
+    <div className="space-y-3">
+      <div className="flex items-center gap-2 font-semibold">
+        <MessageSquare className="h-4 w-4" />
+        Comments
+      </div>
+      {isLoading ? (
+        <Loader2 className="h-4 w-4 animate-spin" />
+      ) : comments.length === 0 ? (
+        <p className="text-sm text-muted-foreground">No comments yet.</p>
+      ) : (
+        <div className="max-h-48 space-y-2 overflow-y-auto">
+          {comments.map((comment) => (
+            <div key={comment.id} className="rounded-md border p-2 text-sm">
+              <div className="flex items-center justify-between gap-2">
+                <span className="font-medium">
+                  {comment.author_username}{" "}
+                  {comment.from_admin && <Badge variant="outline">Admin</Badge>}
+                </span>
+                <span className="flex items-center gap-1 text-xs text-muted-foreground">
+                  {new Date(comment.created_at).toLocaleString()}
+                  {comment.author_id === user.id && (
+                    <Button
+                      variant="ghost"
+                      size="icon"
+                      className="h-6 w-6"
+                      onClick={() => handleEdit(comment)}
+                    >
+                      <Pencil className="h-3 w-3" />
+                    </Button>
+                  )}
+                  {(comment.author_id === user.id || isAdmin(user)) && (
+                    <Button
+                      variant="ghost"
+                      size="icon"
+                      className="h-6 w-6"
+                      onClick={() => handleDelete(comment)}
+                    >
+                      <Trash className="h-3 w-3" />
+                    </Button>
+                  )}
+                </span>
+              </div>
+              <p className="whitespace-pre-wrap">{comment.body}</p>
+            </div>
+          ))}
+        </div>
+      )}
+      <Textarea
+        placeholder="Add a comment..."
+        value={body}
+        onChange={(e) => setBody(e.target.value)}
+      />
+      <div className="flex justify-end gap-2">
+        {editingId && (
+          <Button
+            variant="outline"
+            onClick={() => {
+              setEditingId(null);
+              setBody("");
+            }}
+          >
+            Cancel
+          </Button>
+        )}
+        <Button onClick={handleSubmit} disabled={isSaving || !body.trim()}>
+          {isSaving && <Loader2 className="animate-spin" />}
+          {editingId ? "Save" : "Comment"}
+        </Button>
+      </div>
+    </div>
+  );
+};
+
+export default RequestComments;
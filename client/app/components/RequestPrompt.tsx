@@ -19,6 +19,7 @@ import {
 } from "./ui/select";
 import { isAdmin, useOptionalUser } from "@/utils";
 import { Label } from "./ui/label";
+import { localApi } from "@/lib/localApi";
 
 type Props = {
   selectedBook: NewBookRequest | null;
@@ -42,6 +43,8 @@ const RequestPrompt = (props: Props) => {
     username: string;
     id: string;
   }>({ username: "", id: "" });
+  const [mediaType, setMediaType] = React.useState<MediaType>("ebook");
+  const [quota, setQuota] = React.useState<RequestQuota | null>(null);
 
   React.useEffect(() => {
     if (user) {
@@ -49,12 +52,24 @@ const RequestPrompt = (props: Props) => {
     }
   }, [user]);
 
+  React.useEffect(() => {
+    if (selectedBook) {
+      localApi
+        .getRequestQuota()
+        .then(setQuota)
+        .catch(() => setQuota(null));
+    }
+  }, [selectedBook]);
+
+  const quotaExceeded = !!quota && !quota.unlimited && quota.remaining <= 0;
+
   const handleConfirmRequest = async () => {
     if (selectedBook && onRequestBook) {
       setIsLoading(true);
 
       selectedBook.requestor_id = requestAs.id;
       selectedBook.requestor_username = requestAs.username;
+      selectedBook.media_type = mediaType;
 
       await onRequestBook(selectedBook);
 
@@ -99,6 +114,33 @@ const RequestPrompt = (props: Props) => {
               </p>
             </div>
           </div>
+          <div className="space-y-2">
+            <Label htmlFor="media-type">Format</Label>
+            <Select
+              value={mediaType}
+              onValueChange={(val: string) => setMediaType(val as MediaType)}
+            >
+              <SelectTrigger id="media-type">
+                <SelectValue />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="ebook">Ebook</SelectItem>
+                <SelectItem value="audiobook">Audiobook</SelectItem>
+                <SelectItem value="both">Ebook &amp; Audiobook</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+          {quota && !quota.unlimited && (
+            <p
+              className={`text-sm ${
+                quotaExceeded ? "text-destructive" : "text-muted-foreground"
+              }`}
+            >
+              You can request {quota.remaining} more of {quota.limit} books
+              this {quota.period}. Your quota resets{" "}
+              {new Date(quota.resets_at).toLocaleString()}.
+            </p>
+          )}
           {isAdmin(user) && (
             <div className="space-y-2">
               <Label htmlFor="approval">Request as</Label>
@@ -135,7 +177,7 @@ const RequestPrompt = (props: Props) => {
           <Button
             type="button"
             onClick={handleConfirmRequest}
-            disabled={isLoading}
+            disabled={isLoading || quotaExceeded}
           >
             {isLoading ? (
               <>
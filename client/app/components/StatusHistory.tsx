@@ -0,0 +1,90 @@
+/* eslint-disable import/no-unresolved */
+import React from "react";
+import { History, Loader2 } from "lucide-react";
+import { LinearProcessFlow } from "@/components/LinearProcessFlow";
+import { localApi } from "@/lib/localApi";
+
+type Props = {
+  requestId?: number;
+  issueId?: number;
+};
+
+const describeChange = (
+  label: string,
+  oldStatus: string | null,
+  newStatus: string | null
+) => {
+  if (!newStatus) return null;
+  return oldStatus ? `${label}: ${oldStatus} → ${newStatus}` : `${label}: ${newStatus}`;
+};
+
+// Formats the events as steps for LinearProcessFlow: a "###" title line
+// followed by a line per detail.
+const formatEvents = (events: StatusEvent[]) =>
+  events
+    .map((event) => {
+      const created =
+        !event.old_approval_status &&
+        !event.old_download_status &&
+        !event.old_issue_status;
+      const changes = [
+        describeChange(
+          "Approval",
+          event.old_approval_status,
+          event.new_approval_status
+        ),
+        describeChange(
+          "Download",
+          event.old_download_status,
+          event.new_download_status
+        ),
+        describeChange("Status", event.old_issue_status, event.new_issue_status),
+      ].filter(Boolean);
+
+      return [
+        `### ${created ? "Created" : "Updated"} by ${event.actor_username}`,
+        new Date(event.created_at).toLocaleString(),
+        ...changes,
+      ].join("\n");
+    })
+    .join("\n");
+
+const StatusHistory = (props: Props) => {
+  const { requestId, issueId } = props;
+  const [events, setEvents] = React.useState<StatusEvent[]>([]);
+  const [isLoading, setIsLoading] = React.useState(true);
+
+  React.useEffect(() => {
+    const fetchHistory = requestId
+      ? localApi.getRequestHistory(requestId)
+      : issueId
+      ? localApi.getIssueHistory(issueId)
+      : Promise.resolve([]);
+
+    setIsLoading(true);
+    fetchHistory
+      .then(setEvents)
+      .catch(() => setEvents([]))
+      .finally(() => setIsLoading(false));
+  }, [requestId, issueId]);
+
+  return (
+    <div className="space-y-3">
+      <div className="flex items-center gap-2 font-semibold">
+        <History className="h-4 w-4" />
+        History
+      </div>
+      {isLoading ? (
+        <Loader2 className="h-4 w-4 animate-spin" />
+      ) : events.length === 0 ? (
+        <p className="text-sm text-muted-foreground">No history yet.</p>
+      ) : (
+        <div className="max-h-64 overflow-y-auto">
+          <LinearProcessFlow>{formatEvents(events)}</LinearProcessFlow>
+        </div>
+      )}
+    </div>
+  );
+};
+
+export default StatusHistory;
@@ -19,8 +19,11 @@ export interface UniversalBook {
   source_id: string; // The ID from the original source
   isbn_10?: string | null;
   isbn_13?: string | null;
+  goodreads_id?: string | null;
+  publish_year?: number | null;
   description?: string | null;
   isAudiobook?: boolean;
+  inLibrary?: boolean;
 }
 
 // Props for the UniversalBookShelf component
@@ -57,6 +60,8 @@ export default function UniversalBookShelf({
       source_id: book.source_id,
       isbn_10: book.isbn_10 || null,
       isbn_13: book.isbn_13 || null,
+      goodreads_id: book.goodreads_id || null,
+      publish_year: book.publish_year || null,
       cover: book.coverUrl || null,
       requestor_id: "",
       requestor_username: "",
@@ -192,13 +197,14 @@ export default function UniversalBookShelf({
                           variant="default"
                           size="sm"
                           className="w-full"
+                          disabled={book.inLibrary}
                           onClick={() => handleRequestClick(book)}
                         >
                           <BookOpen
                             className="w-4 h-4 mr-2"
                             aria-hidden="true"
                           />
-                          <span>Request</span>
+                          <span>{book.inLibrary ? "In Library" : "Request"}</span>
                         </Button>
                       </>
                     )}
@@ -53,50 +53,18 @@ const getApiClient = (baseUrl?: string) => {
   });
 };
 
-// Function to handle Google book search
-const googleSearch = async (query: string) => {
+// Function to handle book search with the configured metadata provider
+const search = async (query: string) => {
   try {
     const apiClient: AxiosInstance = getApiClient();
     const response: AxiosResponse<LocalSearchResponse> = await apiClient.post(
-      "/search/google",
+      "/search",
       { query }
     );
     return response.data;
   } catch (error) {
     const errorMessage = getErrorMessage(error);
-    console.error("Google search error:", errorMessage);
-    throw new Error(errorMessage);
-  }
-};
-
-// Function to handle OpenLibrary book search
-const openlibSearch = async (query: string) => {
-  try {
-    const apiClient: AxiosInstance = getApiClient();
-    const response: AxiosResponse<LocalSearchResponse> = await apiClient.post(
-      "/search/openlib",
-      { query }
-    );
-    return response.data;
-  } catch (error) {
-    const errorMessage = getErrorMessage(error);
-    console.error("OpenLibrary search error:", errorMessage);
-    throw new Error(errorMessage);
-  }
-};
-
-// Function to handle Hardcover book search
-const hardcoverSearch = async (query: string) => {
-  try {
-    const apiClient: AxiosInstance = getApiClient();
-    const response: AxiosResponse<LocalSearchResponse> = await apiClient.post(
-      "/search/hardcover",
-      { query }
-    );
-    return response.data;
-  } catch (error) {
-    const errorMessage = getErrorMessage(error);
-    console.error("Hardcover search error:", errorMessage);
+    console.error("Search error:", errorMessage);
     throw new Error(errorMessage);
   }
 };
@@ -164,11 +132,12 @@ const createNewRequest = async (req: NewBookRequest) => {
 };
 
 // Function to get requests
-const getRequests = async (baseUrl?: string) => {
+const getRequests = async (baseUrl?: string, params?: PageParams) => {
   try {
     const apiClient: AxiosInstance = getApiClient(baseUrl);
-    const response: AxiosResponse<BookRequest[]> = await apiClient.get(
-      "/requests/"
+    const response: AxiosResponse<Page<BookRequest>> = await apiClient.get(
+      "/requests/",
+      { params }
     );
     return response.data;
   } catch (error) {
@@ -209,6 +178,117 @@ const deleteRequest = async (reqId: number) => {
   }
 };
 
+// Function to get the download attempt history of a request
+const getRequestAttempts = async (reqId: number) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<DownloadAttempt[]> = await apiClient.get(
+      `/requests/${reqId}/attempts`
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Get request attempts error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to get the status history of a request
+const getRequestHistory = async (reqId: number) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<StatusEvent[]> = await apiClient.get(
+      `/requests/${reqId}/history`
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Get request history error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to get how many more books the user can request
+const getRequestQuota = async () => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<RequestQuota> = await apiClient.get(
+      "/requests/quota"
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Get request quota error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to get the comment thread of a request
+const getRequestComments = async (reqId: number) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<RequestComment[]> = await apiClient.get(
+      `/requests/${reqId}/comments`
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Get request comments error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to comment on a request
+const createRequestComment = async (reqId: number, body: string) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<RequestComment> = await apiClient.post(
+      `/requests/${reqId}/comments`,
+      { body }
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Create request comment error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to edit a comment on a request
+const updateRequestComment = async (
+  reqId: number,
+  commentId: number,
+  body: string
+) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<RequestComment> = await apiClient.patch(
+      `/requests/${reqId}/comments/${commentId}`,
+      { body }
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Update request comment error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to delete a comment on a request
+const deleteRequestComment = async (reqId: number, commentId: number) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response = await apiClient.delete(
+      `/requests/${reqId}/comments/${commentId}`
+    );
+    return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Delete request comment error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
 // Function to submit an issue
 const createNewIssue = async (req: NewIssue) => {
   try {
@@ -226,10 +306,13 @@ const createNewIssue = async (req: NewIssue) => {
 };
 
 // Function to get issues
-const getIssues = async (baseUrl?: string) => {
+const getIssues = async (baseUrl?: string, params?: PageParams) => {
   try {
     const apiClient: AxiosInstance = getApiClient(baseUrl);
-    const response: AxiosResponse<Issue[]> = await apiClient.get("/issues/");
+    const response: AxiosResponse<Page<Issue>> = await apiClient.get(
+      "/issues/",
+      { params }
+    );
     return response.data;
   } catch (error) {
     const errorMessage = getErrorMessage(error);
@@ -414,14 +497,29 @@ const oidcLogout = async () => {
   }
 };
 
-// Function to get users (admin/root only)
-const getUsers = async () => {
+// Function to get the status history of an issue
+const getIssueHistory = async (issueId: number) => {
   try {
     const apiClient: AxiosInstance = getApiClient();
-    const response: AxiosResponse<UsersResponse> = await apiClient.get(
-      "/users"
+    const response: AxiosResponse<StatusEvent[]> = await apiClient.get(
+      `/issues/${issueId}/history`
     );
     return response.data;
+  } catch (error) {
+    const errorMessage = getErrorMessage(error);
+    console.error("Get issue history error:", errorMessage);
+    throw new Error(errorMessage);
+  }
+};
+
+// Function to get users (admin/root only)
+const getUsers = async (params?: PageParams) => {
+  try {
+    const apiClient: AxiosInstance = getApiClient();
+    const response: AxiosResponse<Page<User>> = await apiClient.get("/users", {
+      params,
+    });
+    return response.data;
   } catch (error) {
     const errorMessage = getErrorMessage(error);
     console.error("Get users error:", errorMessage);
@@ -432,25 +530,31 @@ const getUsers = async () => {
 export const localApi = {
   createNewIssue,
   createNewRequest,
+  createRequestComment,
   deleteIssue,
   deleteRequest,
+  deleteRequestComment,
   getAuthInfo,
+  getIssueHistory,
   getIssues,
   getRecentBooks,
+  getRequestAttempts,
+  getRequestComments,
+  getRequestHistory,
+  getRequestQuota,
   getRequests,
   getServerConfig,
   getServerSettings,
   getUserInfo,
   getUserPreferences,
   getUsers,
-  googleSearch,
-  hardcoverSearch,
   initiateOIDCLogin,
   oidcLogout,
-  openlibSearch,
+  search,
   sendEmailVerification,
   updateIssue,
   updateRequest,
+  updateRequestComment,
   updateServerConfig,
   updateUserPreferences,
   verifyEmail,
@@ -10,9 +10,7 @@ import { LoaderFunction, LoaderFunctionArgs } from "@remix-run/node";
 import { getUserToken } from "@/session.server";
 import {
   transformAbsBook,
-  transformGoogleBook,
-  transformHardcoverBook,
-  transformOpenLibraryBook,
+  transformBookResult,
   useDebounce,
   useOptionalUser,
 } from "@/utils";
@@ -113,39 +111,11 @@ export default function IndexHandler() {
     let absData: UniversalBook[] = [];
 
     try {
-      switch (provider) {
-        case "GOOGLE": {
-          const data = await localApi.googleSearch(query);
-          externalData = (data.search_results as GoogleBook[]).map(
-            transformGoogleBook
-          );
-          absData = (data.abs_results as BookItem[]).map((book) =>
-            transformAbsBook(book, absBaseUrl)
-          );
-          break;
-        }
-        case "OPENLIBRARY": {
-          const data = await localApi.openlibSearch(query);
-          const searchRes = data.search_results as OpenLibraryResponse;
-          externalData = (searchRes.docs || []).map(transformOpenLibraryBook);
-          absData = (data.abs_results as BookItem[]).map((book) =>
-            transformAbsBook(book, absBaseUrl)
-          );
-          break;
-        }
-        case "HARDCOVER": {
-          const data = await localApi.hardcoverSearch(query);
-          externalData = (data.search_results as HardcoverBook[]).map(
-            transformHardcoverBook
-          );
-          absData = (data.abs_results as BookItem[]).map((book) =>
-            transformAbsBook(book, absBaseUrl)
-          );
-          break;
-        }
-        default:
-          break;
-      }
+      const data = await localApi.search(query);
+      absData = (data.abs_results as BookItem[]).map((book) =>
+        transformAbsBook(book, absBaseUrl)
+      );
+      externalData = data.search_results.map(transformBookResult);
     } catch (error) {
       console.error("Error fetching data:", error);
       toast({
@@ -212,9 +182,9 @@ export default function IndexHandler() {
     }
 
     localApi
-      .getUsers()
+      .getUsers({ limit: 100 })
       .then((res) => {
-        setUsers(res.users);
+        setUsers(res.items);
       })
       .catch((err) => {
         console.error("Error fetching users:", err);
@@ -247,11 +217,12 @@ export default function IndexHandler() {
       return;
     }
     try {
-      await localApi.createNewRequest(req);
+      const created = await localApi.createNewRequest(req);
 
       toast({
         title: "New Book Request",
-        description: "Your book request was created.",
+        description:
+          created.warnings?.join(" ") || "Your book request was created.",
       });
     } catch (error) {
       console.error(error);
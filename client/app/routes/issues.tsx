@@ -54,6 +54,7 @@ import {
 } from "@/components/ui/select";
 import { getEnvVal } from "@/lib/utils";
 import UserAvatar from "@/components/UserAvatar";
+import StatusHistory from "@/components/StatusHistory";
 
 // Define the data type for the loader
 type LoaderData = {
@@ -88,12 +89,12 @@ const Issues = () => {
   const {} = useLoaderData<LoaderData>();
   const [isOpen, setIsOpen] = useState(false);
   const [isLoading, setIsLoading] = useState(false);
-  const [allIssues, setAllIssues] = useState<Issue[]>([]);
   const [isLoadingIssues, setIsLoadingIssues] = useState(true);
 
   const [currentPage, setCurrentPage] = useState(1);
   const limit = 5;
-  const totalPages = Math.ceil(allIssues.length / limit);
+  const [total, setTotal] = useState(0);
+  const totalPages = Math.ceil(total / limit);
   const [issues, setIssues] = useState<Issue[]>([]);
   const [editing, setEditing] = useState(false);
   const [removing, setRemoving] = useState(false);
@@ -110,21 +111,23 @@ const Issues = () => {
 
   const { toast } = useToast();
 
-  // Function to fetch issues
-  const fetchIssues = async () => {
+  // Function to fetch a page of issues, the first page by default
+  const fetchIssues = async (page = 1) => {
     if (!user) return;
 
     setIsLoadingIssues(true);
     try {
-      const data = await localApi.getIssues(window.location.origin);
-      setAllIssues(data);
-      // Set initial page of issues
-      setIssues(data.slice(0, limit));
-      setCurrentPage(1); // Reset to first page on refresh
+      const data = await localApi.getIssues(window.location.origin, {
+        limit,
+        offset: (page - 1) * limit,
+      });
+      setIssues(data.items);
+      setTotal(data.total);
+      setCurrentPage(page);
     } catch (err) {
       console.error("Error fetching issues:", err);
-      setAllIssues([]);
       setIssues([]);
+      setTotal(0);
       toast({
         title: "Error",
         description: "Failed to fetch issues. Please try again.",
@@ -140,12 +143,6 @@ const Issues = () => {
     fetchIssues();
   }, [user]);
 
-  // Update paginated issues when allIssues changes
-  useEffect(() => {
-    const index = (currentPage - 1) * limit;
-    setIssues(allIssues.slice(index, index + limit));
-  }, [allIssues, currentPage, limit]);
-
   const handleInfoClick = (issue: Issue) => {
     setSelectedIssue(issue);
   };
@@ -198,9 +195,7 @@ const Issues = () => {
   };
 
   const handlePageChange = (page: number) => {
-    setCurrentPage(page);
-    const index = (page - 1) * limit;
-    setIssues(allIssues.slice(index, index + limit));
+    fetchIssues(page);
   };
 
   const handleUpdate = async () => {
@@ -290,7 +285,7 @@ const Issues = () => {
             <div className="flex items-center justify-between mb-6">
               <h1 className="text-2xl font-bold">Issues</h1>
               <Button
-                onClick={fetchIssues}
+                onClick={() => fetchIssues()}
                 disabled={isLoadingIssues}
                 variant="outline"
                 size="sm"
@@ -470,6 +465,7 @@ const Issues = () => {
                       </div>
                     )}
                   </div>
+                  {selectedIssue && <StatusHistory issueId={selectedIssue.id} />}
                 </div>
                 <DialogFooter>
                   <Button type="button" onClick={handleCloseModal}>
@@ -55,7 +55,10 @@ import {
   SelectValue,
 } from "@/components/ui/select";
 import UserAvatar from "@/components/UserAvatar";
+import RequestComments from "@/components/RequestComments";
+import StatusHistory from "@/components/StatusHistory";
 import { Input } from "@/components/ui/input";
+import { Textarea } from "@/components/ui/textarea";
 
 // Define the data type for the loader
 type LoaderData = {
@@ -92,12 +95,12 @@ const BookRequests = () => {
   const {} = useLoaderData<LoaderData>();
   const [isOpen, setIsOpen] = useState(false);
   const [isLoading, setIsLoading] = useState(false);
-  const [allRequests, setAllRequests] = useState<BookRequest[]>([]);
   const [isLoadingRequests, setIsLoadingRequests] = useState(true);
 
   const [currentPage, setCurrentPage] = useState(1);
   const limit = 5;
-  const totalPages = Math.ceil(allRequests.length / limit);
+  const [total, setTotal] = useState(0);
+  const totalPages = Math.ceil(total / limit);
   const [requests, setRequests] = useState<BookRequest[]>([]);
   const [editing, setEditing] = useState(false);
   const [removing, setRemoving] = useState(false);
@@ -116,21 +119,23 @@ const BookRequests = () => {
 
   const { toast } = useToast();
 
-  // Function to fetch requests
-  const fetchRequests = async () => {
+  // Function to fetch a page of requests, the first page by default
+  const fetchRequests = async (page = 1) => {
     if (!user) return;
 
     setIsLoadingRequests(true);
     try {
-      const data = await localApi.getRequests(window.location.origin);
-      setAllRequests(data);
-      // Set initial page of requests
-      setRequests(data.slice(0, limit));
-      setCurrentPage(1); // Reset to first page on refresh
+      const data = await localApi.getRequests(window.location.origin, {
+        limit,
+        offset: (page - 1) * limit,
+      });
+      setRequests(data.items);
+      setTotal(data.total);
+      setCurrentPage(page);
     } catch (err) {
       console.error("Error fetching requests:", err);
-      setAllRequests([]);
       setRequests([]);
+      setTotal(0);
       toast({
         title: "Error",
         description: "Failed to fetch requests. Please try again.",
@@ -146,12 +151,6 @@ const BookRequests = () => {
     fetchRequests();
   }, [user]);
 
-  // Update paginated requests when allRequests changes
-  useEffect(() => {
-    const index = (currentPage - 1) * limit;
-    setRequests(allRequests.slice(index, index + limit));
-  }, [allRequests, currentPage, limit]);
-
   const handleInfoClick = (request: BookRequest) => {
     setSelectedRequest(request);
   };
@@ -162,6 +161,11 @@ const BookRequests = () => {
       approval_status: request.approval_status,
       download_status: request.download_status,
       download_source: request.download_source,
+      // Only admins can set the denial reason and admin note
+      ...(isAdmin(user) && {
+        denial_reason: request.denial_reason || "",
+        admin_note: request.admin_note || "",
+      }),
     });
     setEditing(true);
   };
@@ -206,9 +210,7 @@ const BookRequests = () => {
   };
 
   const handlePageChange = (page: number) => {
-    setCurrentPage(page);
-    const index = (page - 1) * limit;
-    setRequests(allRequests.slice(index, index + limit));
+    fetchRequests(page);
   };
 
   const handleUpdate = async () => {
@@ -290,7 +292,7 @@ const BookRequests = () => {
             <div className="flex items-center justify-between mb-6">
               <h1 className="text-2xl font-bold">Book Requests</h1>
               <Button
-                onClick={fetchRequests}
+                onClick={() => fetchRequests()}
                 disabled={isLoadingRequests}
                 variant="outline"
                 size="sm"
@@ -508,14 +510,37 @@ const BookRequests = () => {
                   <div className="grid grid-cols-4 items-center gap-4">
                     <Download className="h-4 w-4" />
                     <div className="col-span-3">
+                      <p>
+                        <strong>Format:</strong>{" "}
+                        {selectedRequest?.media_type}
+                      </p>
                       <p>
                         <strong>Approval Status:</strong>{" "}
                         {selectedRequest?.approval_status}
                       </p>
+                      {isAdmin(user) && selectedRequest?.approval_rule && (
+                        <p>
+                          <strong>Approval Rule:</strong>{" "}
+                          {selectedRequest.approval_rule}
+                        </p>
+                      )}
                       <p>
                         <strong>Download Status:</strong>{" "}
                         {selectedRequest?.download_status}
                       </p>
+                      {selectedRequest?.approval_status === "denied" &&
+                        selectedRequest.denial_reason && (
+                          <p>
+                            <strong>Denial Reason:</strong>{" "}
+                            {selectedRequest.denial_reason}
+                          </p>
+                        )}
+                      {isAdmin(user) && selectedRequest?.admin_note && (
+                        <p>
+                          <strong>Admin Note:</strong>{" "}
+                          {selectedRequest.admin_note}
+                        </p>
+                      )}
                       {isAdmin(user) &&
                         selectedRequest?.download_status === "complete" && (
                           <p>
@@ -525,6 +550,15 @@ const BookRequests = () => {
                         )}
                     </div>
                   </div>
+                  {selectedRequest && (
+                    <StatusHistory requestId={selectedRequest.id} />
+                  )}
+                  {selectedRequest && user && (
+                    <RequestComments
+                      requestId={selectedRequest.id}
+                      user={user}
+                    />
+                  )}
                 </div>
                 <DialogFooter>
                   <Button type="button" onClick={handleCloseModal}>
@@ -606,6 +640,39 @@ const BookRequests = () => {
                         disabled={formVals.download_status !== "complete"}
                       />
                     </div>
+                    {isAdmin(user) && (
+                      <>
+                        <div className="space-y-2">
+                          <Label htmlFor="denial-reason">Denial Reason</Label>
+                          <Textarea
+                            id="denial-reason"
+                            value={formVals.denial_reason || ""}
+                            placeholder="Why the request was denied, shown to the requester..."
+                            onChange={(e) =>
+                              setFormVals({
+                                ...formVals,
+                                denial_reason: e.target.value,
+                              })
+                            }
+                            disabled={formVals.approval_status !== "denied"}
+                          />
+                        </div>
+                        <div className="space-y-2">
+                          <Label htmlFor="admin-note">Admin Note</Label>
+                          <Textarea
+                            id="admin-note"
+                            value={formVals.admin_note || ""}
+                            placeholder="Private note, only visible to admins..."
+                            onChange={(e) =>
+                              setFormVals({
+                                ...formVals,
+                                admin_note: e.target.value,
+                              })
+                            }
+                          />
+                        </div>
+                      </>
+                    )}
                   </>
                 ) : (
                   <>
@@ -198,7 +198,3 @@ type Author = {
   updatedAt: number;
   numBooks: number;
 };
-
-type UsersResponse = {
-  users: User[];
-};
@@ -1,12 +1,14 @@
 //
 type LocalServerSettings = {
-  metadata_provider: "GOOGLE" | "OPENLIBRARY" | "HARDCOVER";
+  metadata_provider: "GOOGLE" | "OPENLIBRARY" | "HARDCOVER" | "FEDERATED";
   indexer_enabled: boolean;
   zlibrary_enabled: boolean;
   cwa_enabled: boolean;
   version: string;
 };
 
+type MediaType = "ebook" | "audiobook" | "both";
+
 type BookRequest = {
   id: number;
   title: string;
@@ -15,6 +17,9 @@ type BookRequest = {
   source_id: string;
   isbn_10: string | null;
   isbn_13: string | null;
+  asin: string | null;
+  goodreads_id: string | null;
+  publish_year: number | null;
   cover: string | null;
   approval_status: string;
   download_status: string;
@@ -23,6 +28,106 @@ type BookRequest = {
   requestor_username: string;
   created_at: string;
   updated_at: string;
+  media_type: MediaType;
+  denial_reason: string | null;
+  admin_note?: string | null;
+  approval_rule: string | null;
+  followers: RequestFollower[];
+  preferred_formats: string | null;
+  languages: string | null;
+  download_job?: DownloadJob;
+  warnings?: string[];
+};
+
+type DownloadAttempt = {
+  id: number;
+  request_id: number;
+  job_id: number | null;
+  attempt: number;
+  source: string;
+  query: string;
+  succeeded: boolean;
+  in_progress: boolean;
+  client: string | null;
+  client_id: string | null;
+  reason: string | null;
+  created_at: string;
+  updated_at: string;
+};
+
+type RequestComment = {
+  id: number;
+  request_id: number;
+  author_id: string;
+  author_username: string;
+  from_admin: boolean;
+  body: string;
+  created_at: string;
+  updated_at: string;
+};
+
+type StatusEvent = {
+  id: number;
+  request_id: number | null;
+  issue_id: number | null;
+  actor_id: string;
+  actor_username: string;
+  old_approval_status: string | null;
+  new_approval_status: string | null;
+  old_download_status: string | null;
+  new_download_status: string | null;
+  old_issue_status: string | null;
+  new_issue_status: string | null;
+  created_at: string;
+};
+
+// Page is one page of a listing; total counts every matching item.
+type Page<T> = {
+  items: T[];
+  total: number;
+  limit: number;
+  offset: number;
+  next_cursor: string | null;
+};
+
+type PageParams = {
+  limit?: number;
+  offset?: number;
+  cursor?: string;
+};
+
+type RequestQuota = {
+  unlimited: boolean;
+  limit: number;
+  used: number;
+  remaining: number;
+  period: "day" | "week" | "month";
+  resets_at: string;
+};
+
+type DownloadJob = {
+  id: number;
+  request_id: number;
+  status: "queued" | "running" | "succeeded" | "failed";
+  kind: "download" | "track";
+  attempt: number;
+  client: string | null;
+  client_id: string | null;
+  attempt_id: number | null;
+  run_after: string | null;
+  error: string | null;
+  started_at: string | null;
+  finished_at: string | null;
+  created_at: string;
+  updated_at: string;
+};
+
+type RequestFollower = {
+  id: number;
+  request_id: number;
+  user_id: string;
+  username: string;
+  created_at: string;
 };
 
 type NewBookRequest = {
@@ -32,6 +137,11 @@ type NewBookRequest = {
   source_id: string;
   isbn_10: string | null;
   isbn_13: string | null;
+  goodreads_id?: string | null;
+  publish_year?: number | null;
+  preferred_formats?: string | null;
+  languages?: string | null;
+  media_type?: MediaType;
   cover: string | null;
   requestor_id: string;
   requestor_username: string;
@@ -41,10 +151,42 @@ type EditBookRequest = {
   approval_status: string;
   download_status: string;
   download_source: string | null;
+  preferred_formats?: string | null;
+  languages?: string | null;
+  media_type?: MediaType;
+  denial_reason?: string;
+  admin_note?: string;
+};
+
+type BookResult = {
+  title: string;
+  author: string;
+  authors: string[] | null;
+  source: "GOOGLE" | "OPENLIBRARY" | "HARDCOVER";
+  source_id: string;
+  isbn_10: string | null;
+  isbn_13: string | null;
+  asin: string | null;
+  goodreads_id: string | null;
+  cover: string | null;
+  covers: {
+    small: string;
+    medium: string;
+    large: string;
+  } | null;
+  description: string | null;
+  publish_year: number | null;
+  series: string | null;
+  language: string | null;
+  info_link: string | null;
+  providers: string[];
+  in_library: boolean;
+  library_item_id: string | null;
 };
 
 type LocalSearchResponse = {
-  search_results: GoogleBook[] | OpenLibraryResponse | HardcoverBook[];
+  provider: "GOOGLE" | "OPENLIBRARY" | "HARDCOVER" | "FEDERATED";
+  search_results: BookResult[];
   abs_results: BookItem[];
 };
 
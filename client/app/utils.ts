@@ -62,49 +62,19 @@ export const transformAbsBook = (
   isAudiobook: book.libraryItem.media.numAudioFiles > 0,
 });
 
-export const transformGoogleBook = (book: GoogleBook): UniversalBook => ({
-  id: `GOOGLE_${book.id}`,
-  title: book.volumeInfo.title,
-  author: book.volumeInfo.authors?.join(", ") || null,
-  coverUrl:
-    book.volumeInfo.imageLinks?.thumbnail?.replace("http:", "https:") || null,
-  infoLink: book.volumeInfo.infoLink || null,
-  source: "GOOGLE",
-  source_id: book.id,
-  isbn_10:
-    book.volumeInfo.industryIdentifiers?.find((id) => id.type === "ISBN_10")
-      ?.identifier || null,
-  isbn_13:
-    book.volumeInfo.industryIdentifiers?.find((id) => id.type === "ISBN_13")
-      ?.identifier || null,
-  description: book.volumeInfo.description || null,
-  isAudiobook: false,
-});
-
-export const transformOpenLibraryBook = (
-  book: OpenLibraryBook
-): UniversalBook => ({
-  id: `OPENLIBRARY_${book.info_link?.split("/").pop() || book.title}`,
-  title: book.title,
-  author: book.author_name?.join(", ") || null,
-  coverUrl: book.cover_images?.medium || null,
-  infoLink: book.info_link || null,
-  source: "OPENLIBRARY",
-  source_id: book.info_link?.split("/").pop() || "none",
-  description: null,
-  isAudiobook: false,
-});
-
-export const transformHardcoverBook = (book: HardcoverBook): UniversalBook => ({
-  id: `HARDCOVER_${book.id}`,
+export const transformBookResult = (book: BookResult): UniversalBook => ({
+  id: `${book.source}_${book.source_id}`,
   title: book.title,
-  author: book.contributions?.[0]?.author.name || null,
-  coverUrl: book.images?.[0]?.url || null,
-  infoLink: `https://hardcover.app/books/${book.slug}`,
-  source: "HARDCOVER",
-  source_id: book.id.toString(),
-  isbn_10: book.default_physical_edition?.isbn_10 || null,
-  isbn_13: book.default_physical_edition?.isbn_13 || null,
-  description: book.description || null,
+  author: book.author || null,
+  coverUrl: book.cover,
+  infoLink: book.info_link,
+  source: book.source,
+  source_id: book.source_id,
+  isbn_10: book.isbn_10,
+  isbn_13: book.isbn_13,
+  goodreads_id: book.goodreads_id,
+  publish_year: book.publish_year,
+  description: book.description,
   isAudiobook: false,
+  inLibrary: book.in_library,
 });
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"api/lib/cache"
+	"api/middlewares"
+	"net/http"
+
+	"github.com/beego/beego/v2/core/logs"
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+// Operations about the search cache
+type CacheController struct {
+	beego.Controller
+}
+
+// @Title GetCacheStats
+// @Description retrieve search cache hit/miss statistics (admin only)
+// @Success 200 {object} cache.Stats
+// @Failure 403 Admin access required
+// @router / [get]
+func (c *CacheController) Get() {
+	user := middlewares.GetUser(c.Ctx)
+	if user == nil || !user.IsAdmin() {
+		c.Ctx.Output.SetStatus(http.StatusForbidden)
+		c.Data["json"] = map[string]string{"error": "Admin access required"}
+		c.ServeJSON()
+		return
+	}
+
+	c.Data["json"] = cache.GetSearchCache().Stats()
+	c.ServeJSON()
+}
+
+// @Title PurgeCache
+// @Description remove every cached search response (admin only)
+// @Success 204
+// @Failure 403 Admin access required
+// @router / [delete]
+func (c *CacheController) Delete() {
+	user := middlewares.GetUser(c.Ctx)
+	if user == nil || !user.IsAdmin() {
+		c.Ctx.Output.SetStatus(http.StatusForbidden)
+		c.Data["json"] = map[string]string{"error": "Admin access required"}
+		c.ServeJSON()
+		return
+	}
+
+	if err := cache.GetSearchCache().Purge(); err != nil {
+		logs.Warn("Error purging search cache: %v\n", err)
+		c.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		c.Data["json"] = map[string]string{"error": "Internal Server error occurred while purging cache."}
+		c.ServeJSON()
+		return
+	}
+
+	logs.Info("Search cache purged.")
+	c.Ctx.Output.SetStatus(http.StatusNoContent)
+}
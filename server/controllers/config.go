@@ -21,13 +21,13 @@ type ConfigController struct {
 // @router / [get]
 func (s *ConfigController) Get() {
 	sections := []string{
-		"default", "general", "db", "metadata", "notify",
+		"default", "general", "db", "requests", "metadata", "cache", "notify",
 		"download", "smtp", "auth", "oidc",
 	}
 
 	// Get the current user from context
 	user := middlewares.GetUser(s.Ctx)
-	isAdmin := user != nil && (user.Type == "admin" || user.Type == "root")
+	isAdmin := user != nil && user.IsAdmin()
 
 	// Define allowed keys for non-admin users (whitelist approach)
 	allowedKeysForNonAdmin := map[string][]string{
@@ -96,6 +96,15 @@ func (c *ConfigController) UpdateConfig() {
 		return
 	}
 
+	if req.Key == "requests::approvalrules" {
+		if _, err := models.ParseApprovalRules(req.Value); err != nil {
+			c.Ctx.Output.SetStatus(400)
+			c.Data["json"] = map[string]string{"error": err.Error()}
+			c.ServeJSON()
+			return
+		}
+	}
+
 	// Update the configuration
 	if err := config.Set(req.Key, req.Value); err != nil {
 		c.Data["json"] = map[string]string{"error": "Failed to update configuration"}
@@ -6,6 +6,7 @@ import (
 	"api/middlewares"
 	"api/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 
@@ -61,42 +62,87 @@ func (i *IssueController) Post() {
 // @Description Retrieve all issues objects from the database.
 // @Param	limit		query	int		false		"Limit of issue objects, defaults to 20"
 // @Param	offset		query	int		false		"Offset of issue objects, defaults to 0"
-// @Success 200 {object} []models.Issue
+// @Param	cursor		query	string	false		"Next cursor of the previous page, used instead of the offset"
+// @Param	status		query	string	false		"Comma-separated statuses to include"
+// @Param	severity		query	string	false		"Comma-separated severities to include"
+// @Param	creator		query	string	false		"Username of the reporter"
+// @Param	created_after		query	string	false		"Only issues reported at or after this date or time"
+// @Param	created_before		query	string	false		"Only issues reported before this date or time"
+// @Param	q		query	string	false		"Text to find in the book title or description"
+// @Param	sort		query	string	false		"id, created_at, updated_at, book_title or status, defaults to id"
+// @Param	order		query	string	false		"asc or desc, defaults to desc"
+// @Success 200 {object} models.Page[models.Issue]
+// @Failure 400 invalid filter or cursor
 // @Failure 403 Unauthorized
 // @router / [get]
 func (i *IssueController) GetAll() {
 	user := middlewares.GetUser(i.Ctx)
 
-	limit, err := i.GetInt("limit", 20)
+	limit, offset, after, err := pageParams(&i.Controller)
 	if err != nil {
-		limit = 20
+		i.Ctx.Output.SetStatus(http.StatusBadRequest)
+		i.Data["json"] = map[string]string{"error": "Invalid cursor."}
+		i.ServeJSON()
+		return
 	}
 
-	offset, err := i.GetInt("offset", 0)
+	filter, err := i.issueFilter()
 	if err != nil {
-		offset = 0
+		i.Ctx.Output.SetStatus(http.StatusBadRequest)
+		i.Data["json"] = map[string]string{"error": err.Error()}
+		i.ServeJSON()
+		return
 	}
 
 	issueRepository := models.NewIssueRepository(database.DB)
 
 	// Set creator ID if the user isn't admin/root
-	var creatorID *string = nil
-	if user.Type != "root" && user.Type != "admin" {
-		creatorID = &user.ID
+	if !user.IsAdmin() {
+		filter.CreatorID = &user.ID
 	}
 
-	issues, err := issueRepository.GetIssues(limit, offset, creatorID)
-	if err != nil {
+	page, err := issueRepository.ListIssues(filter, limit, offset, after)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		i.Ctx.Output.SetStatus(http.StatusBadRequest)
+		i.Data["json"] = map[string]string{"error": "The cursor doesn't match the sort order."}
+		i.ServeJSON()
+		return
+	} else if err != nil {
 		i.Ctx.Output.SetStatus(http.StatusInternalServerError)
 		i.Data["json"] = map[string]string{"error": "Unable to retrieve issues due to an internal server erroi."}
 		i.ServeJSON()
 		return
 	}
 
-	i.Data["json"] = issues
+	i.Data["json"] = page
 	i.ServeJSON()
 }
 
+// issueFilter reads the listing filters from the query string.
+func (i *IssueController) issueFilter() (models.IssueFilter, error) {
+	filter := models.IssueFilter{
+		Creator: i.GetString("creator"),
+		Search:  i.GetString("q"),
+	}
+	for _, status := range queryList(&i.Controller, "status") {
+		filter.Statuses = append(filter.Statuses, models.IssueStatus(status))
+	}
+	for _, severity := range queryList(&i.Controller, "severity") {
+		filter.Severities = append(filter.Severities, models.IssueSeverity(severity))
+	}
+
+	var err error
+	if filter.CreatedAfter, err = queryTime(&i.Controller, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = queryTime(&i.Controller, "created_before"); err != nil {
+		return filter, err
+	}
+
+	filter.Sort, err = models.ParseSort(i.GetString("sort"), i.GetString("order"), models.IssueSortFields)
+	return filter, err
+}
+
 // @Title GetIssueByID
 // @Description get issue by id
 // @Param	id		path 	string	true		"The Issue ID"
@@ -117,7 +163,7 @@ func (i *IssueController) Get() {
 		return
 	}
 
-	if issue.CreatorID != user.ID && user.Type != "root" && user.Type != "admin" {
+	if issue.CreatorID != user.ID && !user.IsAdmin() {
 		i.Ctx.Output.SetStatus(http.StatusForbidden)
 		i.Data["json"] = map[string]string{"error": "Access denied."}
 		i.ServeJSON()
@@ -151,7 +197,7 @@ func (i *IssueController) Patch() {
 		return
 	}
 
-	if issue.CreatorID != user.ID && user.Type != "root" && user.Type != "admin" {
+	if issue.CreatorID != user.ID && !user.IsAdmin() {
 		i.Ctx.Output.SetStatus(http.StatusForbidden)
 		i.Data["json"] = map[string]string{"error": "Access denied."}
 		i.ServeJSON()
@@ -167,6 +213,8 @@ func (i *IssueController) Patch() {
 		return
 	}
 
+	issueUpdate.Actor = models.Actor{ID: user.ID, Username: user.Username}
+
 	// Store original status for comparison
 	originalStatus := issue.Status
 
@@ -217,7 +265,7 @@ func (i *IssueController) Delete() {
 		return
 	}
 
-	if issue.CreatorID != user.ID && user.Type != "root" && user.Type != "admin" {
+	if issue.CreatorID != user.ID && !user.IsAdmin() {
 		i.Ctx.Output.SetStatus(http.StatusForbidden)
 		i.Data["json"] = map[string]string{"error": "Access denied."}
 		i.ServeJSON()
@@ -234,3 +282,41 @@ func (i *IssueController) Delete() {
 
 	i.Ctx.Output.SetStatus(http.StatusNoContent)
 }
+
+// @Title GetHistory
+// @Description get the status history of an issue
+// @Param	id		path 	string	true		"The Issue ID"
+// @Success 200 {object} []models.StatusEvent
+// @Failure 404 id not found
+// @router /:id/history [get]
+func (i *IssueController) GetHistory() {
+	user := middlewares.GetUser(i.Ctx)
+
+	id := i.GetString(":id")
+
+	issue, err := models.NewIssueRepository(database.DB).GetIssue(id)
+	if err != nil {
+		i.Ctx.Output.SetStatus(http.StatusNotFound)
+		i.Data["json"] = map[string]string{"error": "No issue found with that id."}
+		i.ServeJSON()
+		return
+	}
+
+	if issue.CreatorID != user.ID && !user.IsAdmin() {
+		i.Ctx.Output.SetStatus(http.StatusForbidden)
+		i.Data["json"] = map[string]string{"error": "Access denied."}
+		i.ServeJSON()
+		return
+	}
+
+	events, err := models.NewEventRepository(database.DB).GetIssueEvents(issue.ID)
+	if err != nil {
+		i.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		i.Data["json"] = map[string]string{"error": "Unable to retrieve issue history due to an internal server error."}
+		i.ServeJSON()
+		return
+	}
+
+	i.Data["json"] = events
+	i.ServeJSON()
+}
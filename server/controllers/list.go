@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"api/models"
+	"fmt"
+	"strings"
+	"time"
+
+	beego "github.com/beego/beego/v2/server/web"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// pageParams reads limit, offset and cursor from the query string. The limit
+// defaults to 20 and is at most 100. A cursor takes precedence over the offset.
+func pageParams(c *beego.Controller) (limit, offset int, after *models.Cursor, err error) {
+	limit, err = c.GetInt("limit", defaultPageLimit)
+	if err != nil || limit < 1 {
+		limit = defaultPageLimit
+	}
+	limit = min(limit, maxPageLimit)
+
+	offset, err = c.GetInt("offset", 0)
+	if err != nil || offset < 0 {
+		offset = 0
+	}
+
+	if cursor := c.GetString("cursor"); cursor != "" {
+		after, err = models.DecodeCursor(cursor)
+		return limit, 0, after, err
+	}
+	return limit, offset, nil, nil
+}
+
+// queryList splits a comma-separated query parameter, such as
+// ?download_status=failure,pending.
+func queryList(c *beego.Controller, key string) []string {
+	var values []string
+	for _, value := range strings.Split(c.GetString(key), ",") {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// queryTime parses a query parameter holding an RFC 3339 time or a date, such
+// as 2025-01-31, which is midnight UTC. A missing parameter is nil.
+func queryTime(c *beego.Controller, key string) (*time.Time, error) {
+	value := strings.TrimSpace(c.GetString(key))
+	if value == "" {
+		return nil, nil
+	}
+
+	for _, layout := range []string{time.RFC3339, time.DateOnly} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return &t, nil
+		}
+	}
+	return nil, fmt.Errorf("%s must be a date like 2025-01-31 or an RFC 3339 time", key)
+}
@@ -7,9 +7,14 @@ import (
 	"api/middlewares"
 	"api/models"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/beego/beego/v2/core/config"
 	"github.com/beego/beego/v2/core/logs"
 	beego "github.com/beego/beego/v2/server/web"
 )
@@ -23,20 +28,88 @@ type RequestController struct {
 // @Description create bookRequests
 // @Param	body		body 	models.BookRequest	true		"body for bookRequest content"
 // @Success 201 {object} models.BookRequest
+// @Success 200 {object} models.BookRequest an open request for the same book was followed
 // @Failure 403 body is empty
+// @Failure 409 book is already in the library
+// @Failure 429 the user's request quota is used up
 // @router / [post]
 func (r *RequestController) Post() {
-	bookRequest := new(models.BookRequest)
-	if err := json.Unmarshal(r.Ctx.Input.RequestBody, &bookRequest); err != nil {
+	user := middlewares.GetUser(r.Ctx)
+	bookRequest, err := parseBookRequest(r.Ctx.Input.RequestBody, user)
+	if err != nil {
 		logs.Warn("Error unmarshalling CreateBookRequest body: %v\n", err)
 		r.Ctx.Output.SetStatus(http.StatusBadRequest)
 		r.Data["json"] = map[string]string{"error": "Unable to parse book request in body."}
 		r.ServeJSON()
 		return
 	}
+	if !bookRequest.MediaType.IsValid() {
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "Media type must be ebook, audiobook or both."}
+		r.ServeJSON()
+		return
+	}
+
+	// Check whether the book is already in the library before requesting it
+	policy := strings.ToLower(config.DefaultString("requests::inlibrary", "warn"))
+	if policy != "allow" {
+		item, err := helpers.FindRequestInLibrary(r.Ctx.Request.Context(), bookRequest, user.Token)
+		if err != nil {
+			logs.Warn("Unable to check audiobookshelf library for requested book: %v\n", err)
+		} else if item != nil {
+			if policy == "reject" {
+				r.Ctx.Output.SetStatus(http.StatusConflict)
+				r.Data["json"] = map[string]string{
+					"error":           "This book is already in the library.",
+					"library_item_id": item.ID,
+				}
+				r.ServeJSON()
+				return
+			}
+			bookRequest.Warnings = append(bookRequest.Warnings,
+				fmt.Sprintf("This book appears to already be in the library (item %s).", item.ID))
+		}
+	}
 
 	requestRepository := models.NewRequestRepository(database.DB)
 
+	// Follow an open request for the same book rather than creating another one
+	duplicate, err := requestRepository.FindDuplicateBookRequest(bookRequest)
+	if err != nil {
+		logs.Warn("Unable to check for duplicate book requests: %v\n", err)
+	} else if duplicate != nil {
+		r.followBookRequest(duplicate, bookRequest, requestRepository)
+		return
+	}
+
+	quota, ok := r.userQuota(user, requestRepository)
+	if !ok {
+		return
+	}
+	if quota.Exceeded() {
+		r.Ctx.Output.Header("Retry-After", strconv.Itoa(int(time.Until(quota.ResetsAt).Seconds())+1))
+		r.Ctx.Output.SetStatus(http.StatusTooManyRequests)
+		r.Data["json"] = map[string]any{
+			"error": fmt.Sprintf("You have used all %d of your requests this %s. Your quota resets at %s.",
+				quota.Limit, quota.Period, quota.ResetsAt.Format(time.RFC1123)),
+			"limit":     quota.Limit,
+			"period":    quota.Period,
+			"resets_at": quota.ResetsAt,
+		}
+		r.ServeJSON()
+		return
+	}
+
+	// Look up what the approval rules need now, rather than while the request is being stored
+	bookRequest.Approval = &models.ApprovalContext{Requestor: user}
+	if models.NeedsLibrary(models.ConfiguredApprovalRules()) {
+		inLibrary, err := helpers.AuthorInLibrary(r.Ctx.Request.Context(), bookRequest.Author, user.Token)
+		if err != nil {
+			logs.Warn("Unable to check audiobookshelf library for the requested author: %v\n", err)
+		}
+		bookRequest.Approval.AuthorInLibrary = inLibrary
+	}
+
 	request, err := requestRepository.CreateBookRequest(bookRequest)
 	if err != nil {
 		logs.Warn("Error creating BookRequest: %v\n", err)
@@ -52,7 +125,7 @@ func (r *RequestController) Post() {
 	notifications.SendAdminNotification(title, body)
 
 	if request.ApprovalStatus == models.ASApproved {
-		request = helpers.HandleDownload(request, requestRepository)
+		request = helpers.EnqueueDownload(request)
 	}
 
 	r.Data["json"] = *request
@@ -61,11 +134,112 @@ func (r *RequestController) Post() {
 	r.ServeJSON()
 }
 
+// parseBookRequest reads a new book request made by the user from the body,
+// dropping the fields the user can't set.
+func parseBookRequest(body []byte, user *models.User) (*models.BookRequest, error) {
+	bookRequest := new(models.BookRequest)
+	if err := json.Unmarshal(body, bookRequest); err != nil {
+		return nil, err
+	}
+	if bookRequest.MediaType == "" {
+		bookRequest.MediaType = models.MTEbook
+	}
+
+	// Users only follow requests by asking for the same book; see AddFollower
+	bookRequest.Followers = nil
+
+	// Only admins can request books for other users, so users can't get around
+	// their quota, or write the notes and denial reasons admins set
+	if !user.IsAdmin() {
+		bookRequest.RequestorID, bookRequest.RequestorUsername = user.ID, user.Username
+		bookRequest.AdminNote = nil
+		bookRequest.DenialReason = nil
+	}
+
+	return bookRequest, nil
+}
+
+// followBookRequest attaches the requestor of bookRequest to the existing
+// duplicate request and serves the existing request.
+func (r *RequestController) followBookRequest(duplicate, bookRequest *models.BookRequest, requestRepository models.RequestRepository) {
+	warnings := bookRequest.Warnings
+
+	if duplicate.HasFollower(bookRequest.RequestorID) {
+		warnings = append(warnings, fmt.Sprintf("You have already requested this book (request #%d).", duplicate.ID))
+	} else {
+		request, err := requestRepository.AddFollower(duplicate, bookRequest.RequestorID, bookRequest.RequestorUsername)
+		if err != nil {
+			logs.Warn("Error following BookRequest #%d: %v\n", duplicate.ID, err)
+			r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+			r.Data["json"] = map[string]string{"error": "Internal Server error occurred while creating book request."}
+			r.ServeJSON()
+			return
+		}
+		duplicate = request
+
+		logs.Info("%s is now following book request #%d.", bookRequest.RequestorUsername, duplicate.ID)
+		title := fmt.Sprintf("➕📔 request #%d followed on Seeklit by %s", duplicate.ID, bookRequest.RequestorUsername)
+		body := fmt.Sprintf(`%s by %s`, duplicate.Title, duplicate.Author)
+		notifications.SendAdminNotification(title, body)
+
+		warnings = append(warnings, fmt.Sprintf("This book was already requested (request #%d). You'll be notified when it's updated.", duplicate.ID))
+	}
+
+	duplicate.Warnings = warnings
+	if !middlewares.GetUser(r.Ctx).IsAdmin() {
+		duplicate.HideAdminNote()
+	}
+	r.Data["json"] = *duplicate
+
+	r.Ctx.Output.SetStatus(http.StatusOK)
+	r.ServeJSON()
+}
+
+// userQuota looks up how many more books the user can request this period,
+// serving an error when it can't.
+func (r *RequestController) userQuota(user *models.User, requestRepository models.RequestRepository) (*models.Quota, bool) {
+	quota, err := models.ConfiguredQuotaPolicy().QuotaFor(user, requestRepository, time.Now())
+	if err != nil {
+		logs.Warn("Error checking the request quota of %s: %v\n", user.Username, err)
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Internal Server error occurred while checking your request quota."}
+		r.ServeJSON()
+		return nil, false
+	}
+	return quota, true
+}
+
+// @Title GetQuota
+// @Description get how many more books the user can request this period
+// @Success 200 {object} models.Quota
+// @router /quota [get]
+func (r *RequestController) GetQuota() {
+	user := middlewares.GetUser(r.Ctx)
+
+	quota, ok := r.userQuota(user, models.NewRequestRepository(database.DB))
+	if !ok {
+		return
+	}
+
+	r.Data["json"] = quota
+	r.ServeJSON()
+}
+
 // @Title GetAllBookRequests
 // @Description Retrieve all book request objects from the database.
 // @Param	limit		query	int		false		"Limit of book request objects, defaults to 20"
 // @Param	offset		query	int		false		"Offset of book request objects, defaults to 0"
-// @Success 200 {object} []models.BookRequest
+// @Param	cursor		query	string	false		"Next cursor of the previous page, used instead of the offset"
+// @Param	approval_status		query	string	false		"Comma-separated approval statuses to include"
+// @Param	download_status		query	string	false		"Comma-separated download statuses to include"
+// @Param	requestor		query	string	false		"Username of the requestor"
+// @Param	created_after		query	string	false		"Only requests made at or after this date or time"
+// @Param	created_before		query	string	false		"Only requests made before this date or time"
+// @Param	q		query	string	false		"Text to find in the title or author"
+// @Param	sort		query	string	false		"id, created_at, updated_at, title or author, defaults to id"
+// @Param	order		query	string	false		"asc or desc, defaults to desc"
+// @Success 200 {object} models.Page[models.BookRequest]
+// @Failure 400 invalid filter or cursor
 // @Failure 403 Unauthorized
 // @router / [get]
 func (r *RequestController) GetAll() {
@@ -73,36 +247,77 @@ func (r *RequestController) GetAll() {
 
 	logs.Info("User info: %v\n", user)
 
-	limit, err := r.GetInt("limit", 20)
+	limit, offset, after, err := pageParams(&r.Controller)
 	if err != nil {
-		limit = 20
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "Invalid cursor."}
+		r.ServeJSON()
+		return
 	}
 
-	offset, err := r.GetInt("offset", 0)
+	filter, err := r.requestFilter()
 	if err != nil {
-		offset = 0
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": err.Error()}
+		r.ServeJSON()
+		return
 	}
 
 	requestRepository := models.NewRequestRepository(database.DB)
 
 	// Set requestor ID if the user isn't admin/root
-	var requestorID *string = nil
-	if user.Type != "root" && user.Type != "admin" {
-		requestorID = &user.ID
+	if !user.IsAdmin() {
+		filter.RequestorID = &user.ID
 	}
 
-	bookRequests, err := requestRepository.GetBookRequests(limit, offset, requestorID)
-	if err != nil {
+	page, err := requestRepository.ListBookRequests(filter, limit, offset, after)
+	if errors.Is(err, models.ErrInvalidCursor) {
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "The cursor doesn't match the sort order."}
+		r.ServeJSON()
+		return
+	} else if err != nil {
 		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
 		r.Data["json"] = map[string]string{"error": "Unable to retrieve book requests due to an internal server error."}
 		r.ServeJSON()
 		return
 	}
 
-	r.Data["json"] = bookRequests
+	if filter.RequestorID != nil {
+		for i := range page.Items {
+			page.Items[i].HideAdminNote()
+		}
+	}
+
+	r.Data["json"] = page
 	r.ServeJSON()
 }
 
+// requestFilter reads the listing filters from the query string.
+func (r *RequestController) requestFilter() (models.RequestFilter, error) {
+	filter := models.RequestFilter{
+		Requestor: r.GetString("requestor"),
+		Search:    r.GetString("q"),
+	}
+	for _, status := range queryList(&r.Controller, "approval_status") {
+		filter.ApprovalStatuses = append(filter.ApprovalStatuses, models.ApprovalStatus(status))
+	}
+	for _, status := range queryList(&r.Controller, "download_status") {
+		filter.DownloadStatuses = append(filter.DownloadStatuses, models.DownloadStatus(status))
+	}
+
+	var err error
+	if filter.CreatedAfter, err = queryTime(&r.Controller, "created_after"); err != nil {
+		return filter, err
+	}
+	if filter.CreatedBefore, err = queryTime(&r.Controller, "created_before"); err != nil {
+		return filter, err
+	}
+
+	filter.Sort, err = models.ParseSort(r.GetString("sort"), r.GetString("order"), models.RequestSortFields)
+	return filter, err
+}
+
 // @Title GetRequestByID
 // @Description get bookRequest by id
 // @Param	id		path 	string	true		"The Book Request ID"
@@ -111,23 +326,13 @@ func (r *RequestController) GetAll() {
 func (r *RequestController) Get() {
 	user := middlewares.GetUser(r.Ctx)
 
-	id := r.GetString(":id")
-
-	requestRepository := models.NewRequestRepository(database.DB)
-
-	request, err := requestRepository.GetBookRequest(id)
-	if err != nil {
-		r.Ctx.Output.SetStatus(http.StatusNotFound)
-		r.Data["json"] = map[string]string{"error": "No request found with that id."}
-		r.ServeJSON()
+	request, ok := r.visibleRequest(user)
+	if !ok {
 		return
 	}
 
-	if request.RequestorID != user.ID && user.Type != "root" && user.Type != "admin" {
-		r.Ctx.Output.SetStatus(http.StatusForbidden)
-		r.Data["json"] = map[string]string{"error": "Access denied."}
-		r.ServeJSON()
-		return
+	if !user.IsAdmin() {
+		request.HideAdminNote()
 	}
 
 	r.Data["json"] = request
@@ -157,7 +362,7 @@ func (r *RequestController) Patch() {
 		return
 	}
 
-	if request.RequestorID != user.ID && user.Type != "root" && user.Type != "admin" {
+	if request.RequestorID != user.ID && !user.IsAdmin() {
 		r.Ctx.Output.SetStatus(http.StatusForbidden)
 		r.Data["json"] = map[string]string{"error": "Access denied."}
 		r.ServeJSON()
@@ -172,6 +377,22 @@ func (r *RequestController) Patch() {
 		r.ServeJSON()
 		return
 	}
+	if bookRequestUpdate.MediaType != nil && !bookRequestUpdate.MediaType.IsValid() {
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "Media type must be ebook, audiobook or both."}
+		r.ServeJSON()
+		return
+	}
+
+	isAdmin := user.IsAdmin()
+	if !isAdmin && (bookRequestUpdate.DenialReason != nil || bookRequestUpdate.AdminNote != nil) {
+		r.Ctx.Output.SetStatus(http.StatusForbidden)
+		r.Data["json"] = map[string]string{"error": "Only admins can set a denial reason or admin note."}
+		r.ServeJSON()
+		return
+	}
+
+	bookRequestUpdate.Actor = models.Actor{ID: user.ID, Username: user.Username}
 
 	// Store original status for comparison
 	originalApprovalStatus := request.ApprovalStatus
@@ -187,8 +408,8 @@ func (r *RequestController) Patch() {
 	}
 
 	if request.ApprovalStatus == models.ASApproved && request.DownloadStatus == models.DSPending {
-		logs.Info("Request approved, starting search!")
-		request = helpers.HandleDownload(request, requestRepository)
+		logs.Info("Request approved, queueing download!")
+		request = helpers.EnqueueDownload(request)
 	}
 
 	// Send notification for status changes (only one email per update)
@@ -219,6 +440,10 @@ func (r *RequestController) Patch() {
 
 	logs.Info("Book request #%d updated successfully.", request.ID)
 
+	if !isAdmin {
+		request.HideAdminNote()
+	}
+
 	r.Data["json"] = *request
 
 	r.ServeJSON()
@@ -245,7 +470,7 @@ func (r *RequestController) Delete() {
 		return
 	}
 
-	if request.RequestorID != user.ID && user.Type != "root" && user.Type != "admin" {
+	if request.RequestorID != user.ID && !user.IsAdmin() {
 		r.Ctx.Output.SetStatus(http.StatusForbidden)
 		r.Data["json"] = map[string]string{"error": "Access denied."}
 		r.ServeJSON()
@@ -262,3 +487,258 @@ func (r *RequestController) Delete() {
 
 	r.Ctx.Output.SetStatus(http.StatusNoContent)
 }
+
+// @Title GetDownloadAttempts
+// @Description get the download attempt history of a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Success 200 {object} []models.DownloadAttempt
+// @Failure 404 id not found
+// @router /:id/attempts [get]
+func (r *RequestController) GetAttempts() {
+	user := middlewares.GetUser(r.Ctx)
+
+	request, ok := r.visibleRequest(user)
+	if !ok {
+		return
+	}
+
+	attempts, err := models.NewJobRepository(database.DB).GetAttempts(request.ID)
+	if err != nil {
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Unable to retrieve download attempts due to an internal server error."}
+		r.ServeJSON()
+		return
+	}
+
+	r.Data["json"] = attempts
+	r.ServeJSON()
+}
+
+// @Title GetHistory
+// @Description get the status history of a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Success 200 {object} []models.StatusEvent
+// @Failure 404 id not found
+// @router /:id/history [get]
+func (r *RequestController) GetHistory() {
+	user := middlewares.GetUser(r.Ctx)
+
+	request, ok := r.visibleRequest(user)
+	if !ok {
+		return
+	}
+
+	events, err := models.NewEventRepository(database.DB).GetRequestEvents(request.ID)
+	if err != nil {
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Unable to retrieve request history due to an internal server error."}
+		r.ServeJSON()
+		return
+	}
+
+	r.Data["json"] = events
+	r.ServeJSON()
+}
+
+// @Title GetComments
+// @Description get the comment thread of a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Success 200 {object} []models.RequestComment
+// @Failure 404 id not found
+// @router /:id/comments [get]
+func (r *RequestController) GetComments() {
+	user := middlewares.GetUser(r.Ctx)
+
+	request, ok := r.visibleRequest(user)
+	if !ok {
+		return
+	}
+
+	comments, err := models.NewCommentRepository(database.DB).GetComments(request.ID)
+	if err != nil {
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Unable to retrieve comments due to an internal server error."}
+		r.ServeJSON()
+		return
+	}
+
+	r.Data["json"] = comments
+	r.ServeJSON()
+}
+
+// @Title PostComment
+// @Description comment on a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Param	body		body 	models.RequestCommentUpdate	true		"body for comment content"
+// @Success 201 {object} models.RequestComment
+// @Failure 400 comment is empty
+// @Failure 404 id not found
+// @router /:id/comments [post]
+func (r *RequestController) PostComment() {
+	user := middlewares.GetUser(r.Ctx)
+
+	request, ok := r.visibleRequest(user)
+	if !ok {
+		return
+	}
+
+	body, ok := r.commentBody()
+	if !ok {
+		return
+	}
+
+	comment, err := models.NewCommentRepository(database.DB).CreateComment(&models.RequestComment{
+		RequestID:      request.ID,
+		AuthorID:       user.ID,
+		AuthorUsername: user.Username,
+		FromAdmin:      user.IsAdmin(),
+		Body:           body,
+	})
+	if err != nil {
+		logs.Warn("Error creating comment on request #%d: %v\n", request.ID, err)
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Internal Server error occurred while creating comment."}
+		r.ServeJSON()
+		return
+	}
+
+	notifications.SendRequestCommentNotification(request, comment)
+
+	r.Data["json"] = comment
+	r.Ctx.Output.SetStatus(http.StatusCreated)
+	r.ServeJSON()
+}
+
+// @Title UpdateComment
+// @Description edit a comment on a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Param	commentId		path 	string	true		"The comment ID"
+// @Param	body		body 	models.RequestCommentUpdate	true		"body for comment content"
+// @Success 200 {object} models.RequestComment
+// @Failure 403 only the author can edit a comment
+// @Failure 404 id not found
+// @router /:id/comments/:commentId [patch]
+func (r *RequestController) UpdateComment() {
+	user := middlewares.GetUser(r.Ctx)
+
+	comment, ok := r.ownComment(user)
+	if !ok {
+		return
+	}
+
+	body, ok := r.commentBody()
+	if !ok {
+		return
+	}
+
+	updated, err := models.NewCommentRepository(database.DB).UpdateComment(comment, models.RequestCommentUpdate{Body: body})
+	if err != nil {
+		logs.Warn("Error updating comment #%d: %v\n", comment.ID, err)
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Internal Server error occurred while updating comment."}
+		r.ServeJSON()
+		return
+	}
+
+	r.Data["json"] = updated
+	r.ServeJSON()
+}
+
+// @Title DeleteComment
+// @Description delete a comment on a book request
+// @Param	id		path 	string	true		"The Book Request ID"
+// @Param	commentId		path 	string	true		"The comment ID"
+// @Success 204 comment deleted
+// @Failure 403 only the author or an admin can delete a comment
+// @Failure 404 id not found
+// @router /:id/comments/:commentId [delete]
+func (r *RequestController) DeleteComment() {
+	user := middlewares.GetUser(r.Ctx)
+
+	comment, ok := r.ownComment(user)
+	if !ok {
+		return
+	}
+
+	if err := models.NewCommentRepository(database.DB).DeleteComment(comment); err != nil {
+		logs.Warn("Error deleting comment #%d: %v\n", comment.ID, err)
+		r.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		r.Data["json"] = map[string]string{"error": "Internal Server error occurred while deleting comment."}
+		r.ServeJSON()
+		return
+	}
+
+	r.Ctx.Output.SetStatus(http.StatusNoContent)
+}
+
+// visibleRequest loads the request in the :id path parameter, responding with
+// an error unless the user requested, follows or administers it.
+func (r *RequestController) visibleRequest(user *models.User) (*models.BookRequest, bool) {
+	request, err := models.NewRequestRepository(database.DB).GetBookRequest(r.GetString(":id"))
+	if err != nil {
+		r.Ctx.Output.SetStatus(http.StatusNotFound)
+		r.Data["json"] = map[string]string{"error": "No request found with that id."}
+		r.ServeJSON()
+		return nil, false
+	}
+
+	if !request.HasFollower(user.ID) && !user.IsAdmin() {
+		r.Ctx.Output.SetStatus(http.StatusForbidden)
+		r.Data["json"] = map[string]string{"error": "Access denied."}
+		r.ServeJSON()
+		return nil, false
+	}
+
+	return request, true
+}
+
+// ownComment loads the comment in the :commentId path parameter, responding
+// with an error unless the user wrote it. Admins can moderate any comment they
+// can see, but only edit their own.
+func (r *RequestController) ownComment(user *models.User) (*models.RequestComment, bool) {
+	request, ok := r.visibleRequest(user)
+	if !ok {
+		return nil, false
+	}
+
+	comment, err := models.NewCommentRepository(database.DB).GetComment(request.ID, r.GetString(":commentId"))
+	if err != nil {
+		r.Ctx.Output.SetStatus(http.StatusNotFound)
+		r.Data["json"] = map[string]string{"error": "No comment found with that id."}
+		r.ServeJSON()
+		return nil, false
+	}
+
+	isAdmin := user.IsAdmin()
+	if comment.AuthorID != user.ID && !(isAdmin && r.Ctx.Input.Method() == http.MethodDelete) {
+		r.Ctx.Output.SetStatus(http.StatusForbidden)
+		r.Data["json"] = map[string]string{"error": "Access denied."}
+		r.ServeJSON()
+		return nil, false
+	}
+
+	return comment, true
+}
+
+// commentBody parses the comment in the request body, responding with an
+// error when it's missing or blank.
+func (r *RequestController) commentBody() (string, bool) {
+	update := new(models.RequestCommentUpdate)
+	if err := json.Unmarshal(r.Ctx.Input.RequestBody, &update); err != nil {
+		logs.Warn("Error unmarshalling comment body: %v\n", err)
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "Unable to parse comment in body."}
+		r.ServeJSON()
+		return "", false
+	}
+
+	body := strings.TrimSpace(update.Body)
+	if body == "" {
+		r.Ctx.Output.SetStatus(http.StatusBadRequest)
+		r.Data["json"] = map[string]string{"error": "Comment can't be empty."}
+		r.ServeJSON()
+		return "", false
+	}
+
+	return body, true
+}
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"api/models"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseBookRequest(t *testing.T) {
+	Convey("Subject: Reading new book requests from the request body", t, func() {
+		user := &models.User{ID: "user-1", Username: "user", Type: "user"}
+		body := []byte(`{"title": "Dune", "author": "Frank Herbert", "source": "GOOGLE", "source_id": "abc123",
+			"requestor_id": "user-2", "requestor_username": "other",
+			"followers": [{"user_id": "user-3", "username": "stranger"}]}`)
+
+		Convey("Followers in the body are dropped", func() {
+			request, err := parseBookRequest(body, user)
+			So(err, ShouldBeNil)
+			So(request.Followers, ShouldBeEmpty)
+			So(request.HasFollower("user-3"), ShouldBeFalse)
+			So(request.MediaType, ShouldEqual, models.MTEbook)
+		})
+
+		Convey("Users can only request books for themselves", func() {
+			request, err := parseBookRequest(body, user)
+			So(err, ShouldBeNil)
+			So(request.RequestorID, ShouldEqual, "user-1")
+			So(request.RequestorUsername, ShouldEqual, "user")
+
+			request, err = parseBookRequest(body, &models.User{ID: "admin-1", Type: "admin"})
+			So(err, ShouldBeNil)
+			So(request.RequestorID, ShouldEqual, "user-2")
+		})
+
+		Convey("Only admins can set admin notes and denial reasons", func() {
+			body := []byte(`{"title": "Dune", "author": "Frank Herbert", "admin_note": "VIP, approve at once",
+				"denial_reason": "Denied by the admins"}`)
+
+			request, err := parseBookRequest(body, user)
+			So(err, ShouldBeNil)
+			So(request.AdminNote, ShouldBeNil)
+			So(request.DenialReason, ShouldBeNil)
+
+			request, err = parseBookRequest(body, &models.User{ID: "admin-1", Type: "admin"})
+			So(err, ShouldBeNil)
+			So(*request.AdminNote, ShouldEqual, "VIP, approve at once")
+		})
+
+		Convey("Invalid bodies are rejected", func() {
+			_, err := parseBookRequest([]byte(`{"title": `), user)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -1,15 +1,16 @@
 package controllers
 
 import (
+	"api/lib/abs"
+	"api/lib/cache"
+	"api/lib/metadata"
 	"api/middlewares"
 	"api/models"
-	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
-	"fmt"
-	"io"
 	"net/http"
-	"net/url"
-	"time"
 
 	"github.com/beego/beego/v2/core/config"
 	"github.com/beego/beego/v2/core/logs"
@@ -21,310 +22,87 @@ type SearchController struct {
 	beego.Controller
 }
 
-// @Title Google Search
-// @Description perform google search
-// @Param	body		body 	models.Search	true		"body for search content"
-// @Success 200 {object} map[string]any
-// @router /google [post]
-func (s *SearchController) GoogleSearch() {
-	user := middlewares.GetUser(s.Ctx)
-
-	search := new(models.Search)
-	if err := json.Unmarshal(s.Ctx.Input.RequestBody, &search); err != nil {
-		logs.Warn("Error unmarshalling google search body: %v\n", err)
-		s.Ctx.Output.SetStatus(http.StatusBadRequest)
-		s.Data["json"] = map[string]string{"error": "Unable to parse search in body."}
-		s.ServeJSON()
-		return
-	}
-
-	apiKey, err := config.String("metadata::googleapikey")
-	if err != nil {
-		logs.Critical("Missing metadata::googleapikey in config file. Unable to perform book search with Google.")
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		s.Data["json"] = map[string]string{"error": "Unable to perform search."}
-		s.ServeJSON()
-		return
-	}
-
-	// Define the endpoint and query parameters
-	endpoint := "https://www.googleapis.com/books/v1/volumes"
-	params := url.Values{}
-	params.Add("q", search.Query)
-	params.Add("key", apiKey)
-	params.Add("maxResults", "40")
-
-	// Build the full URL with query parameters
-	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
-
-	// Create an HTTP client with a timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// Make the GET request
-	resp, err := client.Get(fullURL)
-	if err != nil {
-		logs.Critical("Error fetching data: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Check if the status code is OK
-	if resp.StatusCode != http.StatusOK {
-		logs.Critical("Error: Received status code %d", resp.StatusCode)
-		return
-	}
+// absCacheProvider namespaces Audiobookshelf search responses in the search cache.
+const absCacheProvider = "ABS"
 
-	// Decode the response body into a struct
-	var data models.GoogleBooksResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		logs.Critical("Error decoding JSON response: %v", err)
-		return
-	}
-
-	absToken := config.DefaultString("general::audiobookshelfapikey", user.Token)
-	absResults, err := handleAbsSearch(absToken, search.Query)
-	if err != nil {
-		logs.Warn("Unable to complete audiobookshelf search.")
-		absResults = []any{}
-	}
-
-	// Return the search results
-	result := map[string]any{
-		"search_results": data.Items,
-		"abs_results":    absResults,
-	}
-
-	s.Data["json"] = result
-
-	s.ServeJSON()
+// absCacheProviderFor namespaces Audiobookshelf responses by the token that
+// fetched them, since users' tokens can give access to different libraries.
+func absCacheProviderFor(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return absCacheProvider + ":" + hex.EncodeToString(sum[:8])
 }
 
-// @Title OpenLibrary Search
-// @Description perform Open Library search
+// @Title Search
+// @Description perform a book search with the configured metadata provider
 // @Param	body		body 	models.Search	true		"body for search content"
 // @Success 200 {object} map[string]any
-// @router /openlib [post]
-func (s *SearchController) OpenLibrarySearch() {
+// @router / [post]
+func (s *SearchController) Search() {
 	user := middlewares.GetUser(s.Ctx)
 
 	search := new(models.Search)
 	if err := json.Unmarshal(s.Ctx.Input.RequestBody, &search); err != nil {
-		logs.Warn("Error unmarshalling Open Library search body: %v\n", err)
+		logs.Warn("Error unmarshalling search body: %v\n", err)
 		s.Ctx.Output.SetStatus(http.StatusBadRequest)
 		s.Data["json"] = map[string]string{"error": "Unable to parse search in body."}
 		s.ServeJSON()
 		return
 	}
-
-	// Define the endpoint and query parameters
-	endpoint := "https://openlibrary.org/search.json"
-	params := url.Values{}
-	params.Add("q", search.Query)
-	params.Add("fields", "seed,author_name,title,cover_i")
-	params.Add("limit", "40")
-
-	// Build the full URL with query parameters
-	fullURL := fmt.Sprintf("%s?%s", endpoint, params.Encode())
-
-	// Create an HTTP client with a timeout
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-
-	// Make the GET request
-	resp, err := client.Get(fullURL)
-	if err != nil {
-		logs.Critical("Error fetching data: %v", err)
-		return
-	}
-	defer resp.Body.Close()
-
-	// Check if the status code is OK
-	if resp.StatusCode != http.StatusOK {
-		logs.Critical("Error: Received status code %d", resp.StatusCode)
-		return
-	}
-
-	// Decode the response body into a struct
-	var data models.OpenLibraryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		logs.Critical("Error decoding JSON response: %v", err)
-		return
-	}
-
-	for id := range data.Docs {
-		data.Docs[id].SetInfoLink()
-		data.Docs[id].SetCoverImage()
-	}
-
-	absToken := config.DefaultString("general::audiobookshelfapikey", user.Token)
-	absResults, err := handleAbsSearch(absToken, search.Query)
-	if err != nil {
-		logs.Warn("Unable to complete audiobookshelf search.")
-		absResults = []any{}
-	}
-
-	// Return the search results
-	result := map[string]any{
-		"search_results": data,
-		"abs_results":    absResults,
-	}
-
-	s.Data["json"] = result
-
-	s.ServeJSON()
-}
-
-// @Title Hardcover Search **UNSTABLE**
-// @Description perform Hardcover search
-// @Param	body		body 	models.Search	true		"body for search content"
-// @Success 200 {object} map[string]any
-// @router /hardcover [post]
-func (s *SearchController) HardcoverSearch() {
-	user := middlewares.GetUser(s.Ctx)
-
-	search := new(models.Search)
-	if err := json.Unmarshal(s.Ctx.Input.RequestBody, &search); err != nil {
-		logs.Warn("Error unmarshalling Open Library search body: %v\n", err)
+	if search.MediaType != "" && !search.MediaType.IsValid() {
 		s.Ctx.Output.SetStatus(http.StatusBadRequest)
-		s.Data["json"] = map[string]string{"error": "Unable to parse search in body."}
+		s.Data["json"] = map[string]string{"error": "Media type must be ebook, audiobook or both."}
 		s.ServeJSON()
 		return
 	}
 
-	bearerToken, err := config.String("metadata::hardcoverbearertoken")
+	providerName := config.DefaultString("metadata::provider", "OPENLIBRARY")
+	provider, err := metadata.New(providerName)
 	if err != nil {
-		logs.Critical("Missing metadata::hardcoverbearertoken in config file. Unable to perform book search with Hardcover.")
+		logs.Critical("Unable to load metadata provider %s: %v", providerName, err)
 		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
 		s.Data["json"] = map[string]string{"error": "Unable to perform search."}
 		s.ServeJSON()
 		return
 	}
 
-	query := `
-    query BookSearch($search: String!) {
-      books(
-        where: {title: {_ilike: $search}, users_read_count: {_gt: 0}}
-        order_by: [{users_count: desc_nulls_last}, {description: desc_nulls_last}]
-        limit: 40
-      ) {
-        title
-        id
-        slug
-        users_read_count
-        users_count
-        cached_image
-        description
-        contributions {
-          author {
-            name
-            id
-          }
-        }
-        images {
-          url
-        }
-        default_physical_edition {
-          isbn_10
-          isbn_13
-        }
-      }
-    }
-    `
-
-	variables := map[string]string{
-		"search": "%" + search.Query + "%",
-	}
+	searchCache := cache.GetSearchCache()
 
-	payload := map[string]any{
-		"query":     query,
-		"variables": variables,
-	}
-
-	payloadBytes, err := json.Marshal(payload)
-	if err != nil {
-		logs.Critical("Error marshalling JSON payload: %v", err)
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		s.Data["json"] = map[string]string{"error": "Failed to create request payload."}
-		s.ServeJSON()
-		return
-	}
-
-	// Define the endpoint and query parameters
-	fullURL := "https://hardcover-hasura-production-1136269bb9de.herokuapp.com/v1/graphql"
-	headers := map[string]string{
-		"Content-Type":  "application/json",
-		"authorization": fmt.Sprintf("Bearer %s", bearerToken), // Add your authorization header if needed
-	}
-
-	// Create a new HTTP POST request
-	req, err := http.NewRequest("POST", fullURL, bytes.NewBuffer(payloadBytes))
-	if err != nil {
-		logs.Critical("Error creating HTTP request: %v", err)
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		s.Data["json"] = map[string]string{"error": "Failed to create HTTP request."}
-		s.ServeJSON()
-		return
-	}
-
-	// Set headers
-	for key, value := range headers {
-		req.Header.Set(key, value)
-		logs.Info("Header %s: %s", key, value)
-	}
-
-	// Create an HTTP client and send the request
-	client := &http.Client{
-		Timeout: 10 * time.Second,
-	}
-	resp, err := client.Do(req)
-	if err != nil {
-		logs.Critical("Error sending HTTP request: %v", err)
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		s.Data["json"] = map[string]string{"error": "Failed to fetch data."}
-		s.ServeJSON()
-		return
-	}
-	defer resp.Body.Close()
-
-	// Check if the status code is OK
-	if resp.StatusCode != http.StatusOK {
-		logs.Critical("Error: Received status code %d", resp.StatusCode)
-		return
-	}
-
-	// Decode the response body into a struct
-	var data models.HardcoverResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		logs.Critical("Error decoding JSON response: %v", err)
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		return
-	}
-
-	if data.Errors != nil {
-		logs.Critical("Error response from hardcover: %v", data.Errors)
-		s.Ctx.Output.SetStatus(http.StatusInternalServerError)
-		return
+	var searchResults []models.BookResult
+	if !searchCache.Get(provider.Name(), search.Query, &searchResults) {
+		searchResults, err = provider.Search(s.Ctx.Request.Context(), search.Query)
+		if err != nil {
+			logs.Critical("Error performing %s search: %v", provider.Name(), err)
+			s.Ctx.Output.SetStatus(http.StatusInternalServerError)
+			s.Data["json"] = map[string]string{"error": "Failed to fetch data."}
+			s.ServeJSON()
+			return
+		}
+		searchCache.Set(provider.Name(), search.Query, searchResults)
 	}
 
-	for id, book := range data.Data.Books {
-		if book.CachedImage != nil {
-			data.Data.Books[id].Images = []models.HardcoverImage{{URL: book.CachedImage.URL}}
+	var absResults []abs.BookItem
+	absToken := config.DefaultString("general::audiobookshelfapikey", user.Token)
+	absCacheNamespace := absCacheProviderFor(absToken)
+	if !searchCache.Get(absCacheNamespace, search.Query, &absResults) {
+		absResults, err = handleAbsSearch(s.Ctx.Request.Context(), absToken, search.Query)
+		if err != nil {
+			logs.Warn("Unable to complete audiobookshelf search. %v", err)
+			absResults = []abs.BookItem{}
+		} else {
+			searchCache.Set(absCacheNamespace, search.Query, absResults)
 		}
 	}
 
-	absToken := config.DefaultString("general::audiobookshelfapikey", user.Token)
-	absResults, err := handleAbsSearch(absToken, search.Query)
-	if err != nil {
-		logs.Warn("Unable to complete audiobookshelf search.")
-		absResults = []any{}
+	if search.MediaType != "" {
+		absResults = abs.FilterByMedia(absResults, search.MediaType.WantsEbook(), search.MediaType.WantsAudiobook())
 	}
 
+	annotateLibraryMatches(searchResults, absResults)
+
 	// Return the search results
 	result := map[string]any{
-		"search_results": data.Data.Books,
+		"provider":       provider.Name(),
+		"search_results": searchResults,
 		"abs_results":    absResults,
 	}
 
@@ -351,10 +129,10 @@ func (s *SearchController) PersonalizedSearch() {
 	}
 	token := absApiKey
 
-	absResults, err := handleAbsPersonalizedSearch(token)
+	absResults, err := handleAbsPersonalizedSearch(s.Ctx.Request.Context(), token)
 	if err != nil {
 		logs.Warn("Unable to complete audiobookshelf personalized search. %v", err)
-		absResults = []any{}
+		absResults = []abs.BookItem{}
 	}
 
 	// Return the search results
@@ -367,302 +145,48 @@ func (s *SearchController) PersonalizedSearch() {
 	s.ServeJSON()
 }
 
-func handleAbsSearch(token, query string) ([]any, error) {
-	var booksInLibrary []any
-
-	libraryIds, err := getLibraries(token)
-	if err != nil {
-		logs.Warning("Unable to retrieve libraries")
-		return nil, err
-	}
-
-	booksInLibrary, err = searchLibraries(token, libraryIds, query)
-	if err != nil {
-		logs.Warning("Unable to search libraries")
-		return nil, err
-	}
-
-	if booksInLibrary == nil {
-		booksInLibrary = []any{}
-	}
-
-	return booksInLibrary, nil
-}
-
-func handleAbsPersonalizedSearch(token string) ([]any, error) {
-	var booksInLibrary []any
-
-	libraryIds, err := getLibraries(token)
-	if err != nil {
-		logs.Warning("Unable to retrieve libraries")
-		return nil, err
-	}
-
-	for _, id := range libraryIds {
-		logs.Debug("Getting recently added books for library: %s", id)
-		recentlyAddedBooks, err := getPersonalizedLibrary(token, id)
-		if err != nil {
-			logs.Debug("Unable to search libraries %v", err)
-			continue
-		}
-
-		if recentlyAddedBooks != nil {
-			booksInLibrary = append(booksInLibrary, recentlyAddedBooks...)
-		}
-	}
-
-	if booksInLibrary == nil {
-		booksInLibrary = []any{}
-	}
-
-	return booksInLibrary, nil
-}
-
-func getPersonalizedLibrary(token, libraryId string) ([]any, error) {
-	absUrl, err := config.String("general::audiobookshelfurl")
-	if err != nil || absUrl == "" {
-		logs.Critical("Missing general::audiobookshelfurl config... Unable to access Audiobookshelf data.")
-		return []any{}, fmt.Errorf("missing general::audiobookshelfurl config")
-	}
-
-	url := fmt.Sprintf("%s/audiobookshelf/api/libraries/%s/personalized", absUrl, libraryId)
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		logs.Critical("Error creating request: %v", err)
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		logs.Critical("Error fetching libraries: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error: Received status code %d", resp.StatusCode)
-	}
-
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	// Unmarshal into an anonymous struct to grab "recently-added"
-	var response []struct {
-		ID       string `json:"id"`
-		Entities []any  `json:"entities"`
-	}
-
-	if err := json.Unmarshal(body, &response); err != nil {
-		return nil, fmt.Errorf("error unmarshaling response: %w", err)
-	}
-
-	// Step 2: Build []map[string]any to return BookItem-style objects
-	var results []any
-
-	for _, section := range response {
-		if section.ID == "recently-added" {
-			for _, entity := range section.Entities {
-				book := map[string]any{
-					"libraryItem": entity,
-				}
-				results = append(results, book)
-			}
-			break
-		}
-	}
-
-	if len(results) == 0 {
-		return nil, fmt.Errorf("no recently added items found")
-	}
-
-	return results, nil
-}
-
-func getLibraries(token string) ([]string, error) {
-	logs.Info("Retrieving Audiobookshelf libraries...")
-	absUrl, err := config.String("general::audiobookshelfurl")
-	if err != nil || absUrl == "" {
-		logs.Critical("Missing general::audiobookshelfurl config... Unable to access Audiobookshelf data.")
-		return []string{}, fmt.Errorf("Missing general::audiobookshelfurl config")
-	}
-
-	url := fmt.Sprintf("%s/api/libraries", absUrl)
-	req, err := http.NewRequest("GET", url, nil)
+func handleAbsSearch(ctx context.Context, token, query string) ([]abs.BookItem, error) {
+	client, err := abs.NewClientFromConfig(token)
 	if err != nil {
-		logs.Critical("Error creating request: %v", err)
 		return nil, err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		logs.Critical("Error fetching libraries: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Error: Received status code %d", resp.StatusCode)
-	}
-
-	var res map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		logs.Critical("Error decoding response: %v", err)
-		return nil, err
-	}
-
-	// Extract library IDs from the raw JSON response
-	libraries, ok := res["libraries"].([]any)
-	if !ok {
-		return nil, fmt.Errorf("Invalid response format, 'libraries' not found")
-	}
-
-	var libraryIds []string
-	for _, library := range libraries {
-		lib, ok := library.(map[string]any)
-		if !ok {
-			logs.Info("Invalid library entry format")
-			continue
-		}
-		id, ok := lib["id"].(string)
-		if !ok {
-			logs.Info("Invalid library ID format")
-			continue
-		}
-		libraryIds = append(libraryIds, id)
-	}
-
-	return libraryIds, nil
+	return client.Search(ctx, query)
 }
 
-func getAuthorBooks(token, authorID string) ([]any, error) {
-	logs.Info("Retrieving books for author...")
-	absUrl, err := config.String("general::audiobookshelfurl")
-	if err != nil || absUrl == "" {
-		logs.Critical("Missing general::audiobookshelfurl config... Unable to access Audiobookshelf data.")
-		return nil, fmt.Errorf("Missing general::audiobookshelfurl config")
-	}
-
-	params := url.Values{}
-	params.Add("include", "items")
-
-	// Build the full URL with query parameters
-	fullUrl := fmt.Sprintf("%s/api/authors/%s?%s", absUrl, authorID, params.Encode())
-	req, err := http.NewRequest("GET", fullUrl, nil)
+func handleAbsPersonalizedSearch(ctx context.Context, token string) ([]abs.BookItem, error) {
+	client, err := abs.NewClientFromConfig(token)
 	if err != nil {
-		logs.Critical("Error creating request: %v", err)
-		return nil, err
-	}
-
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		logs.Critical("Error fetching author books: %v", err)
-		return nil, err
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Error: Received status code %d", resp.StatusCode)
-	}
-
-	var res map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		logs.Critical("Error decoding response: %v", err)
 		return nil, err
 	}
 
-	// Extract books from the raw JSON response
-	books, ok := res["libraryItems"].([]any)
-	if !ok {
-		logs.Info("Invalid response format, 'libraryItems' not found")
-		return nil, fmt.Errorf("Invalid response format, 'libraryItems' not found")
-	}
-
-	// Format matching library response
-	for id, book := range books {
-		books[id] = map[string]any{"libraryItem": book}
-	}
-
-	return books, nil
+	return client.RecentlyAdded(ctx)
 }
 
-func searchLibraries(token string, libraryIds []string, q string) ([]any, error) {
-	logs.Info("Searching Audiobookshelf libraries for %s...", q)
-	absUrl, err := config.String("general::audiobookshelfurl")
-	if err != nil || absUrl == "" {
-		logs.Critical("Missing general::audiobookshelfurl config... Unable to access Audiobookshelf data.")
-		return nil, fmt.Errorf("Missing general::audiobookshelfurl config")
-	}
-
-	var allBooks []any
-	for _, id := range libraryIds {
-		logs.Info("Performing library search on library ID: %s", id)
-		params := url.Values{}
-		params.Add("q", q)
-
-		// Build the full URL with query parameters
-		absUrl = fmt.Sprintf("%s/api/libraries/%s/search?%s", absUrl, id, params.Encode())
-
-		req, err := http.NewRequest("GET", absUrl, nil)
-		if err != nil {
-			logs.Warn("Error creating request: %v", err)
-			continue
-		}
-
-		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
-		logs.Debug("Query Parameters: %s", params.Encode())
+// annotateLibraryMatches flags the search results that are already in the
+// Audiobookshelf library, matching by ISBN/ASIN or normalized title and author.
+func annotateLibraryMatches(results []models.BookResult, absResults []abs.BookItem) {
+	index := abs.NewLibraryIndex(absResults)
 
-		client := &http.Client{}
-		resp, err := client.Do(req)
-		if err != nil {
-			logs.Critical("Error fetching search results: %v", err)
-			continue
+	for i := range results {
+		result := &results[i]
+		query := abs.BookQuery{Title: result.Title, Author: result.Author}
+		if len(result.Authors) > 0 {
+			query.Author = result.Authors[0]
 		}
-		defer resp.Body.Close()
-
-		if resp.StatusCode != http.StatusOK {
-			logs.Critical("Error: Received status code %d", resp.StatusCode)
-			continue
+		if result.ISBN13 != nil {
+			query.ISBNs = append(query.ISBNs, *result.ISBN13)
 		}
-
-		var searchRes map[string]any
-		if err := json.NewDecoder(resp.Body).Decode(&searchRes); err != nil {
-			logs.Critical("Error decoding search response: %v", err)
-			continue
+		if result.ISBN10 != nil {
+			query.ISBNs = append(query.ISBNs, *result.ISBN10)
 		}
-
-		// Handle books found
-		books, ok := searchRes["book"].([]any)
-		if ok {
-			allBooks = append(allBooks, books...)
+		if result.ASIN != nil {
+			query.ASIN = *result.ASIN
 		}
 
-		// Handle authors found
-		authors, ok := searchRes["authors"].([]any)
-		if ok && len(authors) > 0 {
-			// Assuming we want books from the first author
-			author := authors[0].(map[string]any)
-			authorBooks, err := getAuthorBooks(token, author["id"].(string))
-			if err != nil {
-				logs.Critical("Error retrieving author books: %v", err)
-				continue
-			}
-
-			for _, authorBook := range authorBooks {
-				allBooks = append(allBooks, authorBook)
-			}
+		if item := index.Match(query); item != nil {
+			result.InLibrary = true
+			result.LibraryItemID = &item.ID
 		}
 	}
-
-	return allBooks, nil
 }
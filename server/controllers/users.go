@@ -1,11 +1,12 @@
 package controllers
 
 import (
+	"api/lib/abs"
 	"api/middlewares"
-	"encoding/json"
-	"fmt"
+	"api/models"
 	"net/http"
-	"time"
+	"sort"
+	"strings"
 
 	"github.com/beego/beego/v2/core/config"
 	"github.com/beego/beego/v2/core/logs"
@@ -18,8 +19,12 @@ type UserController struct {
 }
 
 // @Title Get Users
-// @Description Get all users from audiobookshelf (admin/root only)
-// @Success 200 {object} map[string]any
+// @Description Get all users from audiobookshelf (admin/root only), sorted by username
+// @Param	limit		query	int		false		"Limit of users, defaults to 20"
+// @Param	offset		query	int		false		"Offset of users, defaults to 0"
+// @Param	cursor		query	string	false		"Next cursor of the previous page, used instead of the offset"
+// @Success 200 {object} models.Page[abs.User]
+// @Failure 400 {object} map[string]string
 // @Failure 401 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 500 {object} map[string]string
@@ -41,6 +46,14 @@ func (c *UserController) GetUsers() {
 		return
 	}
 
+	limit, offset, after, err := pageParams(&c.Controller)
+	if err != nil || after != nil && after.Field != "username" {
+		c.Ctx.Output.SetStatus(http.StatusBadRequest)
+		c.Data["json"] = map[string]string{"error": "Invalid cursor."}
+		c.ServeJSON()
+		return
+	}
+
 	// For OIDC-only auth, use the configured API key
 	absApiKey := config.DefaultString("general::audiobookshelfapikey", "")
 	if absApiKey == "" {
@@ -52,66 +65,55 @@ func (c *UserController) GetUsers() {
 	}
 	token := absApiKey
 
-	users, err := getAudiobookshelfUsers(token)
+	client, err := abs.NewClientFromConfig(token)
 	if err != nil {
-		logs.Error("Unable to get users from audiobookshelf: %v", err)
 		c.Ctx.Output.SetStatus(http.StatusInternalServerError)
 		c.Data["json"] = map[string]string{"error": "Failed to retrieve users"}
 		c.ServeJSON()
 		return
 	}
 
-	// Return the users in the expected format
-	result := map[string]any{
-		"users": users,
-	}
-
-	c.Data["json"] = result
-	c.ServeJSON()
-}
-
-func getAudiobookshelfUsers(token string) ([]any, error) {
 	logs.Info("Retrieving users from Audiobookshelf...")
-	absUrl, err := config.String("general::audiobookshelfurl")
-	if err != nil || absUrl == "" {
-		logs.Critical("Missing general::audiobookshelfurl config... Unable to get users.")
-		return nil, fmt.Errorf("missing general::audiobookshelfurl config")
-	}
-
-	url := fmt.Sprintf("%s/api/users", absUrl)
-	req, err := http.NewRequest("GET", url, nil)
+	users, err := client.GetUsers(c.Ctx.Request.Context())
 	if err != nil {
-		logs.Critical("Error creating request: %v", err)
-		return nil, err
+		logs.Error("Unable to get users from audiobookshelf: %v", err)
+		c.Ctx.Output.SetStatus(http.StatusInternalServerError)
+		c.Data["json"] = map[string]string{"error": "Failed to retrieve users"}
+		c.ServeJSON()
+		return
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	c.Data["json"] = pageUsers(users, limit, offset, after)
+	c.ServeJSON()
+}
 
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// pageUsers sorts the users by username and returns a page of them, starting
+// after the cursor when there is one.
+func pageUsers(users []abs.User, limit, offset int, after *models.Cursor) *models.Page[abs.User] {
+	cursorFor := func(user abs.User) models.Cursor {
+		return models.Cursor{Field: "username", Value: strings.ToLower(user.Username), ID: user.ID}
 	}
-	resp, err := client.Do(req)
-	if err != nil {
-		logs.Critical("Error fetching users: %v", err)
-		return nil, err
+	less := func(a, b models.Cursor) bool {
+		return a.Value < b.Value || a.Value == b.Value && a.ID < b.ID
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("error: Received status code %d", resp.StatusCode)
-	}
+	sort.Slice(users, func(i, j int) bool {
+		return less(cursorFor(users[i]), cursorFor(users[j]))
+	})
 
-	var res map[string]any
-	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
-		logs.Critical("Error decoding response: %v", err)
-		return nil, err
+	page := &models.Page[abs.User]{Items: []abs.User{}, Total: int64(len(users)), Limit: limit, Offset: offset}
+	start := min(offset, len(users))
+	if after != nil {
+		start = sort.Search(len(users), func(i int) bool {
+			return less(*after, cursorFor(users[i]))
+		})
 	}
 
-	// Extract users from the response
-	users, ok := res["users"].([]any)
-	if !ok {
-		return nil, fmt.Errorf("invalid response format, 'users' not found")
+	end := min(start+limit, len(users))
+	page.Items = append(page.Items, users[start:end]...)
+	if end < len(users) {
+		next := cursorFor(users[end-1]).Encode()
+		page.NextCursor = &next
 	}
-
-	return users, nil
+	return page
 }
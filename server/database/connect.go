@@ -51,7 +51,11 @@ func Connect() {
 	logs.Info("Connection Opened to database.")
 
 	// Migrate the models into DB
-	DB.AutoMigrate(&models.BookRequest{}, &models.Issue{}, &models.UserPreferences{})
+	DB.AutoMigrate(&models.BookRequest{}, &models.RequestFollower{}, &models.DownloadJob{}, &models.DownloadAttempt{}, &models.RequestComment{}, &models.StatusEvent{}, &models.Issue{}, &models.UserPreferences{}, &models.CacheEntry{})
+
+	if err := models.BackfillMatchKeys(DB); err != nil {
+		logs.Error("Error setting the duplicate keys of book requests: %v", err)
+	}
 
 	logs.Info("Database Migrated")
 }
@@ -8,6 +8,7 @@ require github.com/beego/beego/v2 v2.3.8
 
 require (
 	github.com/coreos/go-oidc/v3 v3.14.1
+	github.com/fsnotify/fsnotify v1.9.0
 	github.com/glebarez/sqlite v1.11.0
 	github.com/joho/godotenv v1.5.1
 	github.com/smartystreets/goconvey v1.8.1
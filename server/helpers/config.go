@@ -1,9 +1,15 @@
 package helpers
 
 import (
+	"api/lib/download"
+	"api/lib/metadata"
+	"api/models"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
+	"slices"
+	"strconv"
 	"strings"
 
 	"github.com/beego/beego/v2/core/config"
@@ -35,6 +41,16 @@ func ValidateConfig() error {
 		errors = append(errors, err.Error())
 	}
 
+	// Validate request handling configuration
+	if err := validateRequestsConfig(&warnings); err != nil {
+		errors = append(errors, err.Error())
+	}
+
+	// Validate downloader configuration
+	if err := validateDownloadConfig(&warnings); err != nil {
+		errors = append(errors, err.Error())
+	}
+
 	// Validate SMTP configuration if enabled
 	if err := validateSMTPConfig(&warnings); err != nil {
 		errors = append(errors, err.Error())
@@ -57,7 +73,7 @@ func ValidateConfig() error {
 // validateMetadataProvider checks if the metadata provider is valid
 func validateMetadataProvider(warnings *[]string) error {
 	provider := config.DefaultString("metadata::provider", "OPENLIBRARY")
-	validProviders := []string{"GOOGLE", "OPENLIBRARY", "HARDCOVER"}
+	validProviders := metadata.Providers()
 
 	// Check if provider is valid
 	isValid := false
@@ -85,6 +101,17 @@ func validateMetadataProvider(warnings *[]string) error {
 		if token == "" {
 			*warnings = append(*warnings, "Hardcover bearer token is not configured for HARDCOVER metadata provider")
 		}
+	case "FEDERATED":
+		federated := config.DefaultString("metadata::federatedproviders", "OPENLIBRARY,GOOGLE,HARDCOVER")
+		for _, name := range strings.Split(federated, ",") {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "" {
+				continue
+			}
+			if _, err := metadata.New(name); err != nil {
+				*warnings = append(*warnings, fmt.Sprintf("Federated provider %s will be skipped: %v", name, err))
+			}
+		}
 	}
 
 	return nil
@@ -173,6 +200,66 @@ func validateDatabaseConfig(warnings *[]string) error {
 	return nil
 }
 
+// validateRequestsConfig checks request handling configuration
+func validateRequestsConfig(warnings *[]string) error {
+	policy := config.DefaultString("requests::inlibrary", "warn")
+	validPolicies := []string{"reject", "warn", "allow"}
+
+	if !slices.Contains(validPolicies, strings.ToLower(policy)) {
+		return fmt.Errorf("invalid requests::inlibrary policy '%s'. Valid options are: %s",
+			policy, strings.Join(validPolicies, ", "))
+	}
+
+	if quota := config.DefaultInt("requests::quota", 0); quota < 0 {
+		return fmt.Errorf("invalid requests::quota %d. It must be 0 (unlimited) or more", quota)
+	}
+
+	period := config.DefaultString("requests::quotaperiod", "week")
+	validPeriods := []string{"day", "week", "month"}
+	if !slices.Contains(validPeriods, strings.ToLower(period)) {
+		return fmt.Errorf("invalid requests::quotaperiod '%s'. Valid options are: %s",
+			period, strings.Join(validPeriods, ", "))
+	}
+
+	for _, pair := range strings.Split(config.DefaultString("requests::quotaoverrides", ""), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, ":")
+		if limit, err := strconv.Atoi(strings.TrimSpace(value)); !ok || strings.TrimSpace(name) == "" || err != nil || limit < 0 {
+			*warnings = append(*warnings, fmt.Sprintf("Ignoring invalid requests::quotaoverrides entry '%s'. Expected name:limit", pair))
+		}
+	}
+
+	if _, err := models.ParseApprovalRules(config.DefaultString("requests::approvalrules", "")); err != nil {
+		return fmt.Errorf("invalid requests::approvalrules: %v", err)
+	}
+
+	return nil
+}
+
+// validateDownloadConfig checks that the configured downloaders exist
+func validateDownloadConfig(warnings *[]string) error {
+	downloaders := config.DefaultString("download::downloaders", "CWA")
+	validDownloaders := download.Downloaders()
+
+	for _, name := range strings.Split(downloaders, ",") {
+		name = strings.ToUpper(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+
+		if _, err := download.New(name); errors.Is(err, download.ErrUnknownDownloader) {
+			return fmt.Errorf("invalid downloader '%s'. Valid options are: %s",
+				name, strings.Join(validDownloaders, ", "))
+		} else if err != nil {
+			*warnings = append(*warnings, fmt.Sprintf("Downloader %s will be skipped: %v", name, err))
+		}
+	}
+
+	return nil
+}
+
 // validateSMTPConfig checks SMTP configuration if enabled
 func validateSMTPConfig(warnings *[]string) error {
 	enabled := config.DefaultBool("smtp::enabled", false)
@@ -1,48 +1,339 @@
 package helpers
 
 import (
-	"api/lib/cwa"
+	"api/database"
+	_ "api/lib/cwa" // registers the CWA downloader
+	"api/lib/download"
 	"api/lib/notifications"
+	_ "api/lib/qbittorrent" // registers the qBittorrent download client
+	"api/lib/queue"
+	_ "api/lib/readarr" // registers the Readarr downloader
+	_ "api/lib/sabnzbd" // registers the SABnzbd download client
+	_ "api/lib/torznab" // registers the Torznab downloader
 	"api/models"
+	"context"
+	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"time"
 
+	"github.com/beego/beego/v2/core/config"
 	"github.com/beego/beego/v2/core/logs"
 )
 
-func HandleDownload(request *models.BookRequest, requestRepository models.RequestRepository) *models.BookRequest {
-	logs.Info("Book request is auto-approved. Beginning search.")
-	var status models.DownloadStatus
-	var dlSource *string
+// researchCheckInterval is how often failed requests are checked for a re-search.
+const researchCheckInterval = time.Hour
 
-	book, err := cwa.HandleSearchAndDownload(*request)
-	if err != nil || book == nil {
+// HandleDownload tries the configured downloaders in priority order, updating
+// the request's download status and recording each attempt for the job. When
+// a release was handed to a download client that is still downloading it, the
+// request stays pending and the in-progress attempt is returned for tracking.
+func HandleDownload(ctx context.Context, request *models.BookRequest, requestRepository models.RequestRepository, job *models.DownloadJob) (*models.BookRequest, *models.DownloadAttempt, error) {
+	logs.Info("Beginning search for book request #%d.", request.ID)
+	var tracked *models.DownloadAttempt
+
+	jobRepository := models.NewJobRepository(database.DB)
+	record := func(attempt download.Attempt) {
+		downloadAttempt := &models.DownloadAttempt{
+			RequestID:  request.ID,
+			JobID:      &job.ID,
+			Attempt:    job.Attempt,
+			Source:     attempt.Downloader,
+			Query:      attempt.Query,
+			Succeeded:  attempt.Err == nil && attempt.Handoff == nil,
+			InProgress: attempt.Err == nil && attempt.Handoff != nil,
+		}
+		if attempt.Err != nil {
+			reason := attempt.Err.Error()
+			downloadAttempt.Reason = &reason
+		}
+		if attempt.Handoff != nil {
+			downloadAttempt.Client = &attempt.Handoff.Client
+			downloadAttempt.ClientID = &attempt.Handoff.ID
+		}
+
+		if err := jobRepository.RecordAttempt(downloadAttempt); err != nil {
+			logs.Warn("Unable to record download attempt for request #%d: %v", request.ID, err)
+		}
+		if downloadAttempt.InProgress {
+			tracked = downloadAttempt
+		}
+	}
+
+	attempt, err := download.Run(ctx, *request, download.Configured(), record)
+	if err != nil {
 		logs.Info("Unable to download book check logs. Updating status...")
-		status = models.DSFailure
-	} else {
-		cwaStr := "cwa"
-		dlSource = &cwaStr
-		title := fmt.Sprintf("✔️🎉 request #%d downloaded!", request.ID)
-		body := fmt.Sprintf(`Source: %s
+		return updateDownloadStatus(request, requestRepository, models.DSFailure, nil), nil, err
+	}
+
+	if attempt.Handoff != nil {
+		logs.Info("Request #%d handed to %s; waiting for the download to finish.", request.ID, attempt.Handoff.Client)
+		return updateDownloadStatus(request, requestRepository, models.DSPending, &attempt.Downloader), tracked, nil
+	}
+
+	book := attempt.Release
+	title := fmt.Sprintf("✔️🎉 request #%d downloaded!", request.ID)
+	body := fmt.Sprintf(`Source: %s
 
 		Title: %s
 		Author: %s
 		ID: %s
 		Size: %s
 		Format: %s
-		Year: %s`, cwaStr, book.Title, book.Author, book.ID,
-			book.Size, book.Format, book.Year)
+		Year: %s`, attempt.Downloader, book.Title, book.Author, book.ID,
+		book.Size, book.Format, book.Year)
 
-		notifications.SendAdminNotification(title, body)
-		status = models.DSComplete
-	}
+	notifications.SendAdminNotification(title, body)
+
+	return updateDownloadStatus(request, requestRepository, models.DSComplete, &attempt.Downloader), nil, nil
+}
 
+func updateDownloadStatus(request *models.BookRequest, requestRepository models.RequestRepository, status models.DownloadStatus, source *string) *models.BookRequest {
 	updatedReq, err := requestRepository.UpdateBookRequest(request,
-		models.BookRequestUpdate{DownloadStatus: &status, DownloadSource: dlSource})
+		models.BookRequestUpdate{DownloadStatus: &status, DownloadSource: source})
 	if err != nil {
 		logs.Critical("Unable to update request download attempt.\n%v\n", err)
-	} else {
-		request = updatedReq
+		return request
+	}
+
+	return updatedReq
+}
+
+var (
+	downloadQueue     *queue.Queue
+	downloadQueueOnce sync.Once
+)
+
+// GetDownloadQueue returns the global download queue configured from the
+// [download] section.
+func GetDownloadQueue() *queue.Queue {
+	downloadQueueOnce.Do(func() {
+		downloadQueue = queue.New(models.NewJobRepository(database.DB), processDownloadJob,
+			config.DefaultInt("download::concurrency", 1))
+	})
+	return downloadQueue
+}
+
+// StartDownloadQueue starts the download workers, resuming any jobs left over
+// from a previous run, and the periodic re-search of failed requests.
+func StartDownloadQueue() error {
+	ctx := context.Background()
+	if err := GetDownloadQueue().Start(ctx); err != nil {
+		return err
 	}
 
+	go researchFailedDownloads(ctx)
+	return nil
+}
+
+// EnqueueDownload queues a background download for the request and attaches
+// the job to it so the API can return immediately.
+func EnqueueDownload(request *models.BookRequest) *models.BookRequest {
+	job, err := GetDownloadQueue().Enqueue(request.ID)
+	if err != nil {
+		logs.Critical("Unable to queue download for request #%d.\n%v\n", request.ID, err)
+		notifications.SendErrorNotification("helpers.EnqueueDownload",
+			fmt.Sprintf("queueing download for request #%d", request.ID), err)
+		return request
+	}
+
+	request.DownloadJob = job
 	return request
 }
+
+// processDownloadJob runs the download or completion check for a queued job's
+// request and schedules a retry with backoff when it fails.
+func processDownloadJob(ctx context.Context, job *models.DownloadJob) error {
+	requestRepository := models.NewRequestRepository(database.DB)
+
+	request, err := requestRepository.GetBookRequest(strconv.FormatUint(uint64(job.RequestID), 10))
+	if err != nil {
+		return fmt.Errorf("load request #%d: %w", job.RequestID, err)
+	}
+
+	// Failed requests are still downloaded so retries and re-searches can complete them
+	if request.ApprovalStatus != models.ASApproved ||
+		(request.DownloadStatus != models.DSPending && request.DownloadStatus != models.DSFailure) {
+		logs.Info("Request #%d is no longer awaiting download; skipping job #%d.", request.ID, job.ID)
+		return nil
+	}
+
+	if job.Kind == models.JKTrack {
+		return trackDownload(ctx, job, request, requestRepository)
+	}
+
+	request, tracked, err := HandleDownload(ctx, request, requestRepository, job)
+	if err != nil {
+		return retryDownload(job, request, err)
+	}
+
+	if tracked != nil {
+		next := &models.DownloadJob{
+			RequestID: job.RequestID,
+			Kind:      models.JKTrack,
+			Attempt:   job.Attempt,
+			Client:    tracked.Client,
+			ClientID:  tracked.ClientID,
+			AttemptID: &tracked.ID,
+		}
+		if _, err := GetDownloadQueue().Schedule(next, downloadPollInterval()); err != nil {
+			logs.Critical("Unable to queue download tracking for request #%d.\n%v\n", request.ID, err)
+			return err
+		}
+		return nil
+	}
+
+	notifications.SendBookRequestStatusNotification(request, "completed")
+	return nil
+}
+
+// trackDownload checks on a release handed to a download client, completing or
+// failing the request once the client finishes with it.
+func trackDownload(ctx context.Context, job *models.DownloadJob, request *models.BookRequest, requestRepository models.RequestRepository) error {
+	if job.Client == nil || job.ClientID == nil || job.AttemptID == nil {
+		return errors.New("track job is missing its download client details")
+	}
+
+	jobRepository := models.NewJobRepository(database.DB)
+	attempt, err := jobRepository.GetAttempt(*job.AttemptID)
+	if err != nil {
+		return fmt.Errorf("load download attempt #%d: %w", *job.AttemptID, err)
+	}
+
+	fail := func(reason string) error {
+		attempt.InProgress = false
+		attempt.Reason = &reason
+		if err := jobRepository.UpdateAttempt(attempt); err != nil {
+			logs.Warn("Unable to update download attempt #%d: %v", attempt.ID, err)
+		}
+
+		request = updateDownloadStatus(request, requestRepository, models.DSFailure, nil)
+		return retryDownload(job, request, fmt.Errorf("%s: %s", *job.Client, reason))
+	}
+
+	timeout := time.Duration(config.DefaultInt("download::completiontimeout", 172800)) * time.Second
+	timedOut := time.Since(attempt.CreatedAt) > timeout
+
+	tracker, err := download.NewTracker(*job.Client)
+	if err != nil {
+		return fail(err.Error())
+	}
+
+	status, err := tracker.Status(ctx, *job.ClientID)
+	if err != nil {
+		if timedOut {
+			return fail(fmt.Sprintf("unable to check download: %v", err))
+		}
+		// Clients can be briefly unreachable, so keep polling until the timeout
+		logs.Warn("Unable to check %s download for request #%d: %v", *job.Client, request.ID, err)
+		return trackAgain()
+	}
+
+	switch status.State {
+	case download.StateCompleted:
+		attempt.InProgress = false
+		attempt.Succeeded = true
+		if err := jobRepository.UpdateAttempt(attempt); err != nil {
+			logs.Warn("Unable to update download attempt #%d: %v", attempt.ID, err)
+		}
+
+		request = updateDownloadStatus(request, requestRepository, models.DSComplete, &attempt.Source)
+
+		title := fmt.Sprintf("✔️🎉 request #%d downloaded!", request.ID)
+		body := fmt.Sprintf(`Source: %s
+Client: %s
+
+Title: %s
+Author: %s`, attempt.Source, *job.Client, request.Title, request.Author)
+		notifications.SendAdminNotification(title, body)
+		notifications.SendBookRequestStatusNotification(request, "completed")
+		return nil
+
+	case download.StateFailed:
+		return fail(status.Reason)
+
+	default:
+		if timedOut {
+			return fail(fmt.Sprintf("download didn't finish within %s (%.0f%% done)", timeout, status.Progress*100))
+		}
+		logs.Debug("Request #%d is %s in %s (%.0f%%).", request.ID, status.State, *job.Client, status.Progress*100)
+		return trackAgain()
+	}
+}
+
+// trackAgain puts the track job back on the queue for another completion check.
+func trackAgain() error {
+	return queue.RequeueAfter(downloadPollInterval())
+}
+
+// retryDownload queues another attempt with backoff, or alerts the admin once
+// the attempts are used up. The download error is returned to fail the job.
+func retryDownload(job *models.DownloadJob, request *models.BookRequest, err error) error {
+	maxAttempts := config.DefaultInt("download::maxattempts", 3)
+	if job.Attempt < maxAttempts {
+		delay := queue.Backoff(job.Attempt,
+			time.Duration(config.DefaultInt("download::retrybackoff", 300))*time.Second,
+			time.Duration(config.DefaultInt("download::retrymaxbackoff", 21600))*time.Second)
+
+		if _, rerr := GetDownloadQueue().Retry(job, delay); rerr != nil {
+			logs.Critical("Unable to queue retry for request #%d.\n%v\n", request.ID, rerr)
+		}
+	} else {
+		title := fmt.Sprintf("⚠️📔 request #%d failed to download", request.ID)
+		body := fmt.Sprintf(`%s by %s
+
+Attempts: %d
+Last error: %v`, request.Title, request.Author, job.Attempt, err)
+		notifications.SendAdminNotification(title, body)
+	}
+
+	return err
+}
+
+func downloadPollInterval() time.Duration {
+	return time.Duration(config.DefaultInt("download::pollinterval", 60)) * time.Second
+}
+
+// researchFailedDownloads periodically queues failed requests again, since
+// books often show up on sources some time after they were requested.
+func researchFailedDownloads(ctx context.Context) {
+	interval := time.Duration(config.DefaultInt("download::researchinterval", 86400)) * time.Second
+	days := config.DefaultInt("download::researchdays", 30)
+	if interval <= 0 || days <= 0 {
+		logs.Info("Re-searching failed downloads is disabled.")
+		return
+	}
+
+	ticker := time.NewTicker(researchCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		queueResearch(interval, days)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func queueResearch(interval time.Duration, days int) {
+	now := time.Now()
+	jobRepository := models.NewJobRepository(database.DB)
+
+	ids, err := jobRepository.FindRequestsToResearch(now.AddDate(0, 0, -days), now.Add(-interval))
+	if err != nil {
+		logs.Warn("Unable to find failed requests to re-search: %v", err)
+		return
+	}
+
+	for _, id := range ids {
+		if _, err := GetDownloadQueue().Enqueue(id); err != nil {
+			logs.Warn("Unable to queue re-search for request #%d: %v", id, err)
+			continue
+		}
+		logs.Info("Queued re-search for failed request #%d.", id)
+	}
+}
@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"api/lib/abs"
+	"api/models"
+	"context"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+// LibraryQuery builds an Audiobookshelf lookup for a book request.
+func LibraryQuery(request *models.BookRequest) abs.BookQuery {
+	query := abs.BookQuery{
+		Title:     request.Title,
+		Author:    request.Author,
+		Ebook:     request.MediaType.WantsEbook(),
+		Audiobook: request.MediaType.WantsAudiobook(),
+	}
+	if request.ISBN13 != nil {
+		query.ISBNs = append(query.ISBNs, *request.ISBN13)
+	}
+	if request.ISBN10 != nil {
+		query.ISBNs = append(query.ISBNs, *request.ISBN10)
+	}
+	if request.ASIN != nil {
+		query.ASIN = *request.ASIN
+	}
+	return query
+}
+
+// FindRequestInLibrary returns the Audiobookshelf item matching the request, or
+// nil if the book isn't in the library yet.
+func FindRequestInLibrary(ctx context.Context, request *models.BookRequest, userToken string) (*abs.LibraryItem, error) {
+	client, err := abs.NewClientFromConfig(config.DefaultString("general::audiobookshelfapikey", userToken))
+	if err != nil {
+		return nil, err
+	}
+
+	return client.FindBook(ctx, LibraryQuery(request))
+}
+
+// AuthorInLibrary reports whether the library has books by the author.
+func AuthorInLibrary(ctx context.Context, author string, userToken string) (bool, error) {
+	client, err := abs.NewClientFromConfig(config.DefaultString("general::audiobookshelfapikey", userToken))
+	if err != nil {
+		return false, err
+	}
+
+	return client.HasAuthor(ctx, author)
+}
@@ -0,0 +1,134 @@
+// Package abs is a typed client for the Audiobookshelf API.
+package abs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Client talks to a single Audiobookshelf server with one API token.
+type Client struct {
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// NewClient creates a client for the server at baseURL.
+func NewClient(baseURL, token string, timeout time.Duration) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Token:      token,
+		HTTPClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// NewClientFromConfig creates a client from general::audiobookshelfurl and
+// general::audiobookshelftimeout (seconds).
+func NewClientFromConfig(token string) (*Client, error) {
+	absURL := config.DefaultString("general::audiobookshelfurl", "")
+	if absURL == "" {
+		logs.Critical("Missing general::audiobookshelfurl config... Unable to access Audiobookshelf data.")
+		return nil, ErrNotConfigured
+	}
+
+	timeout := time.Duration(config.DefaultInt("general::audiobookshelftimeout", 10)) * time.Second
+	return NewClient(absURL, token, timeout), nil
+}
+
+// GetLibraries returns every library the token can access.
+func (c *Client) GetLibraries(ctx context.Context) ([]Library, error) {
+	var res struct {
+		Libraries []Library `json:"libraries"`
+	}
+	if err := c.get(ctx, "/api/libraries", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Libraries, nil
+}
+
+// SearchLibrary searches a single library for books and authors.
+func (c *Client) SearchLibrary(ctx context.Context, libraryID, query string) (*SearchResult, error) {
+	params := url.Values{}
+	params.Add("q", query)
+
+	var res SearchResult
+	if err := c.get(ctx, fmt.Sprintf("/api/libraries/%s/search", libraryID), params, &res); err != nil {
+		return nil, err
+	}
+
+	return &res, nil
+}
+
+// GetAuthor returns an author along with their library items.
+func (c *Client) GetAuthor(ctx context.Context, authorID string) (*Author, error) {
+	params := url.Values{}
+	params.Add("include", "items")
+
+	var author Author
+	if err := c.get(ctx, fmt.Sprintf("/api/authors/%s", authorID), params, &author); err != nil {
+		return nil, err
+	}
+
+	return &author, nil
+}
+
+// GetPersonalizedShelves returns the personalized home page shelves of a library.
+func (c *Client) GetPersonalizedShelves(ctx context.Context, libraryID string) ([]Shelf, error) {
+	var shelves []Shelf
+	if err := c.get(ctx, fmt.Sprintf("/api/libraries/%s/personalized", libraryID), nil, &shelves); err != nil {
+		return nil, err
+	}
+
+	return shelves, nil
+}
+
+// GetUsers returns every user on the server. Requires an admin token.
+func (c *Client) GetUsers(ctx context.Context) ([]User, error) {
+	var res struct {
+		Users []User `json:"users"`
+	}
+	if err := c.get(ctx, "/api/users", nil, &res); err != nil {
+		return nil, err
+	}
+
+	return res.Users, nil
+}
+
+// get performs an authenticated GET request and decodes the JSON response into out.
+func (c *Client) get(ctx context.Context, path string, params url.Values, out any) error {
+	fullURL := c.BaseURL + path
+	if len(params) > 0 {
+		fullURL = fmt.Sprintf("%s?%s", fullURL, params.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.Token))
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("audiobookshelf GET %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{Method: http.MethodGet, Path: path, StatusCode: resp.StatusCode}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%w from GET %s: %v", ErrInvalidResponse, path, err)
+	}
+
+	return nil
+}
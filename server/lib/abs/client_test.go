@@ -0,0 +1,132 @@
+package abs
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testToken = "abs-token"
+
+// newFakeServer serves a minimal Audiobookshelf API with two libraries.
+func newFakeServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/libraries", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"libraries":[{"id":"lib1","name":"Audiobooks","mediaType":"book"},{"id":"lib2","name":"Ebooks","mediaType":"book"}]}`))
+	})
+	mux.HandleFunc("/api/libraries/lib1/search", func(w http.ResponseWriter, r *http.Request) {
+		if q := strings.ToLower(r.URL.Query().Get("q")); q != "dune" && q != "herbert, frank" {
+			w.Write([]byte(`{"book":[],"authors":[]}`))
+			return
+		}
+		w.Write([]byte(`{"book":[{"libraryItem":{"id":"li1","libraryId":"lib1","mediaType":"book","media":{"numAudioFiles":3,"metadata":{"title":"Dune","authorName":"Frank Herbert","isbn":"9780441013593"}}},"matchKey":"title","matchText":"Dune"}],"authors":[{"id":"au1","name":"Frank Herbert"}]}`))
+	})
+	mux.HandleFunc("/api/libraries/lib2/search", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	mux.HandleFunc("/api/authors/au1", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("include") != "items" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"id":"au1","name":"Frank Herbert","libraryItems":[{"id":"li1","media":{"metadata":{"title":"Dune"}}},{"id":"li2","media":{"metadata":{"title":"Dune Messiah"}}}]}`))
+	})
+	mux.HandleFunc("/api/libraries/lib1/personalized", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"id":"continue-listening","entities":[{"id":"li9"}]},{"id":"recently-added","entities":[{"id":"li2","media":{"metadata":{"title":"Dune Messiah"}}}]}]`))
+	})
+	mux.HandleFunc("/api/libraries/lib2/personalized", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/api/users", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"users":[{"id":"u1","username":"alice","type":"admin","isActive":true,"permissions":{"download":true}}]}`))
+	})
+	mux.HandleFunc("/api/slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	})
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+testToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	ctx := context.Background()
+
+	Convey("Subject: Audiobookshelf client\n", t, func() {
+		client := NewClient(server.URL+"/", testToken, time.Second)
+
+		Convey("Libraries should be decoded", func() {
+			libraries, err := client.GetLibraries(ctx)
+			So(err, ShouldBeNil)
+			So(libraries, ShouldHaveLength, 2)
+			So(libraries[0].Name, ShouldEqual, "Audiobooks")
+		})
+
+		Convey("Search should include author books without duplicates", func() {
+			books, err := client.Search(ctx, "dune")
+			So(err, ShouldBeNil)
+			So(books, ShouldHaveLength, 2)
+			So(books[0].LibraryItem.Media.Metadata.Title, ShouldEqual, "Dune")
+			So(books[0].LibraryItem.Media.NumAudioFiles, ShouldEqual, 3)
+			So(*books[0].LibraryItem.Media.Metadata.ISBN, ShouldEqual, "9780441013593")
+			So(books[1].LibraryItem.ID, ShouldEqual, "li2")
+		})
+
+		Convey("Authors should match however their name is written", func() {
+			found, err := client.HasAuthor(ctx, "Herbert, Frank")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+
+			// lib2's search fails, so not finding the author also returns its error
+			found, err = client.HasAuthor(ctx, "Ursula K. Le Guin")
+			So(found, ShouldBeFalse)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("Recently added should only use the recently-added shelf", func() {
+			books, err := client.RecentlyAdded(ctx)
+			So(err, ShouldBeNil)
+			So(books, ShouldHaveLength, 1)
+			So(books[0].LibraryItem.ID, ShouldEqual, "li2")
+		})
+
+		Convey("Users should be decoded", func() {
+			users, err := client.GetUsers(ctx)
+			So(err, ShouldBeNil)
+			So(users, ShouldHaveLength, 1)
+			So(users[0].Username, ShouldEqual, "alice")
+			So(users[0].Permissions.Download, ShouldBeTrue)
+		})
+
+		Convey("Status codes should map to typed errors", func() {
+			_, err := client.GetAuthor(ctx, "missing")
+			var apiErr *APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.StatusCode, ShouldEqual, http.StatusNotFound)
+			So(errors.Is(err, ErrNotFound), ShouldBeTrue)
+
+			client.Token = "wrong"
+			_, err = client.GetLibraries(ctx)
+			So(errors.Is(err, ErrUnauthorized), ShouldBeTrue)
+		})
+
+		Convey("Requests should honor the client timeout", func() {
+			client.HTTPClient.Timeout = 50 * time.Millisecond
+			err := client.get(ctx, "/api/slow", nil, &struct{}{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,40 @@
+package abs
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrNotConfigured is returned when general::audiobookshelfurl is missing.
+	ErrNotConfigured = errors.New("missing general::audiobookshelfurl config")
+	// ErrUnauthorized is matched by API errors for rejected tokens.
+	ErrUnauthorized = errors.New("audiobookshelf rejected the api token")
+	// ErrNotFound is matched by API errors for missing resources.
+	ErrNotFound = errors.New("audiobookshelf resource not found")
+	// ErrInvalidResponse is returned when a response body can't be decoded.
+	ErrInvalidResponse = errors.New("invalid audiobookshelf response")
+)
+
+// APIError is returned when Audiobookshelf responds with a non-200 status.
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("audiobookshelf %s %s: received status code %d", e.Method, e.Path, e.StatusCode)
+}
+
+// Unwrap lets callers use errors.Is with ErrUnauthorized and ErrNotFound.
+func (e *APIError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return ErrUnauthorized
+	case http.StatusNotFound:
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package abs
+
+import (
+	"api/lib/match"
+	"context"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Search searches every library for the query. When a library search matches
+// an author, that author's books are included as well.
+func (c *Client) Search(ctx context.Context, query string) ([]BookItem, error) {
+	logs.Info("Searching Audiobookshelf libraries for %s...", query)
+
+	libraries, err := c.GetLibraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	books := []BookItem{}
+	seen := make(map[string]bool)
+	add := func(item BookItem) {
+		if !seen[item.LibraryItem.ID] {
+			seen[item.LibraryItem.ID] = true
+			books = append(books, item)
+		}
+	}
+
+	for _, library := range libraries {
+		logs.Debug("Performing library search on library ID: %s", library.ID)
+		res, err := c.SearchLibrary(ctx, library.ID, query)
+		if err != nil {
+			logs.Warn("Unable to search library %s: %v", library.ID, err)
+			continue
+		}
+
+		for _, book := range res.Book {
+			add(book)
+		}
+
+		// Only the best matching author's books are included.
+		if len(res.Authors) > 0 {
+			author, err := c.GetAuthor(ctx, res.Authors[0].ID)
+			if err != nil {
+				logs.Warn("Unable to retrieve author books: %v", err)
+				continue
+			}
+
+			for _, item := range author.LibraryItems {
+				add(BookItem{LibraryItem: item})
+			}
+		}
+	}
+
+	return books, nil
+}
+
+// RecentlyAdded returns the "recently-added" shelf of every library.
+func (c *Client) RecentlyAdded(ctx context.Context) ([]BookItem, error) {
+	libraries, err := c.GetLibraries(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	books := []BookItem{}
+	for _, library := range libraries {
+		logs.Debug("Getting recently added books for library: %s", library.ID)
+		shelves, err := c.GetPersonalizedShelves(ctx, library.ID)
+		if err != nil {
+			logs.Debug("Unable to get personalized shelves: %v", err)
+			continue
+		}
+
+		for _, shelf := range shelves {
+			if shelf.ID != "recently-added" {
+				continue
+			}
+			for _, item := range shelf.Entities {
+				books = append(books, BookItem{LibraryItem: item})
+			}
+			break
+		}
+	}
+
+	return books, nil
+}
+
+// HasAuthor reports whether any library has books by the author.
+func (c *Client) HasAuthor(ctx context.Context, author string) (bool, error) {
+	want := match.NormalizeAuthor(author)
+	if want == "" {
+		return false, nil
+	}
+
+	libraries, err := c.GetLibraries(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	var lastErr error
+	for _, library := range libraries {
+		res, err := c.SearchLibrary(ctx, library.ID, author)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		for _, found := range res.Authors {
+			if match.NormalizeAuthor(found.Name) == want {
+				return true, nil
+			}
+		}
+	}
+
+	return false, lastErr
+}
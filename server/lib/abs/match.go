@@ -0,0 +1,135 @@
+package abs
+
+import (
+	"api/lib/match"
+	"context"
+	"strings"
+)
+
+// BookQuery describes a book to look for in the library.
+type BookQuery struct {
+	Title  string
+	Author string
+	ISBNs  []string
+	ASIN   string
+	// Ebook and Audiobook only match library items that have that media.
+	Ebook     bool
+	Audiobook bool
+}
+
+// FilterByMedia returns the items that have an ebook, an audiobook or both, as
+// asked. The items are returned unfiltered when neither is asked for.
+func FilterByMedia(items []BookItem, ebook, audiobook bool) []BookItem {
+	if !ebook && !audiobook {
+		return items
+	}
+
+	filtered := make([]BookItem, 0, len(items))
+	for _, item := range items {
+		if ebook && !item.LibraryItem.HasEbook() || audiobook && !item.LibraryItem.HasAudiobook() {
+			continue
+		}
+		filtered = append(filtered, item)
+	}
+	return filtered
+}
+
+// LibraryIndex looks up library items by ISBN, ASIN or normalized title and author.
+type LibraryIndex struct {
+	byISBN map[string]*LibraryItem
+	byASIN map[string]*LibraryItem
+	byKey  map[string]*LibraryItem
+}
+
+// NewLibraryIndex indexes the given library items.
+func NewLibraryIndex(items []BookItem) *LibraryIndex {
+	index := &LibraryIndex{
+		byISBN: make(map[string]*LibraryItem),
+		byASIN: make(map[string]*LibraryItem),
+		byKey:  make(map[string]*LibraryItem),
+	}
+
+	for i := range items {
+		item := &items[i].LibraryItem
+		metadata := item.Media.Metadata
+
+		if metadata.ISBN != nil {
+			if isbn := match.ISBN13(*metadata.ISBN); isbn != "" {
+				index.byISBN[isbn] = item
+			}
+		}
+		if metadata.ASIN != nil && *metadata.ASIN != "" {
+			index.byASIN[strings.ToUpper(*metadata.ASIN)] = item
+		}
+		if metadata.Title != "" {
+			key := match.Key(metadata.Title, itemAuthor(metadata))
+			if _, ok := index.byKey[key]; !ok {
+				index.byKey[key] = item
+			}
+		}
+	}
+
+	return index
+}
+
+// Match returns the library item matching the query, preferring identifier
+// matches over title and author matches, or nil if the book isn't in the library.
+func (i *LibraryIndex) Match(query BookQuery) *LibraryItem {
+	for _, raw := range query.ISBNs {
+		if isbn := match.ISBN13(raw); isbn != "" {
+			if item, ok := i.byISBN[isbn]; ok {
+				return item
+			}
+		}
+	}
+
+	if query.ASIN != "" {
+		if item, ok := i.byASIN[strings.ToUpper(query.ASIN)]; ok {
+			return item
+		}
+	}
+
+	if query.Title == "" {
+		return nil
+	}
+
+	return i.byKey[match.Key(query.Title, query.Author)]
+}
+
+// FindBook searches the libraries for the book by its identifiers and title and
+// returns the matching library item, or nil if the book isn't in the library.
+func (c *Client) FindBook(ctx context.Context, query BookQuery) (*LibraryItem, error) {
+	// Audiobookshelf matches ISBN and ASIN as well as titles in library search.
+	terms := append([]string{}, query.ISBNs...)
+	if query.ASIN != "" {
+		terms = append(terms, query.ASIN)
+	}
+	terms = append(terms, query.Title)
+
+	var lastErr error
+	for _, term := range terms {
+		if strings.TrimSpace(term) == "" {
+			continue
+		}
+
+		books, err := c.Search(ctx, term)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		books = FilterByMedia(books, query.Ebook, query.Audiobook)
+		if item := NewLibraryIndex(books).Match(query); item != nil {
+			return item, nil
+		}
+	}
+
+	return nil, lastErr
+}
+
+func itemAuthor(metadata Metadata) string {
+	if len(metadata.Authors) > 0 {
+		return metadata.Authors[0].Name
+	}
+	return metadata.AuthorName
+}
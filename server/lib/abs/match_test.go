@@ -0,0 +1,86 @@
+package abs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLibraryIndex(t *testing.T) {
+	isbn := "0-441-01359-7"
+	asin := "b00b7nppy8"
+	items := []BookItem{
+		{LibraryItem: LibraryItem{ID: "li1", Media: Media{Metadata: Metadata{Title: "Dune", AuthorName: "Frank Herbert", ISBN: &isbn}}}},
+		{LibraryItem: LibraryItem{ID: "li2", Media: Media{Metadata: Metadata{Title: "The Hobbit", Authors: []AuthorRef{{Name: "J.R.R. Tolkien"}}, ASIN: &asin}}}},
+	}
+
+	Convey("Subject: Library index matching\n", t, func() {
+		index := NewLibraryIndex(items)
+
+		Convey("ISBN-10 and ISBN-13 forms should match each other", func() {
+			item := index.Match(BookQuery{Title: "Something Else", ISBNs: []string{"9780441013593"}})
+			So(item, ShouldNotBeNil)
+			So(item.ID, ShouldEqual, "li1")
+		})
+		Convey("ASINs should match case-insensitively", func() {
+			item := index.Match(BookQuery{ASIN: "B00B7NPPY8"})
+			So(item, ShouldNotBeNil)
+			So(item.ID, ShouldEqual, "li2")
+		})
+		Convey("Title and author should be used as a fallback", func() {
+			item := index.Match(BookQuery{Title: "The Hobbit: or There and Back Again", Author: "Tolkien, J. R. R."})
+			So(item, ShouldNotBeNil)
+			So(item.ID, ShouldEqual, "li2")
+		})
+		Convey("Different authors should not match", func() {
+			So(index.Match(BookQuery{Title: "Dune", Author: "Brian Herbert"}), ShouldBeNil)
+		})
+	})
+}
+
+func TestFilterByMedia(t *testing.T) {
+	epub := "epub"
+	items := []BookItem{
+		{LibraryItem: LibraryItem{ID: "audio", Media: Media{NumAudioFiles: 3}}},
+		{LibraryItem: LibraryItem{ID: "ebook", Media: Media{EbookFormat: &epub}}},
+		{LibraryItem: LibraryItem{ID: "both", Media: Media{NumTracks: 1, EbookFile: &EbookFile{EbookFormat: "epub"}}}},
+	}
+
+	ids := func(items []BookItem) []string {
+		var ids []string
+		for _, item := range items {
+			ids = append(ids, item.LibraryItem.ID)
+		}
+		return ids
+	}
+
+	Convey("Subject: Filtering library items by media\n", t, func() {
+		So(ids(FilterByMedia(items, false, false)), ShouldResemble, []string{"audio", "ebook", "both"})
+		So(ids(FilterByMedia(items, true, false)), ShouldResemble, []string{"ebook", "both"})
+		So(ids(FilterByMedia(items, false, true)), ShouldResemble, []string{"audio", "both"})
+		So(ids(FilterByMedia(items, true, true)), ShouldResemble, []string{"both"})
+	})
+}
+
+func TestFindBook(t *testing.T) {
+	server := newFakeServer()
+	defer server.Close()
+
+	Convey("Subject: Finding a book in the library\n", t, func() {
+		client := NewClient(server.URL, testToken, time.Second)
+
+		Convey("A book in the library should be found by title search", func() {
+			item, err := client.FindBook(context.Background(), BookQuery{Title: "Dune", Author: "Frank Herbert"})
+			So(err, ShouldBeNil)
+			So(item, ShouldNotBeNil)
+			So(item.ID, ShouldEqual, "li1")
+		})
+		Convey("A book that isn't in the library should return nil", func() {
+			item, err := client.FindBook(context.Background(), BookQuery{Title: "Neuromancer", Author: "William Gibson"})
+			So(err, ShouldBeNil)
+			So(item, ShouldBeNil)
+		})
+	})
+}
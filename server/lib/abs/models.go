@@ -0,0 +1,165 @@
+package abs
+
+// Library is an Audiobookshelf library.
+type Library struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	DisplayOrder int    `json:"displayOrder"`
+	Icon         string `json:"icon"`
+	MediaType    string `json:"mediaType"`
+	Provider     string `json:"provider"`
+	CreatedAt    int64  `json:"createdAt"`
+	LastUpdate   int64  `json:"lastUpdate"`
+}
+
+// LibraryItem is a single book or podcast in a library.
+type LibraryItem struct {
+	ID          string   `json:"id"`
+	Ino         string   `json:"ino"`
+	LibraryID   string   `json:"libraryId"`
+	FolderID    string   `json:"folderId"`
+	Path        string   `json:"path"`
+	RelPath     string   `json:"relPath"`
+	IsFile      bool     `json:"isFile"`
+	MtimeMs     int64    `json:"mtimeMs"`
+	CtimeMs     int64    `json:"ctimeMs"`
+	BirthtimeMs int64    `json:"birthtimeMs"`
+	AddedAt     int64    `json:"addedAt"`
+	UpdatedAt   int64    `json:"updatedAt"`
+	IsMissing   bool     `json:"isMissing"`
+	IsInvalid   bool     `json:"isInvalid"`
+	MediaType   string   `json:"mediaType"`
+	Media       Media    `json:"media"`
+	NumFiles    int      `json:"numFiles"`
+	Size        int64    `json:"size"`
+	Tags        []string `json:"tags,omitempty"`
+}
+
+// Media holds the book details of a library item.
+type Media struct {
+	ID            string     `json:"id"`
+	Metadata      Metadata   `json:"metadata"`
+	CoverPath     *string    `json:"coverPath"`
+	Tags          []string   `json:"tags"`
+	NumTracks     int        `json:"numTracks"`
+	NumAudioFiles int        `json:"numAudioFiles"`
+	NumChapters   int        `json:"numChapters"`
+	Duration      float64    `json:"duration"`
+	Size          int64      `json:"size"`
+	EbookFormat   *string    `json:"ebookFormat"`
+	EbookFile     *EbookFile `json:"ebookFile,omitempty"`
+}
+
+// HasEbook reports whether the library item has an ebook file.
+func (i LibraryItem) HasEbook() bool {
+	return (i.Media.EbookFormat != nil && *i.Media.EbookFormat != "") || i.Media.EbookFile != nil
+}
+
+// HasAudiobook reports whether the library item has audio files.
+func (i LibraryItem) HasAudiobook() bool {
+	return i.Media.NumAudioFiles > 0 || i.Media.NumTracks > 0
+}
+
+// EbookFile describes the ebook attached to a library item.
+type EbookFile struct {
+	Ino         string `json:"ino"`
+	EbookFormat string `json:"ebookFormat"`
+}
+
+// Metadata is the book metadata of a library item. Minified items only carry
+// the flattened name fields while expanded items also list authors and series.
+type Metadata struct {
+	Title             string      `json:"title"`
+	TitleIgnorePrefix string      `json:"titleIgnorePrefix"`
+	Subtitle          *string     `json:"subtitle"`
+	Authors           []AuthorRef `json:"authors,omitempty"`
+	Narrators         []string    `json:"narrators,omitempty"`
+	Series            []SeriesRef `json:"series,omitempty"`
+	Genres            []string    `json:"genres"`
+	PublishedYear     *string     `json:"publishedYear"`
+	PublishedDate     *string     `json:"publishedDate"`
+	Publisher         *string     `json:"publisher"`
+	Description       *string     `json:"description"`
+	ISBN              *string     `json:"isbn"`
+	ASIN              *string     `json:"asin"`
+	Language          *string     `json:"language"`
+	Explicit          bool        `json:"explicit"`
+	AuthorName        string      `json:"authorName"`
+	AuthorNameLF      string      `json:"authorNameLF"`
+	NarratorName      string      `json:"narratorName"`
+	SeriesName        string      `json:"seriesName"`
+}
+
+// AuthorRef is an author attached to a book.
+type AuthorRef struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// SeriesRef is a series attached to a book.
+type SeriesRef struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Sequence *string `json:"sequence"`
+}
+
+// Author is an author with, when requested, their library items.
+type Author struct {
+	ID           string        `json:"id"`
+	ASIN         *string       `json:"asin"`
+	Name         string        `json:"name"`
+	Description  *string       `json:"description"`
+	ImagePath    *string       `json:"imagePath"`
+	AddedAt      int64         `json:"addedAt"`
+	UpdatedAt    int64         `json:"updatedAt"`
+	NumBooks     int           `json:"numBooks"`
+	LibraryItems []LibraryItem `json:"libraryItems,omitempty"`
+}
+
+// BookItem wraps a library item the way library search results do. The web
+// client consumes every Audiobookshelf book in this shape.
+type BookItem struct {
+	LibraryItem LibraryItem `json:"libraryItem"`
+	MatchKey    string      `json:"matchKey,omitempty"`
+	MatchText   string      `json:"matchText,omitempty"`
+}
+
+// SearchResult is the response of a library search.
+type SearchResult struct {
+	Book    []BookItem `json:"book"`
+	Authors []Author   `json:"authors"`
+}
+
+// Shelf is a section of the personalized library view such as "recently-added".
+type Shelf struct {
+	ID       string        `json:"id"`
+	Label    string        `json:"label"`
+	Type     string        `json:"type"`
+	Entities []LibraryItem `json:"entities"`
+}
+
+// User is an Audiobookshelf user account.
+type User struct {
+	ID                  string          `json:"id"`
+	Username            string          `json:"username"`
+	Email               *string         `json:"email"`
+	Type                string          `json:"type"`
+	IsActive            bool            `json:"isActive"`
+	IsLocked            bool            `json:"isLocked"`
+	LastSeen            int64           `json:"lastSeen"`
+	CreatedAt           int64           `json:"createdAt"`
+	Permissions         UserPermissions `json:"permissions"`
+	LibrariesAccessible []string        `json:"librariesAccessible"`
+	ItemTagsAccessible  []string        `json:"itemTagsAccessible"`
+}
+
+// UserPermissions are the permissions of an Audiobookshelf user.
+type UserPermissions struct {
+	Download              bool `json:"download"`
+	Update                bool `json:"update"`
+	Delete                bool `json:"delete"`
+	Upload                bool `json:"upload"`
+	AccessAllLibraries    bool `json:"accessAllLibraries"`
+	AccessAllTags         bool `json:"accessAllTags"`
+	AccessExplicitContent bool `json:"accessExplicitContent"`
+}
@@ -0,0 +1,173 @@
+// Package cache stores metadata and Audiobookshelf search responses so repeat
+// searches don't hit the external APIs again.
+package cache
+
+import (
+	"encoding/json"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Backend stores raw cache values.
+type Backend interface {
+	// Name identifies the backend in stats output.
+	Name() string
+	// Get returns the value for key if it exists and hasn't expired.
+	Get(key string) ([]byte, bool, error)
+	// Set stores a value for key until the TTL elapses.
+	Set(key string, value []byte, ttl time.Duration) error
+	// Len returns the number of live entries.
+	Len() (int, error)
+	// Purge removes every entry.
+	Purge() error
+}
+
+// Stats reports cache usage since the process started or the last purge.
+type Stats struct {
+	Enabled    bool    `json:"enabled"`
+	Backend    string  `json:"backend"`
+	Entries    int     `json:"entries"`
+	MaxEntries int     `json:"max_entries"`
+	TTLSeconds int     `json:"ttl_seconds"`
+	Hits       uint64  `json:"hits"`
+	Misses     uint64  `json:"misses"`
+	HitRatio   float64 `json:"hit_ratio"`
+}
+
+// Cache wraps a backend with JSON encoding, key normalization and hit/miss stats.
+type Cache struct {
+	backend    Backend
+	ttl        time.Duration
+	maxEntries int
+	enabled    bool
+	hits       atomic.Uint64
+	misses     atomic.Uint64
+}
+
+var (
+	searchCache *Cache
+	once        sync.Once
+)
+
+// GetSearchCache returns the global search cache configured from the [cache] section.
+func GetSearchCache() *Cache {
+	once.Do(func() {
+		ttl := time.Duration(config.DefaultInt("cache::ttl", 3600)) * time.Second
+		maxEntries := config.DefaultInt("cache::maxentries", 1000)
+		enabled := config.DefaultBool("cache::enabled", true)
+
+		var backend Backend
+		switch strings.ToLower(config.DefaultString("cache::backend", "memory")) {
+		case "sqlite":
+			backend = NewSQLiteBackend(maxEntries)
+		case "memory":
+			backend = NewMemoryBackend(maxEntries)
+		default:
+			logs.Warn("Unknown cache::backend, falling back to memory")
+			backend = NewMemoryBackend(maxEntries)
+		}
+
+		searchCache = New(backend, ttl, maxEntries, enabled)
+	})
+	return searchCache
+}
+
+// New creates a cache around the given backend.
+func New(backend Backend, ttl time.Duration, maxEntries int, enabled bool) *Cache {
+	return &Cache{
+		backend:    backend,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		enabled:    enabled && ttl > 0,
+	}
+}
+
+// Key builds a cache key from a provider name and a search query. Queries are
+// lowercased and whitespace collapsed so "Dune " and "dune" share an entry.
+func Key(provider, query string) string {
+	return strings.ToUpper(provider) + ":" + strings.Join(strings.Fields(strings.ToLower(query)), " ")
+}
+
+// Get decodes the cached response for provider and query into out and reports
+// whether it was found.
+func (c *Cache) Get(provider, query string, out any) bool {
+	if !c.enabled {
+		return false
+	}
+
+	value, ok, err := c.backend.Get(Key(provider, query))
+	if err != nil {
+		logs.Warn("Unable to read from search cache: %v", err)
+	}
+	if !ok || err != nil {
+		c.misses.Add(1)
+		return false
+	}
+
+	if err := json.Unmarshal(value, out); err != nil {
+		logs.Warn("Unable to decode cached search response: %v", err)
+		c.misses.Add(1)
+		return false
+	}
+
+	c.hits.Add(1)
+	return true
+}
+
+// Set stores the response for provider and query.
+func (c *Cache) Set(provider, query string, value any) {
+	if !c.enabled {
+		return
+	}
+
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		logs.Warn("Unable to encode search response for caching: %v", err)
+		return
+	}
+
+	if err := c.backend.Set(Key(provider, query), encoded, c.ttl); err != nil {
+		logs.Warn("Unable to write to search cache: %v", err)
+	}
+}
+
+// Purge removes every cached response and resets the stats.
+func (c *Cache) Purge() error {
+	if err := c.backend.Purge(); err != nil {
+		return err
+	}
+
+	c.hits.Store(0)
+	c.misses.Store(0)
+	return nil
+}
+
+// Stats returns the current cache usage.
+func (c *Cache) Stats() Stats {
+	entries, err := c.backend.Len()
+	if err != nil {
+		logs.Warn("Unable to count search cache entries: %v", err)
+	}
+
+	hits, misses := c.hits.Load(), c.misses.Load()
+	var ratio float64
+	if hits+misses > 0 {
+		ratio = float64(hits) / float64(hits+misses)
+	}
+
+	return Stats{
+		Enabled:    c.enabled,
+		Backend:    c.backend.Name(),
+		Entries:    entries,
+		MaxEntries: c.maxEntries,
+		TTLSeconds: int(c.ttl / time.Second),
+		Hits:       hits,
+		Misses:     misses,
+		HitRatio:   ratio,
+	}
+}
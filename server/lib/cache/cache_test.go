@@ -0,0 +1,101 @@
+package cache
+
+import (
+	"api/models"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	. "github.com/smartystreets/goconvey/convey"
+	"gorm.io/gorm"
+)
+
+func testBackends(t *testing.T) map[string]func(maxEntries int) Backend {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.CacheEntry{}); err != nil {
+		t.Fatal(err)
+	}
+
+	return map[string]func(maxEntries int) Backend{
+		"memory": func(maxEntries int) Backend {
+			return NewMemoryBackend(maxEntries)
+		},
+		"sqlite": func(maxEntries int) Backend {
+			db.Where("1 = 1").Delete(&models.CacheEntry{})
+			return NewSQLiteBackendWithDB(db, maxEntries)
+		},
+	}
+}
+
+func TestCache(t *testing.T) {
+	for name, newBackend := range testBackends(t) {
+		Convey("Subject: Search cache with "+name+" backend\n", t, func() {
+			c := New(newBackend(2), time.Minute, 2, true)
+
+			Convey("Queries should be normalized into the key", func() {
+				c.Set("google", "  Dune  Messiah ", []string{"dune"})
+
+				var out []string
+				So(c.Get("GOOGLE", "dune messiah", &out), ShouldBeTrue)
+				So(out, ShouldResemble, []string{"dune"})
+			})
+
+			Convey("Hits and misses should be counted", func() {
+				var out []string
+				So(c.Get("GOOGLE", "dune", &out), ShouldBeFalse)
+				c.Set("GOOGLE", "dune", []string{"dune"})
+				So(c.Get("GOOGLE", "dune", &out), ShouldBeTrue)
+
+				stats := c.Stats()
+				So(stats.Hits, ShouldEqual, 1)
+				So(stats.Misses, ShouldEqual, 1)
+				So(stats.Entries, ShouldEqual, 1)
+				So(stats.Backend, ShouldEqual, name)
+			})
+
+			Convey("Entries above the max size should be evicted", func() {
+				c.Set("GOOGLE", "one", 1)
+				time.Sleep(5 * time.Millisecond)
+				c.Set("GOOGLE", "two", 2)
+				time.Sleep(5 * time.Millisecond)
+				c.Set("GOOGLE", "three", 3)
+
+				var out int
+				So(c.Get("GOOGLE", "one", &out), ShouldBeFalse)
+				So(c.Get("GOOGLE", "three", &out), ShouldBeTrue)
+				So(c.Stats().Entries, ShouldEqual, 2)
+			})
+
+			Convey("Expired entries should not be returned", func() {
+				short := New(newBackend(10), 10*time.Millisecond, 10, true)
+				short.Set("GOOGLE", "dune", 1)
+				time.Sleep(20 * time.Millisecond)
+
+				var out int
+				So(short.Get("GOOGLE", "dune", &out), ShouldBeFalse)
+			})
+
+			Convey("Purge should remove entries and reset stats", func() {
+				c.Set("GOOGLE", "dune", 1)
+				var out int
+				c.Get("GOOGLE", "dune", &out)
+
+				So(c.Purge(), ShouldBeNil)
+				stats := c.Stats()
+				So(stats.Entries, ShouldEqual, 0)
+				So(stats.Hits, ShouldEqual, 0)
+			})
+		})
+	}
+
+	Convey("Subject: Disabled search cache\n", t, func() {
+		c := New(NewMemoryBackend(10), time.Minute, 10, false)
+		c.Set("GOOGLE", "dune", 1)
+
+		var out int
+		So(c.Get("GOOGLE", "dune", &out), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,105 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryBackend is an in-process LRU cache bounded by entry count.
+type MemoryBackend struct {
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List
+	mutex      sync.Mutex
+}
+
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewMemoryBackend creates an LRU backend. A maxEntries of zero or less means unbounded.
+func NewMemoryBackend(maxEntries int) *MemoryBackend {
+	return &MemoryBackend{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+func (m *MemoryBackend) Name() string {
+	return "memory"
+}
+
+func (m *MemoryBackend) Get(key string) ([]byte, bool, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	element, ok := m.entries[key]
+	if !ok {
+		return nil, false, nil
+	}
+
+	entry := element.Value.(*memoryEntry)
+	if time.Now().After(entry.expiresAt) {
+		m.remove(element)
+		return nil, false, nil
+	}
+
+	m.order.MoveToFront(element)
+	return entry.value, true, nil
+}
+
+func (m *MemoryBackend) Set(key string, value []byte, ttl time.Duration) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	expiresAt := time.Now().Add(ttl)
+	if element, ok := m.entries[key]; ok {
+		entry := element.Value.(*memoryEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		m.order.MoveToFront(element)
+		return nil
+	}
+
+	m.entries[key] = m.order.PushFront(&memoryEntry{key: key, value: value, expiresAt: expiresAt})
+
+	for m.maxEntries > 0 && m.order.Len() > m.maxEntries {
+		m.remove(m.order.Back())
+	}
+
+	return nil
+}
+
+func (m *MemoryBackend) Len() (int, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for element := m.order.Back(); element != nil; {
+		prev := element.Prev()
+		if now.After(element.Value.(*memoryEntry).expiresAt) {
+			m.remove(element)
+		}
+		element = prev
+	}
+
+	return m.order.Len(), nil
+}
+
+func (m *MemoryBackend) Purge() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.entries = make(map[string]*list.Element)
+	m.order.Init()
+	return nil
+}
+
+func (m *MemoryBackend) remove(element *list.Element) {
+	m.order.Remove(element)
+	delete(m.entries, element.Value.(*memoryEntry).key)
+}
@@ -0,0 +1,88 @@
+package cache
+
+import (
+	"api/database"
+	"api/models"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SQLiteBackend persists cache entries in the application database so they
+// survive restarts.
+type SQLiteBackend struct {
+	db         *gorm.DB
+	maxEntries int
+}
+
+// NewSQLiteBackend creates a backend on the application database. A maxEntries
+// of zero or less means unbounded.
+func NewSQLiteBackend(maxEntries int) *SQLiteBackend {
+	return NewSQLiteBackendWithDB(database.DB, maxEntries)
+}
+
+// NewSQLiteBackendWithDB creates a backend on the given database.
+func NewSQLiteBackendWithDB(db *gorm.DB, maxEntries int) *SQLiteBackend {
+	return &SQLiteBackend{db: db, maxEntries: maxEntries}
+}
+
+func (s *SQLiteBackend) Name() string {
+	return "sqlite"
+}
+
+func (s *SQLiteBackend) Get(key string) ([]byte, bool, error) {
+	var entry models.CacheEntry
+	err := s.db.Where("key = ? AND expires_at > ?", key, time.Now()).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	return entry.Value, true, nil
+}
+
+func (s *SQLiteBackend) Set(key string, value []byte, ttl time.Duration) error {
+	entry := models.CacheEntry{
+		Key:       key,
+		Value:     value,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+
+	err := s.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"value", "expires_at", "created_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return err
+	}
+
+	return s.evict()
+}
+
+func (s *SQLiteBackend) Len() (int, error) {
+	var count int64
+	err := s.db.Model(&models.CacheEntry{}).Where("expires_at > ?", time.Now()).Count(&count).Error
+	return int(count), err
+}
+
+func (s *SQLiteBackend) Purge() error {
+	return s.db.Where("1 = 1").Delete(&models.CacheEntry{}).Error
+}
+
+// evict drops expired entries and then the oldest entries above maxEntries.
+func (s *SQLiteBackend) evict() error {
+	if err := s.db.Where("expires_at <= ?", time.Now()).Delete(&models.CacheEntry{}).Error; err != nil {
+		return err
+	}
+
+	if s.maxEntries <= 0 {
+		return nil
+	}
+
+	keep := s.db.Model(&models.CacheEntry{}).Select("key").Order("created_at DESC").Limit(s.maxEntries)
+	return s.db.Where("key NOT IN (?)", keep).Delete(&models.CacheEntry{}).Error
+}
@@ -1,24 +1,81 @@
 package cwa
 
 import (
+	"api/lib/download"
 	"api/models"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"net/http"
-	"regexp"
+	"net/url"
 	"strings"
+	"time"
 
 	"github.com/beego/beego/v2/core/config"
 	"github.com/beego/beego/v2/core/logs"
 )
 
-// query the CWA API for books matching the given query.
-func search(cwaURL, query string) ([]CWABook, error) {
+func init() {
+	download.Register("CWA", func() (download.Downloader, error) {
+		cwaURL, err := config.String("download::cwaurl")
+		if err != nil || cwaURL == "" {
+			logs.Critical("Missing download::cwaurl config setting. Unable to perform CWA search.")
+			return nil, errors.New("Missing download::cwaurl config setting.")
+		}
+		downloader := NewDownloader(cwaURL)
+		downloader.Scorer = download.ConfiguredScorer()
+		return downloader, nil
+	})
+}
+
+// Downloader searches and downloads books through a Calibre-Web-Automated
+// downloader instance.
+type Downloader struct {
+	BaseURL string
+	Client  *http.Client
+	Scorer  *download.Scorer
+}
+
+// NewDownloader returns a CWA downloader for the instance at baseURL.
+func NewDownloader(baseURL string) *Downloader {
+	return &Downloader{
+		BaseURL: strings.TrimRight(baseURL, "/"),
+		Client:  &http.Client{Timeout: 30 * time.Second},
+		Scorer:  download.NewScorer(),
+	}
+}
+
+func (d *Downloader) Name() string {
+	return "cwa"
+}
+
+// Query prefers the request's ISBN-13, then ISBN-10, then its title.
+func (d *Downloader) Query(request models.BookRequest) string {
+	if request.ISBN13 != nil {
+		logs.Debug("Performing CWA search with ISBN-13")
+		return *request.ISBN13
+	} else if request.ISBN10 != nil {
+		logs.Debug("Performing CWA search with ISBN-10")
+		return *request.ISBN10
+	}
+
+	logs.Debug("Performing CWA search with title")
+	return request.Title
+}
+
+// Search queries the CWA API for books matching the request.
+func (d *Downloader) Search(ctx context.Context, request models.BookRequest) ([]download.Release, error) {
+	query := d.Query(request)
 	logs.Info("Searching for books with CWA; query=%s...", query)
 
-	url := fmt.Sprintf("%s/request/api/search", cwaURL)
-	resp, err := http.Get(fmt.Sprintf("%s?query=%s", url, query))
+	reqURL := fmt.Sprintf("%s/request/api/search?query=%s", d.BaseURL, url.QueryEscape(query))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := d.Client.Do(req)
 	if err != nil {
 		logs.Info("Error making search request: %v", err)
 		return nil, err
@@ -37,115 +94,40 @@ func search(cwaURL, query string) ([]CWABook, error) {
 	}
 
 	logs.Info("Search results: %v", books)
-	return books, nil
-}
-
-// fetche a book by its ID from the CWA API.
-func download(cwaURL, bookID string) error {
-	logs.Info("Downloading book with CWA; id=%s...", bookID)
-
-	url := fmt.Sprintf("%s/request/api/download?id=%s", cwaURL, bookID)
-	resp, err := http.Get(url)
-	if err != nil {
-		logs.Info("Error making download request: %v", err)
-		return err
-	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		logs.Info("CWA download response status: %d", resp.StatusCode)
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	releases := make([]download.Release, 0, len(books))
+	for _, book := range books {
+		releases = append(releases, book.Release())
 	}
+	return releases, nil
+}
 
-	return nil
+// Rank returns the releases by score, best first. Every candidate's score is logged.
+func (d *Downloader) Rank(request models.BookRequest, releases []download.Release) ([]download.Release, error) {
+	return d.Scorer.Rank(d.Name(), request, releases)
 }
 
-// HandleSearchAndDownload handles searching and downloading books based on the request.
-func HandleSearchAndDownload(request models.BookRequest) (*CWABook, error) {
-	var books []CWABook
-	var err error
+// Download asks CWA to fetch the release by its ID.
+func (d *Downloader) Download(ctx context.Context, release download.Release) (*download.Handoff, error) {
+	logs.Info("Downloading book with CWA; id=%s...", release.ID)
 
-	cwaURL, err := config.String("download::cwaurl")
+	reqURL := fmt.Sprintf("%s/request/api/download?id=%s", d.BaseURL, url.QueryEscape(release.ID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
-		logs.Critical("Missing download::cwaurl config setting. Unable to perform CWA search.")
-		return nil, errors.New("Missing download::cwaurl config setting.")
-	}
-
-	// Prefer ISBN-13 for searching
-	if request.ISBN13 != nil {
-		logs.Debug("Performing CWA search with ISBN-13")
-		books, err = search(cwaURL, *request.ISBN13)
-	} else if request.ISBN10 != nil {
-		logs.Debug("Performing CWA search with ISBN-10")
-		books, err = search(cwaURL, *request.ISBN10)
-	} else {
-		logs.Debug("Performing CWA search with title")
-		books, err = search(cwaURL, request.Title)
+		return nil, err
 	}
 
+	resp, err := d.Client.Do(req)
 	if err != nil {
-		logs.Warn("Hit an error while attempting download: \n%v\n", err)
+		logs.Info("Error making download request: %v", err)
 		return nil, err
 	}
+	defer resp.Body.Close()
 
-	if len(books) == 0 {
-		logs.Info("No results found when searching with CWA")
-		return nil, errors.New("No results found when searching with CWA")
-	}
-
-	maxMB := config.DefaultFloat("download::ebookmaxbytes", 25<<20) / (1024 * 1024)
-	minMB := config.DefaultFloat("download::ebookminbytes", 104858) / (1024 * 1024)
-
-	// Parse books and download books that pass requirements
-	for _, book := range books {
-		size, err := extractSizeInMB(book.Size)
-		if err != nil || size > maxMB || size < minMB {
-			logs.Info("Invalid size for book: %v", err)
-			continue
-		}
-		if !strings.Contains(strings.ToLower(book.Title), strings.ToLower(request.Title)) {
-			logs.Info("Invalid title: %s", book.Title)
-			continue
-		}
-
-		if containsBlockedTerms(strings.ToLower(book.Title)) {
-			logs.Info("Title contains one or more pre-configured blocked terms: %s", book.Title)
-			continue
-		}
-
-		if err := download(cwaURL, book.ID); err != nil {
-			logs.Info("Error downloading book: %v", err)
-			continue
-		}
-
-		return &book, nil
+	if resp.StatusCode != http.StatusOK {
+		logs.Info("CWA download response status: %d", resp.StatusCode)
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	return nil, nil
 }
-
-// extractSizeInMB parses the size string and extracts the numeric part in MB.
-func extractSizeInMB(size string) (float64, error) {
-	re := regexp.MustCompile(`([\d.]+)MB`)
-	match := re.FindStringSubmatch(size)
-	if len(match) == 2 {
-		var result float64
-		fmt.Sscanf(match[1], "%f", &result)
-		return result, nil
-	}
-	return 0, errors.New("invalid size format")
-}
-
-func containsBlockedTerms(target string) bool {
-	// Split the blocked terms by comma
-	blockedTerms := strings.Split(config.DefaultString("download::blockedterms", ""), ",")
-
-	// Iterate through each blocked term and check if it exists in the target string
-	for _, term := range blockedTerms {
-		trimmedTerm := strings.TrimSpace(term) // Trim any extra whitespace
-		if trimmedTerm != "" && strings.Contains(target, strings.ToLower(trimmedTerm)) {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,82 @@
+package cwa
+
+import (
+	"api/lib/download"
+	"api/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDownloader(t *testing.T) {
+	var downloaded string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/request/api/search":
+			if r.URL.Query().Get("query") != "9780441013593" {
+				json.NewEncoder(w).Encode([]CWABook{})
+				return
+			}
+			json.NewEncoder(w).Encode([]CWABook{{
+				ID:           "abc",
+				Title:        "Dune",
+				Author:       "Frank Herbert",
+				Format:       "epub",
+				Size:         "1.2MB",
+				DownloadURLs: []string{"https://example.com/dune.epub"},
+			}})
+		case "/request/api/download":
+			downloaded = r.URL.Query().Get("id")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	isbn13 := "9780441013593"
+	isbn10 := "0441013597"
+
+	Convey("Subject: CWA downloader", t, func() {
+		downloader := NewDownloader(server.URL + "/")
+		So(downloader.Name(), ShouldEqual, "cwa")
+
+		Convey("Queries prefer ISBN-13, then ISBN-10, then the title", func() {
+			So(downloader.Query(models.BookRequest{Title: "Dune", ISBN10: &isbn10, ISBN13: &isbn13}), ShouldEqual, isbn13)
+			So(downloader.Query(models.BookRequest{Title: "Dune", ISBN10: &isbn10}), ShouldEqual, isbn10)
+			So(downloader.Query(models.BookRequest{Title: "Dune"}), ShouldEqual, "Dune")
+		})
+
+		Convey("Search results are returned as releases", func() {
+			releases, err := downloader.Search(context.Background(), models.BookRequest{Title: "Dune", ISBN13: &isbn13})
+			So(err, ShouldBeNil)
+			So(len(releases), ShouldEqual, 1)
+			So(releases[0].ID, ShouldEqual, "abc")
+			So(releases[0].Size, ShouldEqual, "1.2MB")
+			So(releases[0].URL, ShouldEqual, "https://example.com/dune.epub")
+		})
+
+		Convey("The best scoring release is picked rather than the first title match", func() {
+			request := models.BookRequest{Title: "Hobbit", Author: "J.R.R. Tolkien"}
+			releases := []download.Release{
+				CWABook{ID: "wrong", Title: "The Hobbit Companion", Author: "David Day", Format: "epub", Size: "2.0MB"}.Release(),
+				CWABook{ID: "right", Title: "The Hobbit, or There and Back Again", Author: "J. R. R. Tolkien", Format: "epub", Size: "1.5MB"}.Release(),
+			}
+
+			ranked, err := downloader.Rank(request, releases)
+			So(err, ShouldBeNil)
+			So(ranked[0].ID, ShouldEqual, "right")
+			So(ranked[0].Bytes, ShouldEqual, int64(1.5*1024*1024))
+		})
+
+		Convey("Downloads request the release by ID", func() {
+			handoff, err := downloader.Download(context.Background(), download.Release{ID: "abc"})
+			So(err, ShouldBeNil)
+			So(handoff, ShouldBeNil)
+			So(downloaded, ShouldEqual, "abc")
+		})
+	})
+}
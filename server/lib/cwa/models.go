@@ -1,5 +1,7 @@
 package cwa
 
+import "api/lib/download"
+
 // CWABook represents a book returned by the CWA API.
 type CWABook struct {
 	Author       string   `json:"author"`
@@ -13,3 +15,25 @@ type CWABook struct {
 	Title        string   `json:"title"`
 	Year         string   `json:"year"`
 }
+
+// Release converts the book into a downloadable release.
+func (b CWABook) Release() download.Release {
+	var url string
+	if len(b.DownloadURLs) > 0 {
+		url = b.DownloadURLs[0]
+	}
+
+	bytes, _ := download.ParseSize(b.Size)
+
+	return download.Release{
+		ID:       b.ID,
+		Title:    b.Title,
+		Author:   b.Author,
+		Format:   b.Format,
+		Language: b.Language,
+		Year:     b.Year,
+		Size:     b.Size,
+		URL:      url,
+		Bytes:    bytes,
+	}
+}
@@ -0,0 +1,286 @@
+package download
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+func init() {
+	RegisterClient("BLACKHOLE", func() (Client, error) {
+		return configuredBlackhole()
+	})
+	Register("BLACKHOLE", func() (Downloader, error) {
+		name := config.DefaultString("download::blackholesource", "CWA")
+		if strings.EqualFold(strings.TrimSpace(name), "BLACKHOLE") {
+			return nil, errors.New("download::blackholesource can't be BLACKHOLE")
+		}
+
+		source, err := New(name)
+		if err != nil {
+			return nil, fmt.Errorf("download::blackholesource: %w", err)
+		}
+		blackhole, err := configuredBlackhole()
+		if err != nil {
+			return nil, err
+		}
+		return &BlackholeDownloader{Source: source, Blackhole: blackhole}, nil
+	})
+}
+
+// configuredBlackhole builds the blackhole from download::blackholedir and
+// download::blackholeimportdir. The import directory is required so requests
+// aren't marked complete before the book has actually been downloaded.
+func configuredBlackhole() (*WatchedBlackhole, error) {
+	dir := config.DefaultString("download::blackholedir", "")
+	if dir == "" {
+		return nil, errors.New("download::blackholedir is not configured")
+	}
+	importDir := config.DefaultString("download::blackholeimportdir", "")
+	if importDir == "" {
+		return nil, errors.New("download::blackholeimportdir is not configured")
+	}
+	return NewWatchedBlackhole(dir, importDir), nil
+}
+
+// maxBlackholeFileBytes caps the size of a .torrent or .nzb fetched for the blackhole.
+const maxBlackholeFileBytes = 10 << 20
+
+var unsafeFileChars = regexp.MustCompile(`[^\w\-. ]+`)
+
+// Blackhole writes releases into a directory watched by a torrent or usenet
+// client.
+type Blackhole struct {
+	Dir        string
+	HTTPClient *http.Client
+}
+
+// NewBlackhole returns a blackhole client writing into dir.
+func NewBlackhole(dir string) *Blackhole {
+	return &Blackhole{
+		Dir:        dir,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (b *Blackhole) Name() string {
+	return "blackhole"
+}
+
+// Supports accepts both torrents and NZBs.
+func (b *Blackhole) Supports(protocol Protocol) bool {
+	return true
+}
+
+// Add writes the release's .torrent, .nzb or magnet link into the directory and
+// returns the path of the written file. Releases linking straight to a book
+// file are written as that file.
+func (b *Blackhole) Add(ctx context.Context, release Release) (string, error) {
+	if release.URL == "" {
+		return "", errors.New("release has no download URL")
+	}
+
+	if err := os.MkdirAll(b.Dir, 0o755); err != nil {
+		return "", err
+	}
+
+	if strings.HasPrefix(release.URL, "magnet:") {
+		path := b.path(release, ".magnet")
+		if err := os.WriteFile(path, []byte(release.URL), 0o644); err != nil {
+			return "", err
+		}
+		logs.Info("Wrote magnet link for %s to %s", release.Title, path)
+		return path, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, release.URL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := b.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code fetching release: %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBlackholeFileBytes))
+	if err != nil {
+		return "", err
+	}
+
+	ext := bookExtension(release, resp)
+	if ext == "" && release.Protocol == ProtocolUsenet {
+		ext = ".nzb"
+	} else if ext == "" {
+		ext = ".torrent"
+	}
+
+	path := b.path(release, ext)
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return "", err
+	}
+
+	logs.Info("Wrote %s for %s to %s", ext, release.Title, path)
+	return path, nil
+}
+
+// path returns a file path in the directory named after the release.
+func (b *Blackhole) path(release Release, ext string) string {
+	name := strings.TrimSpace(unsafeFileChars.ReplaceAllString(release.Title, "_"))
+	if name == "" {
+		name = "release"
+	}
+	if len(name) > 150 {
+		name = name[:150]
+	}
+	return filepath.Join(b.Dir, name+ext)
+}
+
+// bookExtensions are the files that count as a downloaded book.
+var bookExtensions = map[string]bool{
+	".epub": true, ".mobi": true, ".azw": true, ".azw3": true, ".pdf": true, ".fb2": true,
+	".m4b": true, ".m4a": true, ".mp3": true,
+}
+
+// bookExtension returns the book file extension of a direct download, taken
+// from the response's file name or the URL, or "" for torrents and NZBs.
+func bookExtension(release Release, resp *http.Response) string {
+	var names []string
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil {
+		names = append(names, params["filename"])
+	}
+	names = append(names, resp.Request.URL.Path)
+	if release.Protocol == "" && release.Format != "" {
+		names = append(names, "."+release.Format)
+	}
+
+	for _, name := range names {
+		if ext := strings.ToLower(filepath.Ext(name)); bookExtensions[ext] {
+			return ext
+		}
+	}
+	return ""
+}
+
+// WatchedBlackhole is a blackhole that tracks releases until the finished book
+// shows up in an import directory, such as the client's completed folder.
+type WatchedBlackhole struct {
+	*Blackhole
+	ImportDir string
+}
+
+// NewWatchedBlackhole returns a blackhole writing into dir and watching importDir.
+func NewWatchedBlackhole(dir, importDir string) *WatchedBlackhole {
+	return &WatchedBlackhole{Blackhole: NewBlackhole(dir), ImportDir: importDir}
+}
+
+// Status looks in the import directory for a book file named after the
+// release, or inside a folder named after it. The id is the path Add wrote.
+func (b *WatchedBlackhole) Status(ctx context.Context, id string) (*Status, error) {
+	name := normalizeName(strings.TrimSuffix(filepath.Base(id), filepath.Ext(id)))
+	if name == "" {
+		return nil, fmt.Errorf("invalid blackhole id %q", id)
+	}
+
+	var found string
+	if index, err := watchImportDir(b.ImportDir); err == nil {
+		found = index.find(name)
+	} else {
+		logs.Warn("Unable to watch import directory %s, searching it instead: %v", b.ImportDir, err)
+		if found, err = findImport(ctx, b.ImportDir, name); err != nil {
+			return nil, err
+		}
+	}
+
+	if found != "" {
+		logs.Info("Found imported book %s", found)
+		return &Status{State: StateCompleted, Progress: 1}, nil
+	}
+
+	// Clients remove files from the blackhole once they've picked them up
+	if _, err := os.Stat(id); err == nil {
+		return &Status{State: StateQueued}, nil
+	}
+	return &Status{State: StateDownloading}, nil
+}
+
+// normalizeName lowercases a file name and collapses everything but letters
+// and digits into single spaces, so names compare equal however they were sanitized.
+func normalizeName(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// BlackholeDownloader finds releases with another downloader and writes them
+// into the blackhole instead of downloading them itself, for setups without an
+// indexer. It's listed as BLACKHOLE in download::downloaders and searches the
+// downloader in download::blackholesource.
+type BlackholeDownloader struct {
+	Source    Downloader
+	Blackhole *WatchedBlackhole
+}
+
+func (d *BlackholeDownloader) Name() string {
+	return "blackhole"
+}
+
+func (d *BlackholeDownloader) Query(request models.BookRequest) string {
+	return d.Source.Query(request)
+}
+
+func (d *BlackholeDownloader) Search(ctx context.Context, request models.BookRequest) ([]Release, error) {
+	return d.Source.Search(ctx, request)
+}
+
+// Rank keeps the source's ranking of the releases that have a download URL.
+func (d *BlackholeDownloader) Rank(request models.BookRequest, releases []Release) ([]Release, error) {
+	ranked, err := d.Source.Rank(request, releases)
+	if err != nil {
+		return nil, err
+	}
+
+	var linked []Release
+	for _, release := range ranked {
+		if release.URL != "" {
+			linked = append(linked, release)
+		}
+	}
+	if len(linked) == 0 {
+		return nil, ErrNoSuitableRelease
+	}
+	return linked, nil
+}
+
+// Download writes the release into the blackhole. The request is tracked until
+// the book shows up in the import directory.
+func (d *BlackholeDownloader) Download(ctx context.Context, release Release) (*Handoff, error) {
+	id, err := d.Blackhole.Add(ctx, release)
+	if err != nil {
+		return nil, err
+	}
+	return &Handoff{Client: d.Blackhole.Name(), ID: id}, nil
+}
+
+// SupportsMedia reports whether the source can fetch the media type.
+func (d *BlackholeDownloader) SupportsMedia(mediaType models.MediaType) bool {
+	return Supports(d.Source, mediaType)
+}
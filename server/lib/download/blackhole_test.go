@@ -0,0 +1,230 @@
+package download
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newBookServer serves a release file at /release and a book at /get.
+func newBookServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/release":
+			w.Write([]byte("release contents"))
+		case "/get":
+			w.Header().Set("Content-Disposition", `attachment; filename="Dune.epub"`)
+			w.Write([]byte("book contents"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestBlackhole(t *testing.T) {
+	server := newBookServer()
+	defer server.Close()
+
+	Convey("Subject: Blackhole download client", t, func() {
+		dir := filepath.Join(t.TempDir(), "watch")
+		blackhole := NewBlackhole(dir)
+
+		Convey("Torrents are fetched into the directory", func() {
+			path, err := blackhole.Add(context.Background(), Release{Title: "Dune: Deluxe", URL: server.URL + "/release", Protocol: ProtocolTorrent})
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, filepath.Join(dir, "Dune_ Deluxe.torrent"))
+
+			data, err := os.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, "release contents")
+		})
+
+		Convey("NZBs get the .nzb extension", func() {
+			path, err := blackhole.Add(context.Background(), Release{Title: "Dune", URL: server.URL + "/release", Protocol: ProtocolUsenet})
+			So(err, ShouldBeNil)
+			So(filepath.Ext(path), ShouldEqual, ".nzb")
+		})
+
+		Convey("Magnet links are written without being fetched", func() {
+			magnet := "magnet:?xt=urn:btih:abc"
+			path, err := blackhole.Add(context.Background(), Release{Title: "Dune", URL: magnet})
+			So(err, ShouldBeNil)
+
+			data, err := os.ReadFile(path)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEqual, magnet)
+		})
+
+		Convey("Direct downloads are written as the book file", func() {
+			path, err := blackhole.Add(context.Background(), Release{Title: "Dune", URL: server.URL + "/get"})
+			So(err, ShouldBeNil)
+			So(path, ShouldEqual, filepath.Join(dir, "Dune.epub"))
+		})
+
+		Convey("Failed fetches are returned as errors", func() {
+			_, err := blackhole.Add(context.Background(), Release{Title: "Dune", URL: server.URL + "/missing"})
+			So(err, ShouldNotBeNil)
+
+			_, err = blackhole.Add(context.Background(), Release{Title: "Dune"})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestWatchedBlackhole(t *testing.T) {
+	server := newBookServer()
+	defer server.Close()
+
+	Convey("Subject: Watching the import directory for finished books", t, func() {
+		root := t.TempDir()
+		importDir := filepath.Join(root, "complete")
+		So(os.MkdirAll(importDir, 0o755), ShouldBeNil)
+
+		blackhole := NewWatchedBlackhole(filepath.Join(root, "watch"), importDir)
+		var client Client = blackhole
+		_, ok := client.(Tracker)
+		So(ok, ShouldBeTrue)
+
+		path, err := blackhole.Add(context.Background(), Release{Title: "Frank Herbert - Dune [EPUB]", URL: "magnet:?xt=urn:btih:abc"})
+		So(err, ShouldBeNil)
+
+		Convey("Releases still in the blackhole are queued", func() {
+			status, err := blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateQueued)
+
+			So(os.Remove(path), ShouldBeNil)
+			status, err = blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateDownloading)
+		})
+
+		Convey("A book in a folder named after the release completes it", func() {
+			release := filepath.Join(importDir, "Frank Herbert - Dune [EPUB]", "epub")
+			So(os.MkdirAll(release, 0o755), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(release, "dune.epub"), []byte("book"), 0o644), ShouldBeNil)
+
+			status, err := blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateCompleted)
+		})
+
+		Convey("A book file named after the release completes it", func() {
+			So(os.WriteFile(filepath.Join(importDir, "Frank Herbert - Dune [EPUB].epub"), []byte("book"), 0o644), ShouldBeNil)
+
+			status, err := blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateCompleted)
+		})
+
+		Convey("Other files and unfinished downloads don't count", func() {
+			So(os.WriteFile(filepath.Join(importDir, "Frank Herbert - Dune [EPUB].epub.part"), []byte("book"), 0o644), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(importDir, "Children of Dune.epub"), []byte("book"), 0o644), ShouldBeNil)
+
+			status, err := blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateQueued)
+		})
+
+		Convey("Books imported after the directory is watched are found", func() {
+			status, err := blackhole.Status(context.Background(), path)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateQueued)
+
+			release := filepath.Join(importDir, "Frank Herbert - Dune [EPUB]")
+			So(os.MkdirAll(release, 0o755), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(release, "dune.epub"), []byte("book"), 0o644), ShouldBeNil)
+
+			So(waitForState(blackhole, path, StateCompleted), ShouldBeTrue)
+
+			So(os.RemoveAll(release), ShouldBeNil)
+			So(waitForState(blackhole, path, StateQueued), ShouldBeTrue)
+		})
+
+		Convey("Direct downloads complete once they reach the import directory", func() {
+			direct, err := blackhole.Add(context.Background(), Release{Title: "Dune", URL: server.URL + "/get"})
+			So(err, ShouldBeNil)
+
+			status, err := blackhole.Status(context.Background(), direct)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, StateQueued)
+
+			So(os.Rename(direct, filepath.Join(importDir, "Dune.epub")), ShouldBeNil)
+			So(waitForState(blackhole, direct, StateCompleted), ShouldBeTrue)
+		})
+
+		Convey("The directory can be searched without watching it", func() {
+			So(os.WriteFile(filepath.Join(importDir, "Frank Herbert - Dune [EPUB].epub"), []byte("book"), 0o644), ShouldBeNil)
+
+			found, err := findImport(context.Background(), importDir, "frank herbert dune epub")
+			So(err, ShouldBeNil)
+			So(found, ShouldEqual, filepath.Join(importDir, "Frank Herbert - Dune [EPUB].epub"))
+
+			found, err = findImport(context.Background(), importDir, "children of dune")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeEmpty)
+		})
+	})
+}
+
+// waitForState polls the release until it reaches the state, giving the
+// import directory watcher time to see changes.
+func waitForState(blackhole *WatchedBlackhole, id string, state State) bool {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		status, err := blackhole.Status(context.Background(), id)
+		if err == nil && status.State == state {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestBlackholeDownloader(t *testing.T) {
+	server := newBookServer()
+	defer server.Close()
+
+	request := models.BookRequest{ID: 1, Title: "Dune"}
+
+	Convey("Subject: Sending another downloader's releases to the blackhole", t, func() {
+		root := t.TempDir()
+		source := &stubDownloader{name: "stub", releases: []Release{
+			{ID: "1", Title: "Dune"},
+			{ID: "2", Title: "Dune", URL: server.URL + "/get"},
+		}}
+		downloader := &BlackholeDownloader{Source: source, Blackhole: NewWatchedBlackhole(filepath.Join(root, "watch"), root)}
+
+		Convey("Releases without a download URL are dropped", func() {
+			ranked, err := downloader.Rank(request, source.releases)
+			So(err, ShouldBeNil)
+			So(len(ranked), ShouldEqual, 1)
+			So(ranked[0].ID, ShouldEqual, "2")
+
+			_, err = downloader.Rank(request, source.releases[:1])
+			So(errors.Is(err, ErrNoSuitableRelease), ShouldBeTrue)
+		})
+
+		Convey("Releases are written to the blackhole and tracked", func() {
+			attempt, err := Run(context.Background(), request, []Downloader{downloader}, nil)
+			So(err, ShouldBeNil)
+			So(attempt.Downloader, ShouldEqual, "blackhole")
+			So(attempt.Release.ID, ShouldEqual, "2")
+			So(attempt.Handoff, ShouldResemble, &Handoff{Client: "blackhole", ID: filepath.Join(root, "watch", "Dune.epub")})
+			So(source.downloads, ShouldBeEmpty)
+		})
+
+		Convey("Media support follows the source", func() {
+			So(Supports(downloader, models.MTEbook), ShouldBeTrue)
+			So(Supports(downloader, models.MTAudiobook), ShouldBeFalse)
+		})
+	})
+}
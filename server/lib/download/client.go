@@ -0,0 +1,182 @@
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// ErrUnknownClient is returned when no download client is registered under the requested name.
+var ErrUnknownClient = errors.New("unknown download client")
+
+// Client receives releases found by indexer downloaders, such as a torrent
+// client or a blackhole directory.
+type Client interface {
+	// Name returns the identifier used for the client in download::client.
+	Name() string
+	// Supports reports whether the client can download releases of the protocol.
+	Supports(protocol Protocol) bool
+	// Add hands the release to the client and returns an ID for tracking it.
+	Add(ctx context.Context, release Release) (string, error)
+}
+
+// State is the progress of a release in a download client.
+type State string
+
+const (
+	StateQueued      State = "queued"
+	StateDownloading State = "downloading"
+	StateCompleted   State = "completed"
+	StateFailed      State = "failed"
+)
+
+// Status reports a release's progress in a download client.
+type Status struct {
+	State State
+	// Progress is the completed fraction from 0 to 1.
+	Progress float64
+	// Reason explains a failure.
+	Reason string
+}
+
+// Tracker reports when a release finishes downloading. Download clients and
+// downloaders that manage their own downloads, like Readarr, implement it.
+type Tracker interface {
+	Name() string
+	// Status returns the progress of the release added under id.
+	Status(ctx context.Context, id string) (*Status, error)
+}
+
+// Handoff identifies a release that is still downloading in a tracked client.
+type Handoff struct {
+	Client string `json:"client"`
+	ID     string `json:"id"`
+}
+
+// ClientFactory builds a download client from the current configuration.
+type ClientFactory func() (Client, error)
+
+var (
+	clientsMu sync.RWMutex
+	clients   = make(map[string]ClientFactory)
+)
+
+// RegisterClient makes a download client available under the given name. Names are case-insensitive.
+func RegisterClient(name string, factory ClientFactory) {
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+
+	clients[strings.ToUpper(name)] = factory
+}
+
+// NewClient builds the download client registered under the given name.
+func NewClient(name string) (Client, error) {
+	clientsMu.RLock()
+	factory, ok := clients[strings.ToUpper(name)]
+	clientsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownClient, name)
+	}
+
+	return factory()
+}
+
+// Clients returns the names of all registered download clients in sorted order.
+func Clients() []string {
+	clientsMu.RLock()
+	defer clientsMu.RUnlock()
+
+	names := make([]string, 0, len(clients))
+	for name := range clients {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// ConfiguredClients builds the download clients listed in download::client.
+// Several clients can be listed, e.g. a torrent and a usenet client; each
+// release goes to the first one that supports its protocol.
+func ConfiguredClients() ([]Client, error) {
+	var configured []Client
+
+	for _, name := range strings.Split(config.DefaultString("download::client", "BLACKHOLE"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		client, err := NewClient(name)
+		if err != nil {
+			return nil, err
+		}
+		configured = append(configured, client)
+	}
+
+	if len(configured) == 0 {
+		return nil, errors.New("download::client is not configured")
+	}
+
+	return configured, nil
+}
+
+// ClientFor returns the first client that supports the protocol, or nil.
+func ClientFor(clients []Client, protocol Protocol) Client {
+	for _, client := range clients {
+		if client.Supports(protocol) {
+			return client
+		}
+	}
+	return nil
+}
+
+// Hand adds the release to the first client supporting its protocol. A
+// Handoff is returned when the client can track the download to completion.
+func Hand(ctx context.Context, clients []Client, release Release) (*Handoff, error) {
+	client := ClientFor(clients, release.Protocol)
+	if client == nil {
+		return nil, fmt.Errorf("no download client supports %s releases", release.Protocol)
+	}
+
+	id, err := client.Add(ctx, release)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", client.Name(), err)
+	}
+
+	logs.Info("Handed %s to %s; id=%s", release.Title, client.Name(), id)
+
+	if _, ok := client.(Tracker); !ok {
+		return nil, nil
+	}
+	return &Handoff{Client: client.Name(), ID: id}, nil
+}
+
+// NewTracker builds the named download client, or downloader when no client
+// has the name, and checks that it can track downloads.
+func NewTracker(name string) (Tracker, error) {
+	var backend interface{ Name() string }
+
+	client, err := NewClient(name)
+	if errors.Is(err, ErrUnknownClient) {
+		backend, err = New(name)
+	} else {
+		backend = client
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tracker, ok := backend.(Tracker)
+	if !ok {
+		return nil, fmt.Errorf("%s can't track downloads", name)
+	}
+	return tracker, nil
+}
@@ -0,0 +1,240 @@
+// Package download defines the backends books are downloaded from and tries
+// the configured backends in priority order.
+package download
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+var (
+	// ErrUnknownDownloader is returned when no downloader is registered under the requested name.
+	ErrUnknownDownloader = errors.New("unknown downloader")
+	// ErrNoReleases is returned when a search finds nothing to download.
+	ErrNoReleases = errors.New("no releases found")
+	// ErrNoSuitableRelease is returned when every release found fails the download checks.
+	ErrNoSuitableRelease = errors.New("no release passed the download checks")
+)
+
+// Release is a downloadable copy of a book found by a downloader.
+type Release struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Author   string `json:"author"`
+	Format   string `json:"format"`
+	Language string `json:"language"`
+	Year     string `json:"year"`
+	Size     string `json:"size"`
+	// URL is where the release is fetched from, when the backend exposes one.
+	URL string `json:"url"`
+	// Fields below are set by indexer backends.
+	Protocol   Protocol `json:"protocol,omitempty"`
+	Bytes      int64    `json:"bytes,omitempty"`
+	Seeders    int      `json:"seeders,omitempty"`
+	Categories []int    `json:"categories,omitempty"`
+	Indexer    string   `json:"indexer,omitempty"`
+}
+
+// Protocol is how an indexer release is downloaded.
+type Protocol string
+
+const (
+	ProtocolTorrent Protocol = "torrent"
+	ProtocolUsenet  Protocol = "usenet"
+)
+
+// Downloader finds and downloads books from a single backend.
+type Downloader interface {
+	// Name returns the identifier used for the downloader in download::downloaders.
+	Name() string
+	// Query returns the search term used for the request.
+	Query(request models.BookRequest) string
+	// Search looks up releases of the requested book.
+	Search(ctx context.Context, request models.BookRequest) ([]Release, error)
+	// Rank returns the releases worth downloading, best first, or an error if
+	// none fit. The next one is tried when a download fails.
+	Rank(request models.BookRequest, releases []Release) ([]Release, error)
+	// Download fetches the release into the library. A Handoff is returned when
+	// the release is still downloading in a client that can be tracked.
+	Download(ctx context.Context, release Release) (*Handoff, error)
+}
+
+// MediaSupporter is implemented by downloaders that can fetch audiobooks.
+// Downloaders that don't implement it only fetch ebooks.
+type MediaSupporter interface {
+	// SupportsMedia reports whether the downloader can fetch the media type.
+	SupportsMedia(mediaType models.MediaType) bool
+}
+
+// Supports reports whether the downloader can fetch a request's media type.
+// Requests for both an ebook and an audiobook go to downloaders of either.
+func Supports(downloader Downloader, mediaType models.MediaType) bool {
+	if supporter, ok := downloader.(MediaSupporter); ok {
+		return supporter.SupportsMedia(mediaType)
+	}
+	return mediaType.WantsEbook()
+}
+
+// Factory builds a downloader from the current configuration.
+type Factory func() (Downloader, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a downloader available under the given name. Names are case-insensitive.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[strings.ToUpper(name)] = factory
+}
+
+// New builds the downloader registered under the given name.
+func New(name string) (Downloader, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToUpper(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownDownloader, name)
+	}
+
+	return factory()
+}
+
+// Downloaders returns the names of all registered downloaders in sorted order.
+func Downloaders() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Configured builds the downloaders listed in download::downloaders, in
+// priority order. Downloaders that can't be built are logged and skipped.
+func Configured() []Downloader {
+	var downloaders []Downloader
+
+	for _, name := range strings.Split(config.DefaultString("download::downloaders", "CWA"), ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		downloader, err := New(name)
+		if err != nil {
+			logs.Warn("Skipping downloader %s: %v", name, err)
+			continue
+		}
+		downloaders = append(downloaders, downloader)
+	}
+
+	return downloaders
+}
+
+// Attempt is the outcome of trying one downloader for a request.
+type Attempt struct {
+	Downloader string
+	Query      string
+	// Release is the release downloaded or, when every download failed, the last one tried.
+	Release *Release
+	// Handoff is set when the release is still downloading in a download client.
+	Handoff *Handoff
+	Err     error
+}
+
+// Run tries each downloader supporting the request's media type in order until
+// one downloads the book. Every attempt is passed to record. The successful
+// attempt is returned; if all downloaders fail, the returned error joins their errors.
+func Run(ctx context.Context, request models.BookRequest, downloaders []Downloader, record func(Attempt)) (*Attempt, error) {
+	if len(downloaders) == 0 {
+		return nil, errors.New("no downloaders configured")
+	}
+
+	var errs []error
+	for _, downloader := range downloaders {
+		if !Supports(downloader, request.MediaType) {
+			logs.Info("Skipping downloader %s for request #%d; it can't download %s requests", downloader.Name(), request.ID, request.MediaType)
+			continue
+		}
+
+		attempt := try(ctx, request, downloader)
+		if record != nil {
+			record(attempt)
+		}
+
+		if attempt.Err == nil {
+			return &attempt, nil
+		}
+
+		logs.Info("Downloader %s failed for request #%d: %v", attempt.Downloader, request.ID, attempt.Err)
+		errs = append(errs, fmt.Errorf("%s: %w", attempt.Downloader, attempt.Err))
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil, fmt.Errorf("no configured downloader can download %s requests", request.MediaType)
+	}
+	return nil, errors.Join(errs...)
+}
+
+func try(ctx context.Context, request models.BookRequest, downloader Downloader) Attempt {
+	attempt := Attempt{
+		Downloader: downloader.Name(),
+		Query:      downloader.Query(request),
+	}
+
+	releases, err := downloader.Search(ctx, request)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+	if len(releases) == 0 {
+		attempt.Err = ErrNoReleases
+		return attempt
+	}
+
+	ranked, err := downloader.Rank(request, releases)
+	if err != nil {
+		attempt.Err = err
+		return attempt
+	}
+
+	var errs []error
+	for i := range ranked {
+		attempt.Release = &ranked[i]
+		attempt.Handoff, err = downloader.Download(ctx, ranked[i])
+		if err == nil {
+			return attempt
+		}
+
+		logs.Info("Downloader %s couldn't download %s for request #%d: %v", attempt.Downloader, ranked[i].Title, request.ID, err)
+		errs = append(errs, err)
+
+		if ctx.Err() != nil {
+			break
+		}
+	}
+
+	attempt.Err = errors.Join(errs...)
+	return attempt
+}
@@ -0,0 +1,236 @@
+package download
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type stubDownloader struct {
+	name      string
+	releases  []Release
+	searchErr error
+	pickErr   error
+	dlErr     error
+	// failing are release IDs whose downloads fail with dlErr; when empty every download does
+	failing   []string
+	downloads []string
+}
+
+func (s *stubDownloader) Name() string                            { return s.name }
+func (s *stubDownloader) Query(request models.BookRequest) string { return request.Title }
+
+func (s *stubDownloader) Search(ctx context.Context, request models.BookRequest) ([]Release, error) {
+	return s.releases, s.searchErr
+}
+
+func (s *stubDownloader) Rank(request models.BookRequest, releases []Release) ([]Release, error) {
+	if s.pickErr != nil {
+		return nil, s.pickErr
+	}
+	return releases, nil
+}
+
+func (s *stubDownloader) Download(ctx context.Context, release Release) (*Handoff, error) {
+	s.downloads = append(s.downloads, release.ID)
+	if len(s.failing) > 0 && !slices.Contains(s.failing, release.ID) {
+		return nil, nil
+	}
+	return nil, s.dlErr
+}
+
+// audiobookDownloader is a stub that only downloads audiobooks.
+type audiobookDownloader struct {
+	stubDownloader
+}
+
+func (a *audiobookDownloader) SupportsMedia(mediaType models.MediaType) bool {
+	return mediaType.WantsAudiobook()
+}
+
+func TestRegistry(t *testing.T) {
+	Convey("Subject: Downloader registry", t, func() {
+		Register("stub", func() (Downloader, error) { return &stubDownloader{name: "stub"}, nil })
+
+		downloader, err := New("STUB")
+		So(err, ShouldBeNil)
+		So(downloader.Name(), ShouldEqual, "stub")
+		So(Downloaders(), ShouldContain, "STUB")
+
+		_, err = New("missing")
+		So(errors.Is(err, ErrUnknownDownloader), ShouldBeTrue)
+	})
+}
+
+func TestRun(t *testing.T) {
+	request := models.BookRequest{ID: 1, Title: "Dune"}
+
+	Convey("Subject: Trying downloaders in priority order", t, func() {
+		var recorded []Attempt
+		record := func(attempt Attempt) { recorded = append(recorded, attempt) }
+
+		Convey("The first downloader to succeed wins", func() {
+			empty := &stubDownloader{name: "empty"}
+			failing := &stubDownloader{name: "failing", releases: []Release{{ID: "f1"}}, dlErr: errors.New("connection refused")}
+			working := &stubDownloader{name: "working", releases: []Release{{ID: "w1", Title: "Dune"}}}
+			unused := &stubDownloader{name: "unused", releases: []Release{{ID: "u1"}}}
+
+			attempt, err := Run(context.Background(), request, []Downloader{empty, failing, working, unused}, record)
+			So(err, ShouldBeNil)
+			So(attempt.Downloader, ShouldEqual, "working")
+			So(attempt.Query, ShouldEqual, "Dune")
+			So(attempt.Release.ID, ShouldEqual, "w1")
+
+			So(len(recorded), ShouldEqual, 3)
+			So(errors.Is(recorded[0].Err, ErrNoReleases), ShouldBeTrue)
+			So(recorded[1].Err.Error(), ShouldEqual, "connection refused")
+			So(recorded[1].Release.ID, ShouldEqual, "f1")
+			So(unused.downloads, ShouldBeEmpty)
+		})
+
+		Convey("Failed downloads fall back to the next ranked release", func() {
+			downloader := &stubDownloader{name: "ranked", releases: []Release{{ID: "r1"}, {ID: "r2"}, {ID: "r3"}},
+				dlErr: errors.New("not found"), failing: []string{"r1"}}
+
+			attempt, err := Run(context.Background(), request, []Downloader{downloader}, record)
+			So(err, ShouldBeNil)
+			So(attempt.Release.ID, ShouldEqual, "r2")
+			So(downloader.downloads, ShouldResemble, []string{"r1", "r2"})
+
+			downloader.failing, downloader.downloads = nil, nil
+			_, err = Run(context.Background(), request, []Downloader{downloader}, record)
+			So(err.Error(), ShouldContainSubstring, "not found")
+			So(downloader.downloads, ShouldResemble, []string{"r1", "r2", "r3"})
+		})
+
+		Convey("Every failure is reported when no downloader succeeds", func() {
+			searchFails := &stubDownloader{name: "search", searchErr: errors.New("timeout")}
+			pickFails := &stubDownloader{name: "pick", releases: []Release{{ID: "p1"}}, pickErr: ErrNoSuitableRelease}
+
+			attempt, err := Run(context.Background(), request, []Downloader{searchFails, pickFails}, record)
+			So(attempt, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "search: timeout")
+			So(errors.Is(err, ErrNoSuitableRelease), ShouldBeTrue)
+			So(len(recorded), ShouldEqual, 2)
+			So(pickFails.downloads, ShouldBeEmpty)
+		})
+
+		Convey("Only downloaders supporting the request's media type are tried", func() {
+			ebooks := &stubDownloader{name: "ebooks", releases: []Release{{ID: "e1"}}}
+			audiobooks := &audiobookDownloader{stubDownloader{name: "audiobooks", releases: []Release{{ID: "a1"}}}}
+
+			audiobook := request
+			audiobook.MediaType = models.MTAudiobook
+			attempt, err := Run(context.Background(), audiobook, []Downloader{ebooks, audiobooks}, record)
+			So(err, ShouldBeNil)
+			So(attempt.Downloader, ShouldEqual, "audiobooks")
+			So(len(recorded), ShouldEqual, 1)
+			So(ebooks.downloads, ShouldBeEmpty)
+
+			attempt, err = Run(context.Background(), request, []Downloader{audiobooks}, record)
+			So(attempt, ShouldBeNil)
+			So(err.Error(), ShouldContainSubstring, "can download")
+		})
+
+		Convey("An empty downloader list is an error", func() {
+			_, err := Run(context.Background(), request, nil, record)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type stubClient struct {
+	name     string
+	protocol Protocol
+	added    []string
+}
+
+func (c *stubClient) Name() string                    { return c.name }
+func (c *stubClient) Supports(protocol Protocol) bool { return protocol == c.protocol }
+
+func (c *stubClient) Add(ctx context.Context, release Release) (string, error) {
+	c.added = append(c.added, release.ID)
+	return "id-" + release.ID, nil
+}
+
+type stubTracker struct {
+	stubClient
+}
+
+func (t *stubTracker) Status(ctx context.Context, id string) (*Status, error) {
+	return &Status{State: StateCompleted}, nil
+}
+
+func TestHand(t *testing.T) {
+	Convey("Subject: Handing releases to download clients", t, func() {
+		torrents := &stubTracker{stubClient{name: "torrents", protocol: ProtocolTorrent}}
+		usenet := &stubClient{name: "usenet", protocol: ProtocolUsenet}
+		clients := []Client{torrents, usenet}
+
+		Convey("Tracked clients return a handoff", func() {
+			handoff, err := Hand(context.Background(), clients, Release{ID: "t1", Protocol: ProtocolTorrent})
+			So(err, ShouldBeNil)
+			So(*handoff, ShouldResemble, Handoff{Client: "torrents", ID: "id-t1"})
+			So(torrents.added, ShouldResemble, []string{"t1"})
+		})
+
+		Convey("Untracked clients complete on handoff", func() {
+			handoff, err := Hand(context.Background(), clients, Release{ID: "u1", Protocol: ProtocolUsenet})
+			So(err, ShouldBeNil)
+			So(handoff, ShouldBeNil)
+			So(usenet.added, ShouldResemble, []string{"u1"})
+		})
+
+		Convey("Releases no client supports are rejected", func() {
+			_, err := Hand(context.Background(), []Client{usenet}, Release{ID: "t2", Protocol: ProtocolTorrent})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+type stubTrackingDownloader struct {
+	stubDownloader
+}
+
+func (d *stubTrackingDownloader) Status(ctx context.Context, id string) (*Status, error) {
+	return &Status{State: StateDownloading}, nil
+}
+
+func TestNewTracker(t *testing.T) {
+	Convey("Subject: Finding the tracker for a handoff", t, func() {
+		RegisterClient("tracked", func() (Client, error) {
+			return &stubTracker{stubClient{name: "tracked"}}, nil
+		})
+		RegisterClient("untracked", func() (Client, error) {
+			return &stubClient{name: "untracked"}, nil
+		})
+		Register("tracking", func() (Downloader, error) {
+			return &stubTrackingDownloader{stubDownloader{name: "tracking"}}, nil
+		})
+
+		tracker, err := NewTracker("tracked")
+		So(err, ShouldBeNil)
+		So(tracker.Name(), ShouldEqual, "tracked")
+
+		Convey("Downloaders that track their own downloads are found by name", func() {
+			tracker, err := NewTracker("tracking")
+			So(err, ShouldBeNil)
+			So(tracker.Name(), ShouldEqual, "tracking")
+		})
+
+		Convey("Clients and downloaders that can't track are rejected", func() {
+			_, err := NewTracker("untracked")
+			So(err, ShouldNotBeNil)
+
+			_, err = NewTracker("stub")
+			So(err, ShouldNotBeNil)
+
+			_, err = NewTracker("missing")
+			So(errors.Is(err, ErrUnknownDownloader), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,9 @@
+package download
+
+import "github.com/beego/beego/v2/core/config"
+
+// EbookSizeLimits returns the configured minimum and maximum ebook size in bytes.
+func EbookSizeLimits() (int64, int64) {
+	return int64(config.DefaultFloat("download::ebookminbytes", 104858)),
+		int64(config.DefaultFloat("download::ebookmaxbytes", 25<<20))
+}
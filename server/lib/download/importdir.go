@@ -0,0 +1,199 @@
+package download
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/core/logs"
+	"github.com/fsnotify/fsnotify"
+)
+
+// maxImportDepth limits how deep the import directory is searched, which is
+// enough for a release folder with a subfolder or two.
+const maxImportDepth = 3
+
+// importIndex is the set of book files in an import directory, kept up to date
+// with fsnotify so finding a release doesn't walk the directory on every poll.
+type importIndex struct {
+	dir     string
+	watcher *fsnotify.Watcher
+
+	mu    sync.Mutex
+	books map[string]bool
+}
+
+var (
+	importIndexesMu sync.Mutex
+	importIndexes   = make(map[string]*importIndex)
+)
+
+// watchImportDir returns the index of the import directory, starting to watch
+// it the first time it's asked for. Indexes live as long as the process.
+func watchImportDir(dir string) (*importIndex, error) {
+	dir = filepath.Clean(dir)
+
+	importIndexesMu.Lock()
+	defer importIndexesMu.Unlock()
+
+	if index, ok := importIndexes[dir]; ok {
+		return index, nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	index := &importIndex{dir: dir, watcher: watcher, books: make(map[string]bool)}
+	// Watch before walking so books added in between aren't missed
+	if err := index.add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	go index.run()
+
+	importIndexes[dir] = index
+	return index, nil
+}
+
+// find returns the first book file named after the release, or in a folder
+// named after it, or "" if there's none.
+func (x *importIndex) find(name string) string {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	for path := range x.books {
+		if rel, err := filepath.Rel(x.dir, path); err == nil && importedAs(rel, name) {
+			return path
+		}
+	}
+	return ""
+}
+
+// add watches the directory and its subdirectories down to maxImportDepth and
+// indexes the book files in them.
+func (x *importIndex) add(root string) error {
+	return walkImportDir(context.Background(), x.dir, root, func(path string, entry fs.DirEntry) error {
+		if entry.IsDir() {
+			if err := x.watcher.Add(path); err != nil && path == root {
+				return err
+			}
+			return nil
+		}
+
+		x.mu.Lock()
+		x.books[path] = true
+		x.mu.Unlock()
+		return nil
+	})
+}
+
+// remove drops the path, and everything under it when it was a directory.
+func (x *importIndex) remove(path string) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	prefix := path + string(filepath.Separator)
+	for book := range x.books {
+		if book == path || strings.HasPrefix(book, prefix) {
+			delete(x.books, book)
+		}
+	}
+}
+
+func (x *importIndex) run() {
+	for {
+		select {
+		case event, ok := <-x.watcher.Events:
+			if !ok {
+				return
+			}
+
+			switch {
+			case event.Has(fsnotify.Create):
+				// Folders are often moved in whole, so index what's already in them
+				if err := x.add(event.Name); err != nil && !os.IsNotExist(err) {
+					logs.Warn("Unable to watch %s: %v", event.Name, err)
+				}
+			case event.Has(fsnotify.Remove), event.Has(fsnotify.Rename):
+				x.remove(event.Name)
+			}
+		case err, ok := <-x.watcher.Errors:
+			if !ok {
+				return
+			}
+			// Events may have been lost, so start over from what's on disk
+			logs.Warn("Error watching import directory %s, indexing it again: %v", x.dir, err)
+			if err := x.add(x.dir); err != nil {
+				logs.Warn("Unable to index import directory %s: %v", x.dir, err)
+			}
+		}
+	}
+}
+
+// findImport searches the import directory for a book file named after the
+// release, or in a folder named after it, and returns its path or "".
+func findImport(ctx context.Context, importDir, name string) (string, error) {
+	found := ""
+	err := walkImportDir(ctx, importDir, importDir, func(path string, entry fs.DirEntry) error {
+		if entry.IsDir() {
+			return nil
+		}
+		if rel, _ := filepath.Rel(importDir, path); importedAs(rel, name) {
+			found = path
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return found, err
+}
+
+// walkImportDir calls fn for root, for the directories under it down to
+// maxImportDepth below importDir and for the book files in them. Unreadable
+// entries other than root are skipped.
+func walkImportDir(ctx context.Context, importDir, root string, fn func(path string, entry fs.DirEntry) error) error {
+	return filepath.WalkDir(root, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if path == root {
+				return err
+			}
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		rel, err := filepath.Rel(importDir, path)
+		if err != nil {
+			return err
+		}
+		parts := strings.Split(rel, string(filepath.Separator))
+		if entry.IsDir() {
+			if rel != "." && len(parts) >= maxImportDepth {
+				return filepath.SkipDir
+			}
+			return fn(path, entry)
+		}
+		if len(parts) > maxImportDepth || !bookExtensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		return fn(path, entry)
+	})
+}
+
+// importedAs reports whether the book file at rel, relative to the import
+// directory, is named after the release or is in a folder named after it.
+func importedAs(rel, name string) bool {
+	parts := strings.Split(rel, string(filepath.Separator))
+	parts[len(parts)-1] = strings.TrimSuffix(parts[len(parts)-1], filepath.Ext(rel))
+	for _, part := range parts {
+		if strings.Contains(normalizeName(part), name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,504 @@
+package download
+
+import (
+	"api/models"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Maximum points for each part of a release's score. They add up to 100.
+const (
+	titlePoints    = 40
+	authorPoints   = 20
+	yearPoints     = 5
+	languagePoints = 10
+	formatPoints   = 10
+	sizePoints     = 10
+	seederPoints   = 5
+)
+
+// minTitleSimilarity rejects releases whose title is too far from the request's,
+// whatever else they score.
+const minTitleSimilarity = 0.6
+
+// DefaultMinScore is the lowest score a release can be downloaded with.
+const DefaultMinScore = 50
+
+var (
+	defaultFormats          = []string{"epub", "azw3", "mobi", "pdf"}
+	defaultAudiobookFormats = []string{"m4b", "mp3"}
+)
+
+// Scorer rates how well releases match a request and ranks them.
+type Scorer struct {
+	// MinScore is the lowest total a release can be downloaded with.
+	MinScore float64
+	// MinBytes and MaxBytes bound the size of ebook releases. Audiobooks aren't limited.
+	MinBytes int64
+	MaxBytes int64
+	// Formats are the preferred file formats, best first. Requests can
+	// override them, and Languages, with their own.
+	Formats []string
+	// AudiobookFormats are the preferred formats of audiobook releases.
+	AudiobookFormats []string
+	// Languages are the accepted release languages, e.g. ISO 639-1 codes.
+	// Releases in any other language are rejected. Empty accepts any language.
+	Languages    []string
+	BlockedTerms []string
+}
+
+// NewScorer returns a scorer with the default preferences and no size limits,
+// language restrictions or blocked terms.
+func NewScorer() *Scorer {
+	return &Scorer{
+		MinScore:         DefaultMinScore,
+		Formats:          defaultFormats,
+		AudiobookFormats: defaultAudiobookFormats,
+	}
+}
+
+// ConfiguredScorer returns a scorer using download::minscore, the ebook size
+// limits, download::preferredformats, download::audiobookformats,
+// download::languages and download::blockedterms.
+func ConfiguredScorer() *Scorer {
+	scorer := NewScorer()
+	scorer.MinScore = config.DefaultFloat("download::minscore", DefaultMinScore)
+	scorer.MinBytes, scorer.MaxBytes = EbookSizeLimits()
+	scorer.Formats = splitList(config.DefaultString("download::preferredformats", strings.Join(defaultFormats, ",")))
+	scorer.AudiobookFormats = splitList(config.DefaultString("download::audiobookformats", strings.Join(defaultAudiobookFormats, ",")))
+	scorer.Languages = splitList(config.DefaultString("download::languages", ""))
+	scorer.BlockedTerms = splitList(config.DefaultString("download::blockedterms", ""))
+	return scorer
+}
+
+// ScoreBreakdown is a release's score split into its parts. Rejected releases
+// can't be downloaded whatever they score.
+type ScoreBreakdown struct {
+	Title    float64
+	Author   float64
+	Year     float64
+	Language float64
+	Format   float64
+	Size     float64
+	Seeders  float64
+	Rejected string
+}
+
+// Total returns the release's score out of 100, or 0 when it's rejected.
+func (b ScoreBreakdown) Total() float64 {
+	if b.Rejected != "" {
+		return 0
+	}
+	return b.Title + b.Author + b.Year + b.Language + b.Format + b.Size + b.Seeders
+}
+
+func (b ScoreBreakdown) String() string {
+	if b.Rejected != "" {
+		return "rejected: " + b.Rejected
+	}
+	return fmt.Sprintf("%.1f (title %.1f, author %.1f, year %.1f, language %.1f, format %.1f, size %.1f, seeders %.1f)",
+		b.Total(), b.Title, b.Author, b.Year, b.Language, b.Format, b.Size, b.Seeders)
+}
+
+// Rank scores every release, logging each breakdown, and returns those that
+// reach MinScore, highest scoring first. Releases scoring the same keep their order.
+func (s *Scorer) Rank(downloader string, request models.BookRequest, releases []Release) ([]Release, error) {
+	type scored struct {
+		release Release
+		total   float64
+	}
+
+	var candidates []scored
+	for _, release := range releases {
+		breakdown := s.Score(request, release)
+		logs.Info("%s release for request #%d scored %s: %s", downloader, request.ID, breakdown, release.Title)
+
+		if breakdown.Rejected != "" || breakdown.Total() < s.MinScore {
+			continue
+		}
+		candidates = append(candidates, scored{release, breakdown.Total()})
+	}
+
+	if len(candidates) == 0 {
+		return nil, ErrNoSuitableRelease
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].total > candidates[j].total })
+
+	ranked := make([]Release, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = candidate.release
+	}
+	logs.Info("Best %s release scored %.1f: %s", downloader, candidates[0].total, ranked[0].Title)
+	return ranked, nil
+}
+
+// Score rates how well the release matches the request.
+func (s *Scorer) Score(request models.BookRequest, release Release) ScoreBreakdown {
+	var breakdown ScoreBreakdown
+
+	if term := s.blockedTerm(release.Title); term != "" {
+		breakdown.Rejected = fmt.Sprintf("title contains blocked term %q", term)
+		return breakdown
+	}
+
+	audiobook := release.IsAudiobook()
+	switch {
+	case audiobook && !request.MediaType.WantsAudiobook():
+		breakdown.Rejected = "release is an audiobook"
+		return breakdown
+	case !audiobook && !request.MediaType.WantsEbook():
+		breakdown.Rejected = "release isn't an audiobook"
+		return breakdown
+	}
+
+	authorWords := wordList(request.Author)
+	similarity := titleSimilarity(request.Title, release.Title, authorWords)
+	if similarity < minTitleSimilarity {
+		breakdown.Rejected = fmt.Sprintf("title doesn't match (%.0f%% similar)", similarity*100)
+		return breakdown
+	}
+	breakdown.Title = titlePoints * similarity
+
+	breakdown.Author = authorPoints * authorSimilarity(authorWords, release)
+	breakdown.Year = yearPoints * yearSimilarity(request.PublishYear, release.Year)
+	formats, languages := s.Formats, s.Languages
+	if audiobook {
+		formats = s.AudiobookFormats
+	}
+	if request.PreferredFormats != nil && *request.PreferredFormats != "" {
+		formats = splitList(*request.PreferredFormats)
+	}
+	if request.Languages != nil && *request.Languages != "" {
+		languages = splitList(*request.Languages)
+	}
+
+	language, accepted := languageMatch(languages, release.Language)
+	if !accepted {
+		breakdown.Rejected = fmt.Sprintf("language %s isn't accepted", release.Language)
+		return breakdown
+	}
+	breakdown.Language = languagePoints * language
+	breakdown.Format = formatPoints * formatPreference(formats, releaseFormat(release))
+
+	switch bytes := release.SizeBytes(); {
+	case audiobook:
+		breakdown.Size = sizePoints
+	case bytes <= 0:
+		breakdown.Size = sizePoints / 2.0
+	case (s.MinBytes > 0 && bytes < s.MinBytes) || (s.MaxBytes > 0 && bytes > s.MaxBytes):
+		breakdown.Rejected = fmt.Sprintf("size %s is outside the ebook limits", FormatSize(bytes))
+		return breakdown
+	default:
+		breakdown.Size = sizePoints
+	}
+
+	switch {
+	case release.Protocol != ProtocolTorrent:
+		// Only torrents depend on seeders
+		breakdown.Seeders = seederPoints
+	case release.Seeders == 0:
+		breakdown.Rejected = "torrent has no seeders"
+		return breakdown
+	case release.Seeders > 0:
+		breakdown.Seeders = math.Min(seederPoints, math.Log2(float64(1+release.Seeders)))
+	default:
+		breakdown.Seeders = seederPoints / 2.0
+	}
+
+	return breakdown
+}
+
+// IsAudiobook reports whether the release is in a Newznab audio category or
+// in an audio format.
+func (r Release) IsAudiobook() bool {
+	for _, category := range r.Categories {
+		if category >= 3000 && category < 4000 {
+			return true
+		}
+	}
+	return audioFormats[releaseFormat(r)]
+}
+
+func (s *Scorer) blockedTerm(title string) string {
+	title = strings.ToLower(title)
+	for _, term := range s.BlockedTerms {
+		if term != "" && strings.Contains(title, strings.ToLower(term)) {
+			return term
+		}
+	}
+	return ""
+}
+
+// languageMatch is 1 for an accepted language and 0.5 when the release
+// doesn't say or any language is accepted. Other languages aren't accepted.
+func languageMatch(languages []string, language string) (float64, bool) {
+	code := languageCode(language)
+	if code == "" || len(languages) == 0 {
+		return 0.5, true
+	}
+	for _, accepted := range languages {
+		if languageCode(accepted) == code {
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+// formatPreference scales from 1 for the first preferred format down the
+// list, is 0 for formats that aren't listed and 0.5 when the format is unknown.
+func formatPreference(formats []string, format string) float64 {
+	if format == "" || len(formats) == 0 {
+		return 0.5
+	}
+	for i, preferred := range formats {
+		if strings.EqualFold(strings.TrimPrefix(preferred, "."), format) {
+			return 1 - float64(i)/float64(len(formats))
+		}
+	}
+	return 0
+}
+
+// knownFormats are the file formats recognized in release titles.
+var knownFormats = map[string]bool{
+	"epub": true, "azw": true, "azw3": true, "mobi": true, "pdf": true, "fb2": true,
+	"m4b": true, "m4a": true, "mp3": true, "flac": true,
+}
+
+// audioFormats are the known formats of audiobooks.
+var audioFormats = map[string]bool{"m4b": true, "m4a": true, "mp3": true, "flac": true}
+
+// releaseFormat returns the release's format, from the release or its title.
+func releaseFormat(release Release) string {
+	if release.Format != "" {
+		return strings.ToLower(strings.TrimPrefix(release.Format, "."))
+	}
+	for _, word := range wordList(release.Title) {
+		if knownFormats[word] {
+			return word
+		}
+	}
+	return ""
+}
+
+// titleStopWords are ignored when matching titles so they can't make up a match alone.
+var titleStopWords = map[string]bool{"a": true, "an": true, "and": true, "of": true, "or": true, "the": true}
+
+// noiseWords are release title words that describe the file rather than the book.
+var noiseWords = map[string]bool{
+	"ebook": true, "retail": true, "audiobook": true, "unabridged": true, "abridged": true,
+	"kindle": true, "edition": true, "novel": true, "book": true, "by": true,
+}
+
+// titleSimilarity compares the release title with the request's full and main
+// title, ignoring stop words, the author's name and format or year noise. The
+// share of the requested words found counts most; extra words count a little
+// against the release.
+func titleSimilarity(requested, releaseTitle string, authorWords []string) float64 {
+	requestedWords := wordList(requested)
+
+	var releaseWords []string
+	for _, word := range wordList(releaseTitle) {
+		if titleStopWords[word] {
+			continue
+		}
+		if !containsWord(requestedWords, word) &&
+			(noiseWords[word] || knownFormats[word] || isYear(word) || containsWord(authorWords, word)) {
+			continue
+		}
+		releaseWords = append(releaseWords, word)
+	}
+	if len(releaseWords) == 0 {
+		return 0
+	}
+
+	best := 0.0
+	for _, variant := range titleVariants(requested) {
+		titleWords := significantWords(variant)
+		if len(titleWords) == 0 {
+			continue
+		}
+
+		matched := 0
+		for _, word := range titleWords {
+			if fuzzyContains(releaseWords, word) {
+				matched++
+			}
+		}
+		recall := float64(matched) / float64(len(titleWords))
+		precision := math.Min(1, float64(matched)/float64(len(releaseWords)))
+
+		best = math.Max(best, recall*(0.75+0.25*precision))
+	}
+	return best
+}
+
+// titleVariants returns the title and, when it has one, the title without its
+// subtitle or alternative title, e.g. "The Hobbit, or There and Back Again".
+func titleVariants(title string) []string {
+	variants := []string{title}
+	lower := strings.ToLower(title)
+	for _, sep := range []string{":", " - ", "(", ", or ", "; or "} {
+		if i := strings.Index(lower, sep); i > 0 {
+			variants = append(variants, title[:i])
+		}
+	}
+	return variants
+}
+
+func significantWords(s string) []string {
+	words := wordList(s)
+	kept := words[:0]
+	for _, word := range words {
+		if !titleStopWords[word] {
+			kept = append(kept, word)
+		}
+	}
+	if len(kept) == 0 {
+		return words
+	}
+	return kept
+}
+
+// authorSimilarity is the share of the author's surname and other names found
+// in the release's author or title. The surname alone counts for most of it.
+func authorSimilarity(authorWords []string, release Release) float64 {
+	var names []string
+	for _, word := range authorWords {
+		if len(word) > 1 {
+			names = append(names, word)
+		}
+	}
+	if len(names) == 0 {
+		return 0
+	}
+
+	releaseWords := wordList(release.Author + " " + release.Title)
+	surname := names[len(names)-1]
+	if !fuzzyContains(releaseWords, surname) {
+		return 0
+	}
+
+	matched := 0
+	for _, name := range names {
+		if fuzzyContains(releaseWords, name) {
+			matched++
+		}
+	}
+	return 0.75 + 0.25*float64(matched)/float64(len(names))
+}
+
+// yearSimilarity is 1 for the same year, less for nearby years, 0 for distant
+// ones and 0.5 when either year is unknown.
+func yearSimilarity(requested *int, releaseYear string) float64 {
+	year, err := strconv.Atoi(strings.TrimSpace(releaseYear))
+	if requested == nil || *requested == 0 || err != nil || year == 0 {
+		return 0.5
+	}
+
+	switch diff := *requested - year; {
+	case diff == 0:
+		return 1
+	case diff >= -1 && diff <= 1:
+		return 0.8
+	default:
+		// Often a later edition or reprint
+		return 0
+	}
+}
+
+// languages maps common language names and ISO 639-2 codes to ISO 639-1.
+var languages = map[string]string{
+	"english": "en", "eng": "en",
+	"german": "de", "deutsch": "de", "deu": "de", "ger": "de",
+	"french": "fr", "francais": "fr", "français": "fr", "fra": "fr", "fre": "fr",
+	"spanish": "es", "español": "es", "espanol": "es", "spa": "es",
+	"italian": "it", "italiano": "it", "ita": "it",
+	"dutch": "nl", "nederlands": "nl", "nld": "nl", "dut": "nl",
+	"portuguese": "pt", "português": "pt", "por": "pt",
+	"russian": "ru", "rus": "ru",
+	"polish": "pl", "polski": "pl", "pol": "pl",
+	"swedish": "sv", "svenska": "sv", "swe": "sv",
+	"japanese": "ja", "jpn": "ja",
+	"chinese": "zh", "zho": "zh", "chi": "zh",
+}
+
+// languageCode returns the ISO 639-1 code for a language name or code.
+func languageCode(language string) string {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if code, ok := languages[language]; ok {
+		return code
+	}
+	return language
+}
+
+// wordList returns the lowercase words in s in order.
+func wordList(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func containsWord(words []string, word string) bool {
+	for _, w := range words {
+		if w == word {
+			return true
+		}
+	}
+	return false
+}
+
+// fuzzyContains reports whether words contains word or, for longer words, one
+// a single typo away.
+func fuzzyContains(words []string, word string) bool {
+	for _, w := range words {
+		if w == word || (len(word) >= 5 && editDistance(w, word) <= 1) {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+func isYear(word string) bool {
+	year, err := strconv.Atoi(word)
+	return err == nil && len(word) == 4 && year >= 1000 && year <= 2999
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,181 @@
+package download
+
+import (
+	"api/models"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScore(t *testing.T) {
+	request := models.BookRequest{Title: "The Name of the Wind", Author: "Patrick Rothfuss"}
+	release := Release{
+		Title:    "Patrick Rothfuss - The Name of the Wind (epub)",
+		Protocol: ProtocolTorrent,
+		Bytes:    2 << 20,
+		Seeders:  10,
+	}
+
+	Convey("Subject: Release scoring", t, func() {
+		scorer := NewScorer()
+		scorer.MinBytes, scorer.MaxBytes = 100<<10, 25<<20
+		scorer.BlockedTerms = []string{"bundle", "box set"}
+
+		good := scorer.Score(request, release).Total()
+		So(good, ShouldBeGreaterThan, 90)
+
+		Convey("Releases missing the author score lower", func() {
+			noAuthor := release
+			noAuthor.Title = "The Name of the Wind (epub)"
+			So(scorer.Score(request, noAuthor).Total(), ShouldBeLessThan, good)
+		})
+
+		Convey("More seeders score higher", func() {
+			few := release
+			few.Seeders = 1
+			So(scorer.Score(request, few).Total(), ShouldBeLessThan, good)
+		})
+
+		Convey("Unusable releases are rejected", func() {
+			wrongTitle := release
+			wrongTitle.Title = "Patrick Rothfuss - The Slow Regard of Silent Things"
+			So(scorer.Score(request, wrongTitle).Rejected, ShouldStartWith, "title doesn't match")
+
+			tooBig := release
+			tooBig.Bytes = 1 << 30
+			So(scorer.Score(request, tooBig).Rejected, ShouldStartWith, "size 1.0GB")
+
+			noSeeders := release
+			noSeeders.Seeders = 0
+			So(scorer.Score(request, noSeeders).Rejected, ShouldEqual, "torrent has no seeders")
+
+			blocked := release
+			blocked.Title = "Patrick Rothfuss - The Name of the Wind Box Set"
+			So(scorer.Score(request, blocked).Total(), ShouldEqual, 0)
+		})
+
+		Convey("Audiobooks aren't held to the ebook size limits", func() {
+			audiobookRequest := request
+			audiobookRequest.MediaType = models.MTAudiobook
+			audiobook := release
+			audiobook.Title = "Patrick Rothfuss - The Name of the Wind (m4b)"
+			audiobook.Bytes = 1 << 30
+			audiobook.Categories = []int{3030}
+			So(scorer.Score(audiobookRequest, audiobook).Total(), ShouldBeGreaterThan, 90)
+		})
+
+		Convey("Releases of the wrong media type are rejected", func() {
+			audiobook := release
+			audiobook.Title = "Patrick Rothfuss - The Name of the Wind (mp3)"
+			So(audiobook.IsAudiobook(), ShouldBeTrue)
+			So(scorer.Score(request, audiobook).Rejected, ShouldEqual, "release is an audiobook")
+
+			audiobookRequest := request
+			audiobookRequest.MediaType = models.MTAudiobook
+			So(scorer.Score(audiobookRequest, release).Rejected, ShouldEqual, "release isn't an audiobook")
+
+			both := request
+			both.MediaType = models.MTBoth
+			So(scorer.Score(both, release).Rejected, ShouldBeEmpty)
+			So(scorer.Score(both, audiobook).Rejected, ShouldBeEmpty)
+		})
+
+		Convey("Usenet releases don't need seeders", func() {
+			usenet := release
+			usenet.Protocol = ProtocolUsenet
+			usenet.Seeders = -1
+			So(scorer.Score(request, usenet).Total(), ShouldBeGreaterThan, 80)
+		})
+
+		Convey("Alternative titles and subtitles still match", func() {
+			hobbit := models.BookRequest{Title: "Hobbit", Author: "J.R.R. Tolkien"}
+			breakdown := scorer.Score(hobbit, Release{Title: "The Hobbit, or There and Back Again", Author: "J. R. R. Tolkien"})
+			So(breakdown.Rejected, ShouldBeEmpty)
+			So(breakdown.Author, ShouldEqual, authorPoints)
+
+			hobbit.Title = "The Hobbit, or There and Back Again"
+			breakdown = scorer.Score(hobbit, Release{Title: "The Hobbit"})
+			So(breakdown.Title, ShouldEqual, titlePoints)
+		})
+
+		Convey("Titles tolerate a typo but not a different book", func() {
+			So(titleSimilarity("The Final Empire", "The Finel Empire", nil), ShouldEqual, 1)
+			So(titleSimilarity("Dune", "Dune Messiah", nil), ShouldBeLessThan, 1)
+			So(titleSimilarity("Dune Messiah", "Dune", nil), ShouldBeLessThan, minTitleSimilarity)
+		})
+
+		Convey("Titles that include the author's name still match", func() {
+			autobiography := models.BookRequest{Title: "Agatha Christie: An Autobiography", Author: "Agatha Christie"}
+			breakdown := scorer.Score(autobiography, Release{Title: "Agatha Christie - An Autobiography"})
+			So(breakdown.Rejected, ShouldBeEmpty)
+		})
+
+		Convey("The author's surname counts for most of the author score", func() {
+			So(authorSimilarity(wordList("Frank Herbert"), Release{Author: "Herbert, Frank"}), ShouldEqual, 1)
+			So(authorSimilarity(wordList("Frank Herbert"), Release{Title: "Herbert - Dune"}), ShouldEqual, 0.875)
+			So(authorSimilarity(wordList("Frank Herbert"), Release{Author: "Brian Frank"}), ShouldEqual, 0)
+		})
+
+		Convey("Year, language and format preferences add to the score", func() {
+			year := 1965
+			dune := models.BookRequest{Title: "Dune", Author: "Frank Herbert", PublishYear: &year}
+			scorer.Languages = []string{"en"}
+
+			epub := scorer.Score(dune, Release{Title: "Dune", Format: "epub", Language: "English", Year: "1965"})
+			So(epub.Year, ShouldEqual, yearPoints)
+			So(epub.Language, ShouldEqual, languagePoints)
+			So(epub.Format, ShouldEqual, formatPoints)
+
+			pdf := scorer.Score(dune, Release{Title: "Dune", Format: "pdf", Year: "2005"})
+			So(pdf.Year, ShouldEqual, 0)
+			So(pdf.Format, ShouldBeLessThan, epub.Format)
+
+			unknown := scorer.Score(dune, Release{Title: "Dune"})
+			So(unknown.Year, ShouldEqual, yearPoints/2.0)
+			So(unknown.Language, ShouldEqual, languagePoints/2.0)
+			So(unknown.Format, ShouldEqual, formatPoints/2.0)
+		})
+
+		Convey("Releases in other languages are rejected once languages are set", func() {
+			dune := models.BookRequest{Title: "Dune", Author: "Frank Herbert"}
+			german := Release{Title: "Dune", Language: "ger"}
+			So(scorer.Score(dune, german).Rejected, ShouldBeEmpty)
+
+			scorer.Languages = []string{"en"}
+			So(scorer.Score(dune, german).Rejected, ShouldEqual, "language ger isn't accepted")
+
+			scorer.Languages = []string{"en", "de"}
+			So(scorer.Score(dune, german).Rejected, ShouldBeEmpty)
+		})
+
+		Convey("Requests can override the preferred formats and languages", func() {
+			formats, languages := "pdf,epub", "de"
+			dune := models.BookRequest{Title: "Dune", Author: "Frank Herbert", PreferredFormats: &formats, Languages: &languages}
+
+			pdf := scorer.Score(dune, Release{Title: "Dune", Format: "pdf", Language: "German"})
+			epub := scorer.Score(dune, Release{Title: "Dune", Format: "epub", Language: "German"})
+			So(pdf.Rejected, ShouldBeEmpty)
+			So(pdf.Format, ShouldEqual, formatPoints)
+			So(epub.Format, ShouldBeLessThan, pdf.Format)
+
+			So(scorer.Score(dune, Release{Title: "Dune", Language: "en"}).Rejected, ShouldNotBeEmpty)
+		})
+
+		Convey("Rank orders the releases above the minimum score, best first", func() {
+			releases := []Release{
+				{ID: "few", Title: "The Name of the Wind", Protocol: ProtocolTorrent, Bytes: 2 << 20, Seeders: 1},
+				{ID: "best", Title: release.Title, Protocol: ProtocolTorrent, Bytes: 2 << 20, Seeders: 10},
+				{ID: "dead", Title: release.Title, Protocol: ProtocolTorrent, Bytes: 2 << 20, Seeders: 0},
+			}
+			ranked, err := scorer.Rank("test", request, releases)
+			So(err, ShouldBeNil)
+			So(ranked, ShouldHaveLength, 2)
+			So(ranked[0].ID, ShouldEqual, "best")
+			So(ranked[1].ID, ShouldEqual, "few")
+
+			scorer.MinScore = 95
+			_, err = scorer.Rank("test", request, releases)
+			So(err, ShouldEqual, ErrNoSuitableRelease)
+		})
+	})
+}
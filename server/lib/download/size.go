@@ -0,0 +1,128 @@
+package download
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ErrInvalidSize is returned for size strings that can't be parsed.
+var ErrInvalidSize = errors.New("invalid size")
+
+// sizeUnits maps lowercase unit spellings to their size in bytes. Indexers and
+// download sites almost always mean 1024 by KB, so the decimal and binary
+// spellings are treated alike. French octet units are included.
+var sizeUnits = map[string]int64{
+	"": 1, "b": 1, "byte": 1, "bytes": 1, "o": 1,
+	"k": 1 << 10, "kb": 1 << 10, "kib": 1 << 10, "ko": 1 << 10,
+	"m": 1 << 20, "mb": 1 << 20, "mib": 1 << 20, "mo": 1 << 20,
+	"g": 1 << 30, "gb": 1 << 30, "gib": 1 << 30, "go": 1 << 30,
+	"t": 1 << 40, "tb": 1 << 40, "tib": 1 << 40, "to": 1 << 40,
+}
+
+// ParseSize parses a human readable size such as "12.3MB", "840 KiB",
+// "1,5 GB" or "1.234,5 kB" into bytes. A bare number is a count of bytes.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToLower(strings.TrimSpace(size))
+	// Strip spacing inside the value, including the non-breaking and thin spaces some sites use
+	s = strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != ','
+	})
+	if split < 0 {
+		split = len(s)
+	}
+	number, unit := s[:split], s[split:]
+
+	multiplier, ok := sizeUnits[unit]
+	if !ok || number == "" {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, size)
+	}
+
+	value, err := strconv.ParseFloat(normalizeDecimal(number), 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidSize, size)
+	}
+
+	bytes := value * float64(multiplier)
+	if bytes > math.MaxInt64 {
+		return 0, fmt.Errorf("%w: %q is too large", ErrInvalidSize, size)
+	}
+	return int64(math.Round(bytes)), nil
+}
+
+// normalizeDecimal rewrites a number using either '.' or ',' as the decimal
+// separator, and either as a thousands separator, into Go's format. When both
+// appear the last one is the decimal separator. A repeated separator, or a lone
+// comma followed by exactly three digits, separates thousands.
+func normalizeDecimal(number string) string {
+	dot, comma := strings.LastIndex(number, "."), strings.LastIndex(number, ",")
+
+	var decimal, thousands string
+	switch {
+	case dot >= 0 && comma >= 0:
+		decimal, thousands = ".", ","
+		if comma > dot {
+			decimal, thousands = ",", "."
+		}
+	case dot >= 0:
+		decimal, thousands = ".", ","
+		if strings.Count(number, ".") > 1 {
+			decimal, thousands = "", "."
+		}
+	case comma >= 0:
+		decimal, thousands = ",", "."
+		if strings.Count(number, ",") > 1 || len(number)-comma-1 == 3 {
+			decimal, thousands = "", ","
+		}
+	default:
+		return number
+	}
+
+	number = strings.ReplaceAll(number, thousands, "")
+	if decimal != "" {
+		number = strings.Replace(number, decimal, ".", 1)
+	}
+	return number
+}
+
+// FormatSize formats bytes as a human readable size like "1.2MB".
+func FormatSize(bytes int64) string {
+	if bytes <= 0 {
+		return ""
+	}
+
+	value, unit := float64(bytes), "B"
+	for _, next := range []string{"KB", "MB", "GB", "TB"} {
+		if value < 1024 {
+			break
+		}
+		value, unit = value/1024, next
+	}
+
+	if unit == "B" {
+		return fmt.Sprintf("%d%s", bytes, unit)
+	}
+	return fmt.Sprintf("%.1f%s", value, unit)
+}
+
+// SizeBytes returns the release's size in bytes, parsing its display size when
+// the backend didn't report a byte count. It's 0 when the size is unknown.
+func (r Release) SizeBytes() int64 {
+	if r.Bytes > 0 {
+		return r.Bytes
+	}
+	if bytes, err := ParseSize(r.Size); err == nil {
+		return bytes
+	}
+	return 0
+}
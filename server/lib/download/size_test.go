@@ -0,0 +1,90 @@
+package download
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseSize(t *testing.T) {
+	Convey("Subject: Parsing release sizes", t, func() {
+		tests := []struct {
+			size  string
+			bytes int64
+		}{
+			// Anna's Archive and CWA
+			{"12.3MB", 12897485},
+			{"0.4MB", 419430},
+			{"840KB", 860160},
+			{"1.2GB", 1288490189},
+			// Spacing and case variants
+			{"12.3 MB", 12897485},
+			{" 12.3  mb ", 12897485},
+			{"12.3 Mb", 12897485},
+			{"12.3\u00a0MB", 12897485},
+			// Binary units from Jackett, Prowlarr and qBittorrent
+			{"840 KiB", 860160},
+			{"1.5 MiB", 1572864},
+			{"2 GiB", 2147483648},
+			// Decimal commas and thousands separators
+			{"1,5 MB", 1572864},
+			{"1,234 KB", 1263616},
+			{"1.234,5 KB", 1264128},
+			{"1,234.5 KB", 1264128},
+			{"1.234.567 B", 1234567},
+			// Bytes
+			{"1048576", 1048576},
+			{"512 B", 512},
+			{"512 bytes", 512},
+			{"1,5 Mo", 1572864},
+			// Single letter units
+			{"3.4M", 3565158},
+			{"700k", 716800},
+		}
+
+		for _, test := range tests {
+			bytes, err := ParseSize(test.size)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldEqual, test.bytes)
+		}
+
+		Convey("Unparseable sizes are errors", func() {
+			for _, size := range []string{"", "MB", "unknown", "12.3 XB", "-5 MB", "1.2.3,4,5 MB", "12MB extra"} {
+				_, err := ParseSize(size)
+				So(errors.Is(err, ErrInvalidSize), ShouldBeTrue)
+			}
+		})
+	})
+}
+
+func TestFormatSize(t *testing.T) {
+	Convey("Subject: Formatting release sizes", t, func() {
+		tests := []struct {
+			bytes int64
+			size  string
+		}{
+			{0, ""},
+			{512, "512B"},
+			{860160, "840.0KB"},
+			{12897485, "12.3MB"},
+			{1288490189, "1.2GB"},
+		}
+
+		for _, test := range tests {
+			So(FormatSize(test.bytes), ShouldEqual, test.size)
+		}
+
+		Convey("Formatted sizes parse back to about the same size", func() {
+			bytes, err := ParseSize(FormatSize(12897485))
+			So(err, ShouldBeNil)
+			So(bytes, ShouldAlmostEqual, 12897485, 1<<17)
+		})
+	})
+
+	Convey("Subject: Release sizes", t, func() {
+		So(Release{Bytes: 100, Size: "1MB"}.SizeBytes(), ShouldEqual, 100)
+		So(Release{Size: "1MB"}.SizeBytes(), ShouldEqual, 1<<20)
+		So(Release{Size: "unknown"}.SizeBytes(), ShouldEqual, 0)
+	})
+}
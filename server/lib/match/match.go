@@ -0,0 +1,123 @@
+// Package match normalizes book identifiers, titles and author names so books
+// from different sources can be compared with each other.
+package match
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// leadingArticles are dropped from the start of titles before comparison.
+var leadingArticles = []string{"the ", "a ", "an "}
+
+// NormalizeISBN strips separators from an ISBN and returns it only if it has a
+// valid ISBN-10 or ISBN-13 length.
+func NormalizeISBN(isbn string) string {
+	var b strings.Builder
+	for _, r := range strings.ToUpper(isbn) {
+		if (r >= '0' && r <= '9') || r == 'X' {
+			b.WriteRune(r)
+		}
+	}
+
+	normalized := b.String()
+	if len(normalized) != 10 && len(normalized) != 13 {
+		return ""
+	}
+
+	return normalized
+}
+
+// ISBN13 returns the normalized ISBN-13 form of an ISBN-10 or ISBN-13, or an
+// empty string if the input isn't a valid length.
+func ISBN13(isbn string) string {
+	isbn = NormalizeISBN(isbn)
+	if len(isbn) != 10 {
+		return isbn
+	}
+
+	base := "978" + isbn[:9]
+	sum := 0
+	for i, r := range base {
+		digit := int(r - '0')
+		if i%2 == 1 {
+			digit *= 3
+		}
+		sum += digit
+	}
+	check := (10 - sum%10) % 10
+
+	return base + string(rune('0'+check))
+}
+
+// NormalizeTitle lowercases a title, drops any subtitle and leading article, and
+// collapses punctuation so "The Hobbit: or There and Back Again" becomes "hobbit".
+func NormalizeTitle(title string) string {
+	title = strings.ToLower(title)
+	if i := strings.IndexAny(title, ":("); i > 0 {
+		title = title[:i]
+	}
+
+	title = collapse(title)
+	for _, article := range leadingArticles {
+		if strings.HasPrefix(title, article) {
+			title = strings.TrimPrefix(title, article)
+			break
+		}
+	}
+
+	return title
+}
+
+// NormalizeAuthor lowercases an author name and sorts its words so
+// "Herbert, Frank" and "Frank Herbert" compare equal. Only the first author is
+// kept when the string lists several full names.
+func NormalizeAuthor(author string) string {
+	author = strings.ToLower(author)
+	for _, sep := range []string{"&", ";", " and "} {
+		if i := strings.Index(author, sep); i > 0 {
+			author = author[:i]
+		}
+	}
+
+	// "Frank Herbert, Brian Herbert" lists two authors while "Herbert, Frank"
+	// is a single inverted name.
+	if first, _, found := strings.Cut(author, ","); found && strings.Contains(strings.TrimSpace(first), " ") {
+		author = first
+	}
+
+	words := strings.Fields(collapse(author))
+	// Drop initials so "J. R. R. Tolkien" matches "J.R.R. Tolkien" and "Tolkien".
+	kept := words[:0]
+	for _, word := range words {
+		if len(word) > 1 {
+			kept = append(kept, word)
+		}
+	}
+	sort.Strings(kept)
+
+	return strings.Join(kept, " ")
+}
+
+// Key builds a comparison key from a title and author.
+func Key(title, author string) string {
+	return NormalizeTitle(title) + "|" + NormalizeAuthor(author)
+}
+
+// collapse replaces every run of non-alphanumeric characters with a single space.
+func collapse(s string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range s {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+			space = false
+		} else if !space {
+			b.WriteRune(' ')
+			space = true
+		}
+	}
+
+	return strings.TrimSpace(b.String())
+}
@@ -0,0 +1,52 @@
+package match
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestNormalizeISBN(t *testing.T) {
+	Convey("Subject: ISBN normalization\n", t, func() {
+		cases := map[string]string{
+			"978-0-441-01359-3": "9780441013593",
+			"0 441 01359 7":     "0441013597",
+			"080442957x":        "080442957X",
+			"12345":             "",
+			"":                  "",
+		}
+		for input, expected := range cases {
+			So(NormalizeISBN(input), ShouldEqual, expected)
+		}
+	})
+}
+
+func TestISBN13(t *testing.T) {
+	Convey("Subject: ISBN-10 to ISBN-13 conversion\n", t, func() {
+		So(ISBN13("0441013597"), ShouldEqual, "9780441013593")
+		So(ISBN13("0-306-40615-2"), ShouldEqual, "9780306406157")
+		So(ISBN13("9780441013593"), ShouldEqual, "9780441013593")
+		So(ISBN13("bogus"), ShouldEqual, "")
+	})
+}
+
+func TestKey(t *testing.T) {
+	Convey("Subject: Title and author keys\n", t, func() {
+		Convey("Subtitles, articles and punctuation should be ignored", func() {
+			So(NormalizeTitle("The Hobbit: or There and Back Again"), ShouldEqual, "hobbit")
+			So(NormalizeTitle("Hobbit"), ShouldEqual, "hobbit")
+			So(NormalizeTitle("Dune (Dune Chronicles #1)"), ShouldEqual, "dune")
+		})
+		Convey("Author name order and initials should be ignored", func() {
+			So(NormalizeAuthor("Herbert, Frank"), ShouldEqual, NormalizeAuthor("Frank Herbert"))
+			So(NormalizeAuthor("J. R. R. Tolkien"), ShouldEqual, NormalizeAuthor("J.R.R. Tolkien"))
+		})
+		Convey("Only the first of several authors should be kept", func() {
+			So(NormalizeAuthor("Frank Herbert, Brian Herbert"), ShouldEqual, "frank herbert")
+			So(NormalizeAuthor("Terry Pratchett & Neil Gaiman"), ShouldEqual, "pratchett terry")
+		})
+		Convey("Equivalent books should share a key", func() {
+			So(Key("The Hobbit", "Tolkien, J.R.R."), ShouldEqual, Key("Hobbit", "J. R. R. Tolkien"))
+		})
+	})
+}
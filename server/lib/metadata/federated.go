@@ -0,0 +1,236 @@
+package metadata
+
+import (
+	"api/lib/match"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+const defaultFederatedProviders = "OPENLIBRARY,GOOGLE,HARDCOVER"
+
+// FederatedProvider queries several providers concurrently and merges their
+// results so each book is returned once, listing every provider that found it.
+type FederatedProvider struct {
+	Providers []MetadataProvider
+	// Timeout bounds each individual provider search. Zero means no extra limit.
+	Timeout time.Duration
+}
+
+func init() {
+	Register("FEDERATED", func() (MetadataProvider, error) {
+		names := strings.Split(config.DefaultString("metadata::federatedproviders", defaultFederatedProviders), ",")
+
+		var providers []MetadataProvider
+		for _, name := range names {
+			name = strings.ToUpper(strings.TrimSpace(name))
+			if name == "" || name == "FEDERATED" {
+				continue
+			}
+
+			provider, err := New(name)
+			if err != nil {
+				logs.Warn("Skipping %s in federated search: %v", name, err)
+				continue
+			}
+			providers = append(providers, provider)
+		}
+
+		if len(providers) == 0 {
+			return nil, errors.New("no usable providers configured in metadata::federatedproviders")
+		}
+
+		timeout := time.Duration(config.DefaultInt("metadata::providertimeout", 5)) * time.Second
+		return NewFederatedProvider(timeout, providers...), nil
+	})
+}
+
+// NewFederatedProvider creates a provider that fans out to the given providers.
+// Provider order decides which source wins when merged results disagree.
+func NewFederatedProvider(timeout time.Duration, providers ...MetadataProvider) *FederatedProvider {
+	return &FederatedProvider{
+		Providers: providers,
+		Timeout:   timeout,
+	}
+}
+
+func (p *FederatedProvider) Name() string {
+	return "FEDERATED"
+}
+
+func (p *FederatedProvider) Search(ctx context.Context, query string) ([]models.BookResult, error) {
+	results := make([][]models.BookResult, len(p.Providers))
+	errs := make([]error, len(p.Providers))
+
+	var wg sync.WaitGroup
+	for i, provider := range p.Providers {
+		wg.Add(1)
+		go func(i int, provider MetadataProvider) {
+			defer wg.Done()
+
+			providerCtx := ctx
+			if p.Timeout > 0 {
+				var cancel context.CancelFunc
+				providerCtx, cancel = context.WithTimeout(ctx, p.Timeout)
+				defer cancel()
+			}
+
+			results[i], errs[i] = provider.Search(providerCtx, query)
+		}(i, provider)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			failed++
+			logs.Warn("Federated search: %s failed: %v", p.Providers[i].Name(), err)
+		}
+	}
+
+	if failed == len(p.Providers) {
+		return nil, fmt.Errorf("all federated providers failed: %w", errors.Join(errs...))
+	}
+
+	return mergeResults(results), nil
+}
+
+// mergeResults de-duplicates results from several providers. Books are matched
+// by ISBN-13 and, when either side has no ISBN, by normalized title and author.
+// Results are interleaved by rank so each provider's best matches stay on top.
+func mergeResults(providerResults [][]models.BookResult) []models.BookResult {
+	var merged []*models.BookResult
+	byISBN := make(map[string]*models.BookResult)
+	byKey := make(map[string]*models.BookResult)
+
+	for rank := 0; ; rank++ {
+		remaining := false
+		for _, results := range providerResults {
+			if rank >= len(results) {
+				continue
+			}
+			remaining = true
+
+			result := results[rank]
+			isbn := bookISBN13(result)
+			key := match.Key(result.Title, primaryAuthor(result))
+
+			existing := byISBN[isbn]
+			if existing == nil && isbn != "" {
+				// Only fall back to title matching when the other entry can't
+				// contradict us with a different ISBN.
+				if candidate := byKey[key]; candidate != nil && bookISBN13(*candidate) == "" {
+					existing = candidate
+				}
+			} else if existing == nil {
+				existing = byKey[key]
+			}
+
+			if existing == nil {
+				book := result
+				book.Providers = append([]string(nil), result.Providers...)
+				existing = &book
+				merged = append(merged, existing)
+			} else {
+				mergeInto(existing, result)
+			}
+
+			if isbn := bookISBN13(*existing); isbn != "" {
+				byISBN[isbn] = existing
+			}
+			if _, ok := byKey[key]; !ok {
+				byKey[key] = existing
+			}
+		}
+
+		if !remaining {
+			break
+		}
+	}
+
+	books := make([]models.BookResult, 0, len(merged))
+	for _, book := range merged {
+		books = append(books, *book)
+	}
+
+	return books
+}
+
+// mergeInto fills fields missing from dst with values from src and records the
+// providers that returned src.
+func mergeInto(dst *models.BookResult, src models.BookResult) {
+	for _, provider := range src.Providers {
+		if !containsString(dst.Providers, provider) {
+			dst.Providers = append(dst.Providers, provider)
+		}
+	}
+
+	if dst.Author == "" {
+		dst.Author = src.Author
+		dst.Authors = src.Authors
+	}
+	if dst.ISBN10 == nil {
+		dst.ISBN10 = src.ISBN10
+	}
+	if dst.ISBN13 == nil {
+		dst.ISBN13 = src.ISBN13
+	}
+	if dst.ASIN == nil {
+		dst.ASIN = src.ASIN
+	}
+	if dst.GoodreadsID == nil {
+		dst.GoodreadsID = src.GoodreadsID
+	}
+	if dst.Cover == nil {
+		dst.Cover = src.Cover
+		dst.Covers = src.Covers
+	}
+	if dst.Description == nil {
+		dst.Description = src.Description
+	}
+	if dst.PublishYear == nil || (src.PublishYear != nil && *src.PublishYear < *dst.PublishYear) {
+		dst.PublishYear = src.PublishYear
+	}
+	if dst.Series == nil {
+		dst.Series = src.Series
+	}
+	if dst.Language == nil {
+		dst.Language = src.Language
+	}
+	if dst.InfoLink == nil {
+		dst.InfoLink = src.InfoLink
+	}
+}
+
+func bookISBN13(book models.BookResult) string {
+	if book.ISBN13 != nil {
+		return match.ISBN13(*book.ISBN13)
+	}
+	if book.ISBN10 != nil {
+		return match.ISBN13(*book.ISBN10)
+	}
+	return ""
+}
+
+func primaryAuthor(book models.BookResult) string {
+	if len(book.Authors) > 0 {
+		return book.Authors[0]
+	}
+	return book.Author
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package metadata
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type stubProvider struct {
+	name    string
+	results []models.BookResult
+	err     error
+	delay   time.Duration
+}
+
+func (s *stubProvider) Name() string {
+	return s.name
+}
+
+func (s *stubProvider) Search(ctx context.Context, query string) ([]models.BookResult, error) {
+	if s.delay > 0 {
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	return s.results, s.err
+}
+
+func stubBook(provider, title, author, isbn13 string) models.BookResult {
+	book := models.BookResult{
+		Title:     title,
+		Author:    author,
+		Source:    provider,
+		SourceID:  provider + "-" + title,
+		Providers: []string{provider},
+	}
+	if isbn13 != "" {
+		book.ISBN13 = &isbn13
+	}
+	return book
+}
+
+func TestFederatedProvider(t *testing.T) {
+	Convey("Subject: Federated metadata search\n", t, func() {
+		description := "Spice and sandworms"
+		google := stubBook("GOOGLE", "Dune", "Frank Herbert", "9780441013593")
+		google.Description = &description
+
+		openlib := &stubProvider{name: "OPENLIBRARY", results: []models.BookResult{
+			stubBook("OPENLIBRARY", "Dune", "Frank Herbert", "9780441013593"),
+			stubBook("OPENLIBRARY", "The Hobbit", "J.R.R. Tolkien", ""),
+		}}
+		googleProvider := &stubProvider{name: "GOOGLE", results: []models.BookResult{
+			google,
+			stubBook("GOOGLE", "Hobbit: There and Back Again", "Tolkien, J. R. R.", "9780547928227"),
+			stubBook("GOOGLE", "Dune Messiah", "Frank Herbert", "9780593098233"),
+		}}
+
+		Convey("Books should be merged by ISBN and by title and author", func() {
+			provider := NewFederatedProvider(time.Second, openlib, googleProvider)
+			results, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 3)
+
+			So(results[0].Source, ShouldEqual, "OPENLIBRARY")
+			So(results[0].Providers, ShouldResemble, []string{"OPENLIBRARY", "GOOGLE"})
+			So(*results[0].Description, ShouldEqual, description)
+
+			So(results[1].Title, ShouldEqual, "The Hobbit")
+			So(results[1].Providers, ShouldResemble, []string{"OPENLIBRARY", "GOOGLE"})
+			So(*results[1].ISBN13, ShouldEqual, "9780547928227")
+
+			So(results[2].Title, ShouldEqual, "Dune Messiah")
+		})
+
+		Convey("Books with different ISBNs should not be merged", func() {
+			other := &stubProvider{name: "HARDCOVER", results: []models.BookResult{
+				stubBook("HARDCOVER", "Dune", "Frank Herbert", "9780340960196"),
+			}}
+			provider := NewFederatedProvider(time.Second, openlib, other)
+			results, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 3)
+		})
+
+		Convey("A failing or slow provider should not fail the search", func() {
+			broken := &stubProvider{name: "HARDCOVER", err: errors.New("boom")}
+			slow := &stubProvider{name: "SLOW", delay: time.Second}
+			provider := NewFederatedProvider(50*time.Millisecond, openlib, broken, slow)
+
+			results, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 2)
+		})
+
+		Convey("An error should be returned when every provider fails", func() {
+			broken := &stubProvider{name: "HARDCOVER", err: errors.New("boom")}
+			provider := NewFederatedProvider(time.Second, broken)
+
+			_, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
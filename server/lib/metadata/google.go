@@ -0,0 +1,115 @@
+package metadata
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+const googleBaseURL = "https://www.googleapis.com/books/v1/volumes"
+
+// GoogleProvider searches the Google Books volumes API.
+type GoogleProvider struct {
+	BaseURL string
+	APIKey  string
+	Client  *http.Client
+}
+
+func init() {
+	Register("GOOGLE", func() (MetadataProvider, error) {
+		apiKey, err := config.String("metadata::googleapikey")
+		if err != nil || apiKey == "" {
+			return nil, errors.New("missing metadata::googleapikey config")
+		}
+		return NewGoogleProvider(apiKey), nil
+	})
+}
+
+// NewGoogleProvider creates a Google Books provider using the public API endpoint.
+func NewGoogleProvider(apiKey string) *GoogleProvider {
+	return &GoogleProvider{
+		BaseURL: googleBaseURL,
+		APIKey:  apiKey,
+		Client:  newHTTPClient(),
+	}
+}
+
+func (p *GoogleProvider) Name() string {
+	return "GOOGLE"
+}
+
+func (p *GoogleProvider) Search(ctx context.Context, query string) ([]models.BookResult, error) {
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("key", p.APIKey)
+	params.Add("maxResults", "40")
+
+	fullURL := fmt.Sprintf("%s?%s", p.BaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data models.GoogleBooksResponse
+	if err := doJSON(p.Client, req, &data); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BookResult, 0, len(data.Items))
+	for _, book := range data.Items {
+		results = append(results, googleBookResult(book))
+	}
+
+	return results, nil
+}
+
+func googleBookResult(book models.GoogleBook) models.BookResult {
+	info := book.VolumeInfo
+
+	var identifiers []string
+	for _, id := range info.IndustryIdentifiers {
+		if id.Type == "ISBN_10" || id.Type == "ISBN_13" {
+			identifiers = append(identifiers, id.Identifier)
+		}
+	}
+	isbn10, isbn13 := splitISBNs(identifiers)
+
+	result := models.BookResult{
+		Title:       info.Title,
+		Author:      strings.Join(info.Authors, ", "),
+		Authors:     info.Authors,
+		Source:      "GOOGLE",
+		Providers:   []string{"GOOGLE"},
+		SourceID:    book.ID,
+		ISBN10:      isbn10,
+		ISBN13:      isbn13,
+		Description: stringPtr(info.Description),
+		PublishYear: parseYear(info.PublishedDate),
+		Language:    stringPtr(info.Language),
+		InfoLink:    stringPtr(info.InfoLink),
+	}
+
+	if links := info.ImageLinks; links != nil {
+		covers := models.CoverImages{
+			Small:  secureURL(links.SmallThumbnail),
+			Medium: secureURL(links.Thumbnail),
+			Large:  secureURL(links.Large),
+		}
+		if covers.Large == "" {
+			covers.Large = secureURL(links.Medium)
+		}
+		if covers.Large == "" {
+			covers.Large = covers.Medium
+		}
+		result.Covers = &covers
+		result.Cover = stringPtr(covers.Medium)
+	}
+
+	return result
+}
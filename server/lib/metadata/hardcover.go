@@ -0,0 +1,186 @@
+package metadata
+
+import (
+	"api/models"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+const hardcoverBaseURL = "https://hardcover-hasura-production-1136269bb9de.herokuapp.com/v1/graphql"
+
+const hardcoverQuery = `
+    query BookSearch($search: String!) {
+      books(
+        where: {title: {_ilike: $search}, users_read_count: {_gt: 0}}
+        order_by: [{users_count: desc_nulls_last}, {description: desc_nulls_last}]
+        limit: 40
+      ) {
+        title
+        id
+        slug
+        users_read_count
+        users_count
+        cached_image
+        description
+        release_year
+        book_series {
+          position
+          series {
+            name
+          }
+        }
+        contributions {
+          author {
+            name
+            id
+          }
+        }
+        images {
+          url
+        }
+        default_physical_edition {
+          isbn_10
+          isbn_13
+        }
+        default_audio_edition {
+          asin
+        }
+      }
+    }
+    `
+
+// HardcoverProvider searches the Hardcover GraphQL API.
+type HardcoverProvider struct {
+	BaseURL     string
+	BearerToken string
+	Client      *http.Client
+}
+
+func init() {
+	Register("HARDCOVER", func() (MetadataProvider, error) {
+		token, err := config.String("metadata::hardcoverbearertoken")
+		if err != nil || token == "" {
+			return nil, errors.New("missing metadata::hardcoverbearertoken config")
+		}
+		return NewHardcoverProvider(token), nil
+	})
+}
+
+// NewHardcoverProvider creates a Hardcover provider using the public GraphQL endpoint.
+func NewHardcoverProvider(bearerToken string) *HardcoverProvider {
+	return &HardcoverProvider{
+		BaseURL:     hardcoverBaseURL,
+		BearerToken: bearerToken,
+		Client:      newHTTPClient(),
+	}
+}
+
+func (p *HardcoverProvider) Name() string {
+	return "HARDCOVER"
+}
+
+func (p *HardcoverProvider) Search(ctx context.Context, query string) ([]models.BookResult, error) {
+	payload := map[string]any{
+		"query": hardcoverQuery,
+		"variables": map[string]string{
+			"search": "%" + query + "%",
+		},
+	}
+
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error marshalling JSON payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.BaseURL, bytes.NewBuffer(payloadBytes))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", fmt.Sprintf("Bearer %s", p.BearerToken))
+
+	var data models.HardcoverResponse
+	if err := doJSON(p.Client, req, &data); err != nil {
+		return nil, err
+	}
+
+	if data.Errors != nil {
+		return nil, fmt.Errorf("error response from hardcover: %v", data.Errors)
+	}
+
+	results := make([]models.BookResult, 0, len(data.Data.Books))
+	for _, book := range data.Data.Books {
+		results = append(results, hardcoverBookResult(book))
+	}
+
+	return results, nil
+}
+
+func hardcoverBookResult(book models.HardcoverBook) models.BookResult {
+	var authors []string
+	for _, contribution := range book.Contributions {
+		if contribution.Author.Name != "" {
+			authors = append(authors, contribution.Author.Name)
+		}
+	}
+
+	result := models.BookResult{
+		Title:       book.Title,
+		Authors:     authors,
+		Source:      "HARDCOVER",
+		Providers:   []string{"HARDCOVER"},
+		SourceID:    strconv.Itoa(book.ID),
+		Description: book.Description,
+		PublishYear: book.ReleaseYear,
+		InfoLink:    stringPtr("https://hardcover.app/books/" + book.Slug),
+	}
+
+	// Hardcover lists every contributor; the first is the primary author.
+	if len(authors) > 0 {
+		result.Author = authors[0]
+	}
+
+	if edition := book.DefaultPhysicalEdition; edition != nil {
+		var identifiers []string
+		if edition.ISBN10 != nil {
+			identifiers = append(identifiers, *edition.ISBN10)
+		}
+		if edition.ISBN13 != nil {
+			identifiers = append(identifiers, *edition.ISBN13)
+		}
+		result.ISBN10, result.ISBN13 = splitISBNs(identifiers)
+	}
+
+	if edition := book.DefaultAudioEdition; edition != nil && edition.ASIN != nil {
+		result.ASIN = stringPtr(*edition.ASIN)
+	}
+
+	if len(book.BookSeries) > 0 {
+		series := book.BookSeries[0]
+		name := series.Series.Name
+		if series.Position != nil {
+			name = fmt.Sprintf("%s #%s", name, strconv.FormatFloat(*series.Position, 'f', -1, 64))
+		}
+		result.Series = stringPtr(name)
+	}
+
+	image := ""
+	if book.CachedImage != nil {
+		image = book.CachedImage.URL
+	} else if len(book.Images) > 0 {
+		image = book.Images[0].URL
+	}
+	if image != "" {
+		result.Covers = &models.CoverImages{Small: image, Medium: image, Large: image}
+		result.Cover = &image
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"api/lib/match"
+	"strconv"
+	"strings"
+)
+
+// splitISBNs returns the first ISBN-10 and ISBN-13 found in the list.
+func splitISBNs(isbns []string) (isbn10, isbn13 *string) {
+	for _, raw := range isbns {
+		isbn := match.NormalizeISBN(raw)
+		switch {
+		case len(isbn) == 10 && isbn10 == nil:
+			isbn10 = &isbn
+		case len(isbn) == 13 && isbn13 == nil:
+			isbn13 = &isbn
+		}
+		if isbn10 != nil && isbn13 != nil {
+			break
+		}
+	}
+
+	return isbn10, isbn13
+}
+
+// parseYear extracts a four digit year from the start of a date string such as
+// "2004", "2004-09" or "2004-09-01".
+func parseYear(date string) *int {
+	if len(date) < 4 {
+		return nil
+	}
+
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return nil
+	}
+
+	return &year
+}
+
+// stringPtr returns nil for empty strings so absent values are serialized as null.
+func stringPtr(s string) *string {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+// secureURL upgrades plain http image links, which browsers block as mixed content.
+func secureURL(link string) string {
+	if strings.HasPrefix(link, "http://") {
+		return "https://" + strings.TrimPrefix(link, "http://")
+	}
+	return link
+}
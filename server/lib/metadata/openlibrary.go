@@ -0,0 +1,107 @@
+package metadata
+
+import (
+	"api/models"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+)
+
+const openLibraryBaseURL = "https://openlibrary.org/search.json"
+
+// OpenLibraryProvider searches the Open Library search API.
+type OpenLibraryProvider struct {
+	BaseURL string
+	Client  *http.Client
+}
+
+func init() {
+	Register("OPENLIBRARY", func() (MetadataProvider, error) {
+		return NewOpenLibraryProvider(), nil
+	})
+}
+
+// NewOpenLibraryProvider creates an Open Library provider using the public API endpoint.
+func NewOpenLibraryProvider() *OpenLibraryProvider {
+	return &OpenLibraryProvider{
+		BaseURL: openLibraryBaseURL,
+		Client:  newHTTPClient(),
+	}
+}
+
+func (p *OpenLibraryProvider) Name() string {
+	return "OPENLIBRARY"
+}
+
+func (p *OpenLibraryProvider) Search(ctx context.Context, query string) ([]models.BookResult, error) {
+	params := url.Values{}
+	params.Add("q", query)
+	params.Add("fields", "key,seed,author_name,title,cover_i,isbn,id_goodreads,first_publish_year,language,first_sentence")
+	params.Add("limit", "40")
+
+	fullURL := fmt.Sprintf("%s?%s", p.BaseURL, params.Encode())
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fullURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var data models.OpenLibraryResponse
+	if err := doJSON(p.Client, req, &data); err != nil {
+		return nil, err
+	}
+
+	results := make([]models.BookResult, 0, len(data.Docs))
+	for _, book := range data.Docs {
+		results = append(results, openLibraryBookResult(book))
+	}
+
+	return results, nil
+}
+
+func openLibraryBookResult(book models.OpenLibraryBook) models.BookResult {
+	book.SetInfoLink()
+	book.SetCoverImage()
+
+	sourceID := path.Base(book.Key)
+	if book.Key == "" && len(book.Seed) > 0 {
+		sourceID = path.Base(book.Seed[0])
+	}
+
+	isbn10, isbn13 := splitISBNs(book.ISBN)
+
+	result := models.BookResult{
+		Title:       book.Title,
+		Author:      strings.Join(book.AuthorName, ", "),
+		Authors:     book.AuthorName,
+		Source:      "OPENLIBRARY",
+		Providers:   []string{"OPENLIBRARY"},
+		SourceID:    sourceID,
+		ISBN10:      isbn10,
+		ISBN13:      isbn13,
+		PublishYear: book.FirstPublishYear,
+		InfoLink:    book.InfoLink,
+	}
+
+	if len(book.GoodreadsIDs) > 0 {
+		result.GoodreadsID = stringPtr(book.GoodreadsIDs[0])
+	}
+
+	if len(book.FirstSentence) > 0 {
+		result.Description = stringPtr(book.FirstSentence[0])
+	}
+
+	if len(book.Language) > 0 {
+		result.Language = stringPtr(book.Language[0])
+	}
+
+	if len(book.CoverImages) > 0 {
+		covers := models.CoverImages(book.CoverImages[0])
+		result.Covers = &covers
+		result.Cover = stringPtr(covers.Medium)
+	}
+
+	return result
+}
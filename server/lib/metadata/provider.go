@@ -0,0 +1,65 @@
+package metadata
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrUnknownProvider is returned when no provider is registered under the requested name.
+var ErrUnknownProvider = errors.New("unknown metadata provider")
+
+// MetadataProvider performs book searches against an external metadata source.
+type MetadataProvider interface {
+	// Name returns the identifier used for the provider in metadata::provider.
+	Name() string
+	// Search queries the provider and maps its results into BookResults.
+	Search(ctx context.Context, query string) ([]models.BookResult, error)
+}
+
+// Factory builds a provider from the current configuration.
+type Factory func() (MetadataProvider, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a provider available under the given name. Names are case-insensitive.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	registry[strings.ToUpper(name)] = factory
+}
+
+// New builds the provider registered under the given name.
+func New(name string) (MetadataProvider, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToUpper(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	return factory()
+}
+
+// Providers returns the names of all registered providers in sorted order.
+func Providers() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
@@ -0,0 +1,138 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRegistry(t *testing.T) {
+	Convey("Subject: Metadata provider registry\n", t, func() {
+		Convey("The built-in providers should be registered", func() {
+			So(Providers(), ShouldContain, "GOOGLE")
+			So(Providers(), ShouldContain, "OPENLIBRARY")
+			So(Providers(), ShouldContain, "HARDCOVER")
+		})
+		Convey("Lookups should be case-insensitive", func() {
+			provider, err := New("openlibrary")
+			So(err, ShouldBeNil)
+			So(provider.Name(), ShouldEqual, "OPENLIBRARY")
+		})
+		Convey("Unknown providers should return ErrUnknownProvider", func() {
+			_, err := New("nope")
+			So(errors.Is(err, ErrUnknownProvider), ShouldBeTrue)
+		})
+	})
+}
+
+func TestGoogleProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "test-key" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"items":[{"id":"abc","volumeInfo":{"title":"Dune","authors":["Frank Herbert"],"publishedDate":"1965-08-01","language":"en","industryIdentifiers":[{"type":"ISBN_10","identifier":"0441013597"},{"type":"ISBN_13","identifier":"978-0441013593"}],"imageLinks":{"smallThumbnail":"http://img/s.jpg","thumbnail":"http://img/m.jpg"}}}]}`))
+	}))
+	defer server.Close()
+
+	Convey("Subject: Google provider\n", t, func() {
+		provider := NewGoogleProvider("test-key")
+		provider.BaseURL = server.URL
+
+		Convey("Results should be mapped into BookResults", func() {
+			results, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldBeNil)
+			So(results, ShouldHaveLength, 1)
+
+			book := results[0]
+			So(book.Source, ShouldEqual, "GOOGLE")
+			So(book.SourceID, ShouldEqual, "abc")
+			So(book.Author, ShouldEqual, "Frank Herbert")
+			So(*book.ISBN10, ShouldEqual, "0441013597")
+			So(*book.ISBN13, ShouldEqual, "9780441013593")
+			So(*book.PublishYear, ShouldEqual, 1965)
+			So(*book.Language, ShouldEqual, "en")
+			So(*book.Cover, ShouldEqual, "https://img/m.jpg")
+		})
+		Convey("Non-200 responses should return an error", func() {
+			provider.APIKey = "bad-key"
+			_, err := provider.Search(context.Background(), "dune")
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestOpenLibraryProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"numFound":1,"docs":[{"key":"/works/OL1W","title":"Dune","author_name":["Frank Herbert"],"cover_i":42,"isbn":["9780441013593","0441013597"],"first_publish_year":1965,"language":["eng"]}]}`))
+	}))
+	defer server.Close()
+
+	Convey("Subject: Open Library provider\n", t, func() {
+		provider := NewOpenLibraryProvider()
+		provider.BaseURL = server.URL
+
+		results, err := provider.Search(context.Background(), "dune")
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 1)
+
+		book := results[0]
+		Convey("Identifiers should be taken from the work key and ISBN list", func() {
+			So(book.SourceID, ShouldEqual, "OL1W")
+			So(*book.ISBN10, ShouldEqual, "0441013597")
+			So(*book.ISBN13, ShouldEqual, "9780441013593")
+			So(*book.PublishYear, ShouldEqual, 1965)
+		})
+		Convey("Info links and cover images should be populated", func() {
+			So(*book.InfoLink, ShouldEqual, "https://openlibrary.org/works/OL1W")
+			So(*book.Cover, ShouldEqual, "https://covers.openlibrary.org/b/id/42-M.jpg")
+			So(book.Covers.Large, ShouldEqual, "https://covers.openlibrary.org/b/id/42-L.jpg")
+		})
+	})
+}
+
+func TestHardcoverProvider(t *testing.T) {
+	var gotAuth string
+	var gotSearch string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("authorization")
+
+		var body struct {
+			Variables map[string]string `json:"variables"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		gotSearch = body.Variables["search"]
+
+		w.Write([]byte(`{"data":{"books":[{"id":7,"title":"Dune","slug":"dune","release_year":1965,"cached_image":{"url":"https://img/dune.jpg"},"contributions":[{"author":{"name":"Frank Herbert","id":1}}],"book_series":[{"position":1,"series":{"name":"Dune"}}],"default_physical_edition":{"isbn_13":"9780441013593"}}]}}`))
+	}))
+	defer server.Close()
+
+	Convey("Subject: Hardcover provider\n", t, func() {
+		provider := NewHardcoverProvider("secret")
+		provider.BaseURL = server.URL
+
+		results, err := provider.Search(context.Background(), "dune")
+		So(err, ShouldBeNil)
+		So(results, ShouldHaveLength, 1)
+
+		Convey("The bearer token and wildcard search should be sent", func() {
+			So(gotAuth, ShouldEqual, "Bearer secret")
+			So(gotSearch, ShouldEqual, "%dune%")
+		})
+		Convey("The result should be mapped into a BookResult", func() {
+			book := results[0]
+			So(book.SourceID, ShouldEqual, "7")
+			So(book.Author, ShouldEqual, "Frank Herbert")
+			So(*book.ISBN13, ShouldEqual, "9780441013593")
+			So(book.ISBN10, ShouldBeNil)
+			So(*book.Series, ShouldEqual, "Dune #1")
+			So(*book.Cover, ShouldEqual, "https://img/dune.jpg")
+			So(*book.InfoLink, ShouldEqual, "https://hardcover.app/books/dune")
+		})
+	})
+}
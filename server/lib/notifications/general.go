@@ -109,6 +109,11 @@ Beep Boop,
 Seeklit Automated Alerts`, request.Title, request.Author, request.ID)
 
 	case "denied":
+		reason := ""
+		if request.DenialReason != nil {
+			reason = fmt.Sprintf("Reason: %s\n", *request.DenialReason)
+		}
+
 		title = "❌ Your Book Request Was Denied"
 		body = fmt.Sprintf(`Hello,
 
@@ -116,11 +121,11 @@ Unfortunately, your book request for "%s" by %s has been denied.
 
 Request ID: #%d
 Status: Denied
-
+%s
 If you have questions about this decision, please contact the administrator.
 
 Beep Boop,
-Seeklit Automated Alerts`, request.Title, request.Author, request.ID)
+Seeklit Automated Alerts`, request.Title, request.Author, request.ID, reason)
 
 	case "completed":
 		title = "🎉 Your Book Request is Complete!"
@@ -156,6 +161,28 @@ Seeklit Automated Alerts`, request.Title, request.Author, request.ID)
 	}
 
 	SendUserNotificationIfEnabled(request.RequestorID, title, body)
+
+	// Users who asked for the same book follow the original request
+	if statusType == "completed" || statusType == "denied" {
+		sendFollowerNotifications(request, "", title, body)
+	}
+}
+
+// sendFollowerNotifications notifies every follower of a book request except
+// the requestor and, when set, the user who caused the notification
+func sendFollowerNotifications(request *models.BookRequest, exceptID, title, body string) {
+	var followers []models.RequestFollower
+	if err := database.DB.Where("request_id = ?", request.ID).Find(&followers).Error; err != nil {
+		logs.Warn("Failed to get followers for book request #%d: %v", request.ID, err)
+		return
+	}
+
+	for _, follower := range followers {
+		if follower.UserID == request.RequestorID || follower.UserID == exceptID {
+			continue
+		}
+		SendUserNotificationIfEnabled(follower.UserID, title, body)
+	}
 }
 
 // SendIssueStatusNotification sends notification when issue status changes
@@ -200,3 +227,32 @@ Seeklit Automated Alerts`, issue.BookTitle, issue.ID, issue.Description)
 
 	SendUserNotificationIfEnabled(issue.CreatorID, title, body)
 }
+
+// SendRequestCommentNotification tells the other side of a request's thread
+// about a new comment. Admin comments go to the requestor and followers, and
+// everyone else's go to the admins.
+func SendRequestCommentNotification(request *models.BookRequest, comment *models.RequestComment) {
+	if !comment.FromAdmin {
+		title := fmt.Sprintf("💬 %s commented on request #%d", comment.AuthorUsername, request.ID)
+		body := fmt.Sprintf("%s by %s\n\n%s", request.Title, request.Author, comment.Body)
+		SendAdminNotification(title, body)
+		return
+	}
+
+	title := "💬 New Comment on Your Book Request"
+	body := fmt.Sprintf(`Hello,
+
+%s commented on your book request for "%s" by %s:
+
+%s
+
+Request ID: #%d
+
+Beep Boop,
+Seeklit Automated Alerts`, comment.AuthorUsername, request.Title, request.Author, comment.Body, request.ID)
+
+	if request.RequestorID != comment.AuthorID {
+		SendUserNotificationIfEnabled(request.RequestorID, title, body)
+	}
+	sendFollowerNotifications(request, comment.AuthorID, title, body)
+}
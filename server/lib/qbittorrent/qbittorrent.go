@@ -0,0 +1,254 @@
+// Package qbittorrent adds releases to qBittorrent through its Web API and
+// tracks them until they finish downloading.
+package qbittorrent
+
+import (
+	"api/lib/download"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+func init() {
+	download.RegisterClient("QBITTORRENT", func() (download.Client, error) {
+		baseURL := config.DefaultString("download::qbittorrenturl", "")
+		if baseURL == "" {
+			return nil, errors.New("download::qbittorrenturl is not configured")
+		}
+
+		client := NewClient(baseURL,
+			config.DefaultString("download::qbittorrentusername", ""),
+			config.DefaultString("download::qbittorrentpassword", ""))
+		client.Category = config.DefaultString("download::qbittorrentcategory", "seeklit")
+		return client, nil
+	})
+}
+
+// ErrLoginFailed is returned when qBittorrent rejects the configured credentials.
+var ErrLoginFailed = errors.New("qbittorrent login failed")
+
+// Client talks to the qBittorrent Web API (v2).
+type Client struct {
+	BaseURL  string
+	Username string
+	Password string
+	// Category is applied to every added torrent.
+	Category   string
+	HTTPClient *http.Client
+
+	loginMu  sync.Mutex
+	loggedIn atomic.Bool
+}
+
+// NewClient returns a client for the qBittorrent instance at baseURL.
+func NewClient(baseURL, username, password string) *Client {
+	jar, _ := cookiejar.New(nil)
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		Username:   username,
+		Password:   password,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second, Jar: jar},
+	}
+}
+
+// Torrent is an entry from /api/v2/torrents/info.
+type Torrent struct {
+	Hash     string  `json:"hash"`
+	Name     string  `json:"name"`
+	State    string  `json:"state"`
+	Progress float64 `json:"progress"`
+	Tags     string  `json:"tags"`
+	Category string  `json:"category"`
+}
+
+func (c *Client) Name() string {
+	return "qbittorrent"
+}
+
+// Supports accepts torrents only.
+func (c *Client) Supports(protocol download.Protocol) bool {
+	return protocol == download.ProtocolTorrent
+}
+
+// Add adds the release by URL with a unique tag, which is returned as the ID.
+// qBittorrent doesn't return the torrent hash, so the tag is how it's found again.
+func (c *Client) Add(ctx context.Context, release download.Release) (string, error) {
+	if release.URL == "" {
+		return "", errors.New("release has no download URL")
+	}
+
+	tag, err := newTag()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("urls", release.URL)
+	form.Set("tags", tag)
+	if c.Category != "" {
+		form.Set("category", c.Category)
+	}
+
+	body, err := c.post(ctx, "/api/v2/torrents/add", form)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(string(body)) == "Fails." {
+		return "", errors.New("qbittorrent rejected the torrent")
+	}
+
+	return tag, nil
+}
+
+// Status looks up the torrent added under the tag.
+func (c *Client) Status(ctx context.Context, id string) (*download.Status, error) {
+	body, err := c.get(ctx, "/api/v2/torrents/info", url.Values{"tag": {id}})
+	if err != nil {
+		return nil, err
+	}
+
+	var torrents []Torrent
+	if err := json.Unmarshal(body, &torrents); err != nil {
+		return nil, fmt.Errorf("decode qbittorrent torrents: %w", err)
+	}
+
+	if len(torrents) == 0 {
+		return &download.Status{State: download.StateFailed, Reason: "torrent is no longer in qBittorrent"}, nil
+	}
+
+	return torrentStatus(torrents[0]), nil
+}
+
+// torrentStatus maps qBittorrent's torrent states onto download states.
+func torrentStatus(torrent Torrent) *download.Status {
+	status := &download.Status{Progress: torrent.Progress}
+
+	switch torrent.State {
+	case "error":
+		status.State = download.StateFailed
+		status.Reason = "qBittorrent reported an error for the torrent"
+	case "missingFiles":
+		status.State = download.StateFailed
+		status.Reason = "qBittorrent is missing the torrent's files"
+	case "uploading", "stalledUP", "pausedUP", "stoppedUP", "queuedUP", "forcedUP", "checkingUP":
+		status.State = download.StateCompleted
+	case "queuedDL", "metaDL", "checkingDL", "allocating", "checkingResumeData", "moving":
+		status.State = download.StateQueued
+	default:
+		// downloading, stalledDL, pausedDL, stoppedDL, forcedDL and unknown states
+		status.State = download.StateDownloading
+	}
+
+	if status.State != download.StateFailed && torrent.Progress >= 1 {
+		status.State = download.StateCompleted
+	}
+
+	return status
+}
+
+func (c *Client) login(ctx context.Context) error {
+	c.loginMu.Lock()
+	defer c.loginMu.Unlock()
+
+	form := url.Values{"username": {c.Username}, "password": {c.Password}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/v2/auth/login", strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	// qBittorrent rejects logins whose Referer doesn't match its host
+	req.Header.Set("Referer", c.BaseURL)
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || strings.TrimSpace(string(body)) != "Ok." {
+		return ErrLoginFailed
+	}
+
+	c.loggedIn.Store(true)
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, params url.Values) ([]byte, error) {
+	return c.do(ctx, func() (*http.Request, error) {
+		return http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+path+"?"+params.Encode(), nil)
+	})
+}
+
+func (c *Client) post(ctx context.Context, path string, form url.Values) ([]byte, error) {
+	return c.do(ctx, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, strings.NewReader(form.Encode()))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		return req, nil
+	})
+}
+
+// do sends the request, logging in first and again if the session expired.
+func (c *Client) do(ctx context.Context, build func() (*http.Request, error)) ([]byte, error) {
+	if !c.loggedIn.Load() {
+		if err := c.login(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	for retried := false; ; retried = true {
+		req, err := build()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Referer", c.BaseURL)
+
+		resp, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusForbidden && !retried {
+			if err := c.login(ctx); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("qbittorrent returned status code %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		}
+
+		return body, nil
+	}
+}
+
+func newTag() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "seeklit-" + hex.EncodeToString(b), nil
+}
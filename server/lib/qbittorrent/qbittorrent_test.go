@@ -0,0 +1,137 @@
+package qbittorrent
+
+import (
+	"api/lib/download"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type fakeQBittorrent struct {
+	mu       sync.Mutex
+	logins   int
+	torrents map[string]Torrent
+}
+
+func newFakeQBittorrent() (*fakeQBittorrent, *httptest.Server) {
+	fake := &fakeQBittorrent{torrents: map[string]Torrent{}}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		if r.URL.Path == "/api/v2/auth/login" {
+			if r.FormValue("username") != "admin" || r.FormValue("password") != "adminadmin" {
+				w.Write([]byte("Fails."))
+				return
+			}
+			fake.logins++
+			http.SetCookie(w, &http.Cookie{Name: "SID", Value: "session", Path: "/"})
+			w.Write([]byte("Ok."))
+			return
+		}
+
+		if cookie, err := r.Cookie("SID"); err != nil || cookie.Value != "session" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte("Forbidden"))
+			return
+		}
+
+		switch r.URL.Path {
+		case "/api/v2/torrents/add":
+			if r.FormValue("urls") == "" {
+				w.Write([]byte("Fails."))
+				return
+			}
+			tag := r.FormValue("tags")
+			fake.torrents[tag] = Torrent{Hash: "abc", Name: "Dune", State: "downloading", Progress: 0.5, Tags: tag, Category: r.FormValue("category")}
+			w.Write([]byte("Ok."))
+		case "/api/v2/torrents/info":
+			torrents := []Torrent{}
+			if torrent, ok := fake.torrents[r.URL.Query().Get("tag")]; ok {
+				torrents = append(torrents, torrent)
+			}
+			json.NewEncoder(w).Encode(torrents)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	return fake, server
+}
+
+func (f *fakeQBittorrent) setState(tag, state string, progress float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	torrent := f.torrents[tag]
+	torrent.State = state
+	torrent.Progress = progress
+	f.torrents[tag] = torrent
+}
+
+func TestClient(t *testing.T) {
+	fake, server := newFakeQBittorrent()
+	defer server.Close()
+
+	release := download.Release{Title: "Dune", URL: "magnet:?xt=urn:btih:abc", Protocol: download.ProtocolTorrent}
+
+	Convey("Subject: qBittorrent download client", t, func() {
+		client := NewClient(server.URL, "admin", "adminadmin")
+		client.Category = "books"
+
+		So(client.Supports(download.ProtocolTorrent), ShouldBeTrue)
+		So(client.Supports(download.ProtocolUsenet), ShouldBeFalse)
+
+		Convey("Added torrents are tracked until they finish", func() {
+			tag, err := client.Add(context.Background(), release)
+			So(err, ShouldBeNil)
+			So(tag, ShouldStartWith, "seeklit-")
+			So(fake.torrents[tag].Category, ShouldEqual, "books")
+
+			status, err := client.Status(context.Background(), tag)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateDownloading)
+			So(status.Progress, ShouldEqual, 0.5)
+
+			fake.setState(tag, "stalledUP", 1)
+			status, err = client.Status(context.Background(), tag)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateCompleted)
+
+			fake.setState(tag, "error", 0.7)
+			status, err = client.Status(context.Background(), tag)
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+			So(status.Reason, ShouldNotBeEmpty)
+		})
+
+		Convey("Torrents removed from the client fail", func() {
+			status, err := client.Status(context.Background(), "seeklit-missing")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+		})
+
+		Convey("Expired sessions log in again", func() {
+			_, err := client.Add(context.Background(), release)
+			So(err, ShouldBeNil)
+			logins := fake.logins
+
+			// Drop the session cookie as if qBittorrent had expired it
+			client.HTTPClient.Jar, _ = cookiejar.New(nil)
+
+			_, err = client.Status(context.Background(), "seeklit-missing")
+			So(err, ShouldBeNil)
+			So(fake.logins, ShouldEqual, logins+1)
+		})
+
+		Convey("Bad credentials are reported", func() {
+			_, err := NewClient(server.URL, "admin", "wrong").Add(context.Background(), release)
+			So(err, ShouldEqual, ErrLoginFailed)
+		})
+	})
+}
@@ -0,0 +1,225 @@
+// Package queue processes persisted download jobs in the background with a
+// fixed pool of workers.
+package queue
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Handler processes a single job. Returning an error marks the job as failed.
+type Handler func(ctx context.Context, job *models.DownloadJob) error
+
+// RequeueError asks the queue to run the job again after Delay instead of
+// finishing it. Handlers return it with RequeueAfter.
+type RequeueError struct {
+	Delay time.Duration
+}
+
+func (e *RequeueError) Error() string {
+	return fmt.Sprintf("requeued for %s", e.Delay)
+}
+
+// RequeueAfter returns an error that puts the job back on the queue until delay
+// has elapsed, e.g. to poll something that hasn't finished yet.
+func RequeueAfter(delay time.Duration) error {
+	return &RequeueError{Delay: delay}
+}
+
+// defaultPollInterval is how often idle workers check the table for jobs that
+// were queued without a wake-up, e.g. by another process.
+const defaultPollInterval = 30 * time.Second
+
+// Queue hands queued jobs to a pool of workers.
+type Queue struct {
+	repo         models.JobRepository
+	handler      Handler
+	concurrency  int
+	PollInterval time.Duration
+
+	wake    chan struct{}
+	claimMu sync.Mutex
+	wg      sync.WaitGroup
+	cancel  context.CancelFunc
+}
+
+// New returns a queue that runs handler with up to concurrency jobs at once.
+func New(repo models.JobRepository, handler Handler, concurrency int) *Queue {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &Queue{
+		repo:         repo,
+		handler:      handler,
+		concurrency:  concurrency,
+		PollInterval: defaultPollInterval,
+		wake:         make(chan struct{}, concurrency),
+	}
+}
+
+// Start requeues jobs interrupted by a previous shutdown and starts the workers.
+func (q *Queue) Start(ctx context.Context) error {
+	requeued, err := q.repo.RequeueRunningJobs()
+	if err != nil {
+		return fmt.Errorf("requeue interrupted jobs: %w", err)
+	}
+	if requeued > 0 {
+		logs.Info("Requeued %d interrupted download job(s).", requeued)
+	}
+
+	ctx, q.cancel = context.WithCancel(ctx)
+	for i := 0; i < q.concurrency; i++ {
+		q.wg.Add(1)
+		go q.work(ctx, i+1)
+	}
+
+	logs.Info("Download queue started with %d worker(s).", q.concurrency)
+	return nil
+}
+
+// Stop signals the workers to exit and waits for running jobs to return.
+func (q *Queue) Stop() {
+	if q.cancel != nil {
+		q.cancel()
+	}
+	q.wg.Wait()
+}
+
+// Enqueue persists a job for the request and wakes an idle worker. If the
+// request already has a queued or running job, that job is returned instead.
+func (q *Queue) Enqueue(requestID uint) (*models.DownloadJob, error) {
+	job, err := q.repo.GetActiveJob(requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if job == nil {
+		job, err = q.repo.CreateJob(&models.DownloadJob{RequestID: requestID})
+		if err != nil {
+			return nil, err
+		}
+		logs.Info("Queued download job #%d for request #%d.", job.ID, requestID)
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return job, nil
+}
+
+// Retry queues the next attempt of a failed job once delay has elapsed.
+func (q *Queue) Retry(job *models.DownloadJob, delay time.Duration) (*models.DownloadJob, error) {
+	return q.Schedule(&models.DownloadJob{RequestID: job.RequestID, Attempt: job.Attempt + 1}, delay)
+}
+
+// Schedule queues the job to run once delay has elapsed.
+func (q *Queue) Schedule(job *models.DownloadJob, delay time.Duration) (*models.DownloadJob, error) {
+	runAfter := time.Now().Add(delay)
+	job.RunAfter = &runAfter
+
+	next, err := q.repo.CreateJob(job)
+	if err != nil {
+		return nil, err
+	}
+
+	logs.Info("Queued %s job #%d for request #%d (attempt %d) to run after %s.",
+		next.Kind, next.ID, next.RequestID, next.Attempt, runAfter.Format(time.RFC3339))
+	return next, nil
+}
+
+// Backoff returns the exponential delay before the retry that follows attempt,
+// doubling base for every attempt and capping the result at max.
+func Backoff(attempt int, base, max time.Duration) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	delay := base
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= max {
+			return max
+		}
+	}
+
+	if delay > max {
+		return max
+	}
+	return delay
+}
+
+func (q *Queue) work(ctx context.Context, worker int) {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := q.claim()
+		if err != nil {
+			logs.Warn("Download worker %d unable to claim job: %v", worker, err)
+		}
+
+		if job != nil {
+			q.run(ctx, worker, job)
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-q.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// claim serializes claims so workers don't contend on the database.
+func (q *Queue) claim() (*models.DownloadJob, error) {
+	q.claimMu.Lock()
+	defer q.claimMu.Unlock()
+
+	return q.repo.ClaimNextJob()
+}
+
+func (q *Queue) run(ctx context.Context, worker int, job *models.DownloadJob) {
+	logs.Info("Download worker %d processing job #%d for request #%d.", worker, job.ID, job.RequestID)
+
+	jobErr := q.safeHandle(ctx, job)
+
+	var requeue *RequeueError
+	if errors.As(jobErr, &requeue) {
+		if err := q.repo.RequeueJob(job, time.Now().Add(requeue.Delay)); err != nil {
+			logs.Critical("Unable to requeue download job #%d.\n%v\n", job.ID, err)
+		}
+		return
+	}
+
+	if jobErr != nil {
+		logs.Warn("Download job #%d failed: %v", job.ID, jobErr)
+	}
+
+	if _, err := q.repo.FinishJob(job, jobErr); err != nil {
+		logs.Critical("Unable to record result of download job #%d.\n%v\n", job.ID, err)
+	}
+}
+
+// safeHandle keeps a panicking handler from taking down the worker.
+func (q *Queue) safeHandle(ctx context.Context, job *models.DownloadJob) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return q.handler(ctx, job)
+}
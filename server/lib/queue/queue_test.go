@@ -0,0 +1,187 @@
+package queue
+
+import (
+	"api/models"
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	. "github.com/smartystreets/goconvey/convey"
+	"gorm.io/gorm"
+)
+
+func newTestRepository(t *testing.T) models.JobRepository {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+
+	// Every connection to :memory: is a separate database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("get sql database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(&models.DownloadJob{}); err != nil {
+		t.Fatalf("migrate test database: %v", err)
+	}
+
+	return models.NewJobRepository(db)
+}
+
+func waitForStatus(repo models.JobRepository, id uint, status models.JobStatus) *models.DownloadJob {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := repo.GetJob(id)
+		if err == nil && job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	job, _ := repo.GetJob(id)
+	return job
+}
+
+func TestQueue(t *testing.T) {
+	Convey("Subject: Background download queue", t, func() {
+		repo := newTestRepository(t)
+
+		var mu sync.Mutex
+		handled := []uint{}
+		handler := func(ctx context.Context, job *models.DownloadJob) error {
+			mu.Lock()
+			handled = append(handled, job.RequestID)
+			mu.Unlock()
+
+			if job.RequestID == 2 {
+				return errors.New("no release found")
+			}
+			return nil
+		}
+
+		q := New(repo, handler, 2)
+
+		Convey("Enqueued jobs are processed and their outcome recorded", func() {
+			So(q.Start(context.Background()), ShouldBeNil)
+			defer q.Stop()
+
+			ok, err := q.Enqueue(1)
+			So(err, ShouldBeNil)
+			So(ok.Status, ShouldEqual, models.JSQueued)
+
+			failed, err := q.Enqueue(2)
+			So(err, ShouldBeNil)
+
+			job := waitForStatus(repo, ok.ID, models.JSSucceeded)
+			So(job.Status, ShouldEqual, models.JSSucceeded)
+			So(job.FinishedAt, ShouldNotBeNil)
+
+			job = waitForStatus(repo, failed.ID, models.JSFailed)
+			So(job.Status, ShouldEqual, models.JSFailed)
+			So(*job.Error, ShouldEqual, "no release found")
+		})
+
+		Convey("Enqueueing a request with an active job returns the existing job", func() {
+			first, err := q.Enqueue(3)
+			So(err, ShouldBeNil)
+
+			second, err := q.Enqueue(3)
+			So(err, ShouldBeNil)
+			So(second.ID, ShouldEqual, first.ID)
+		})
+
+		Convey("Jobs interrupted by a shutdown are resumed on start", func() {
+			job, err := repo.CreateJob(&models.DownloadJob{RequestID: 4})
+			So(err, ShouldBeNil)
+
+			claimed, err := repo.ClaimNextJob()
+			So(err, ShouldBeNil)
+			So(claimed.ID, ShouldEqual, job.ID)
+			So(claimed.Status, ShouldEqual, models.JSRunning)
+
+			So(q.Start(context.Background()), ShouldBeNil)
+			defer q.Stop()
+
+			resumed := waitForStatus(repo, job.ID, models.JSSucceeded)
+			So(resumed.Status, ShouldEqual, models.JSSucceeded)
+
+			mu.Lock()
+			defer mu.Unlock()
+			So(handled, ShouldContain, uint(4))
+		})
+
+		Convey("Retries wait for their backoff before running", func() {
+			job, err := q.Enqueue(7)
+			So(err, ShouldBeNil)
+
+			retry, err := q.Retry(job, time.Hour)
+			So(err, ShouldBeNil)
+			So(retry.Attempt, ShouldEqual, 2)
+			So(retry.RunAfter, ShouldNotBeNil)
+
+			claimed, err := repo.ClaimNextJob()
+			So(err, ShouldBeNil)
+			So(claimed.ID, ShouldEqual, job.ID)
+
+			claimed, err = repo.ClaimNextJob()
+			So(err, ShouldBeNil)
+			So(claimed, ShouldBeNil)
+		})
+
+		Convey("Handlers can put a job back on the queue", func() {
+			var runs atomic.Int32
+			q := New(repo, func(ctx context.Context, job *models.DownloadJob) error {
+				if runs.Add(1) == 1 {
+					return RequeueAfter(0)
+				}
+				return nil
+			}, 1)
+			q.PollInterval = 10 * time.Millisecond
+			So(q.Start(context.Background()), ShouldBeNil)
+			defer q.Stop()
+
+			job, _ := q.Enqueue(8)
+			finished := waitForStatus(repo, job.ID, models.JSSucceeded)
+			So(finished.Status, ShouldEqual, models.JSSucceeded)
+			So(runs.Load(), ShouldEqual, 2)
+		})
+
+		Convey("A panicking handler fails the job without stopping the worker", func() {
+			q := New(repo, func(ctx context.Context, job *models.DownloadJob) error {
+				if job.RequestID == 5 {
+					panic("boom")
+				}
+				return nil
+			}, 1)
+			So(q.Start(context.Background()), ShouldBeNil)
+			defer q.Stop()
+
+			panicked, _ := q.Enqueue(5)
+			next, _ := q.Enqueue(6)
+
+			So(waitForStatus(repo, panicked.ID, models.JSFailed).Status, ShouldEqual, models.JSFailed)
+			So(waitForStatus(repo, next.ID, models.JSSucceeded).Status, ShouldEqual, models.JSSucceeded)
+		})
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	Convey("Subject: Exponential retry backoff", t, func() {
+		base := 5 * time.Minute
+		max := time.Hour
+
+		So(Backoff(0, base, max), ShouldEqual, 5*time.Minute)
+		So(Backoff(1, base, max), ShouldEqual, 5*time.Minute)
+		So(Backoff(2, base, max), ShouldEqual, 10*time.Minute)
+		So(Backoff(3, base, max), ShouldEqual, 20*time.Minute)
+		So(Backoff(4, base, max), ShouldEqual, 40*time.Minute)
+		So(Backoff(5, base, max), ShouldEqual, time.Hour)
+		So(Backoff(50, base, max), ShouldEqual, time.Hour)
+	})
+}
@@ -0,0 +1,317 @@
+// Package readarr sends approved requests to Readarr, which finds and
+// downloads the book itself, and follows the book through Readarr's queue and
+// history until it's imported.
+package readarr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client talks to the Readarr API (v1).
+type Client struct {
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the Readarr instance at baseURL.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is a non-success response from Readarr.
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("readarr returned status code %d", e.StatusCode)
+	}
+	return fmt.Sprintf("readarr returned status code %d: %s", e.StatusCode, e.Message)
+}
+
+// Book is a book resource. Books from a lookup that aren't in Readarr yet have
+// an ID of 0.
+type Book struct {
+	ID            int         `json:"id"`
+	Title         string      `json:"title"`
+	ForeignBookID string      `json:"foreignBookId"`
+	AuthorID      int         `json:"authorId"`
+	Monitored     bool        `json:"monitored"`
+	ReleaseDate   string      `json:"releaseDate"`
+	Author        *Author     `json:"author"`
+	Editions      []Edition   `json:"editions"`
+	Statistics    *Statistics `json:"statistics"`
+
+	// raw keeps every field of a looked up book, which Readarr expects back
+	// when the book is added.
+	raw json.RawMessage
+}
+
+func (b *Book) UnmarshalJSON(data []byte) error {
+	type book Book
+	if err := json.Unmarshal(data, (*book)(b)); err != nil {
+		return err
+	}
+	b.raw = append(json.RawMessage(nil), data...)
+	return nil
+}
+
+// Year returns the year the book was released, if known.
+func (b *Book) Year() string {
+	if len(b.ReleaseDate) < 4 {
+		return ""
+	}
+	return b.ReleaseDate[:4]
+}
+
+// AuthorName returns the name of the book's author, if known.
+func (b *Book) AuthorName() string {
+	if b.Author == nil {
+		return ""
+	}
+	return b.Author.AuthorName
+}
+
+// Author is the author embedded in a book resource.
+type Author struct {
+	ID         int    `json:"id"`
+	AuthorName string `json:"authorName"`
+}
+
+// Edition is one edition of a book.
+type Edition struct {
+	Title  string `json:"title"`
+	ISBN13 string `json:"isbn13"`
+	Format string `json:"format"`
+}
+
+// Statistics counts a book's files on disk.
+type Statistics struct {
+	BookFileCount int `json:"bookFileCount"`
+}
+
+// QueueItem is a download Readarr is tracking.
+type QueueItem struct {
+	BookID                int             `json:"bookId"`
+	Title                 string          `json:"title"`
+	Status                string          `json:"status"`
+	TrackedDownloadStatus string          `json:"trackedDownloadStatus"`
+	TrackedDownloadState  string          `json:"trackedDownloadState"`
+	Size                  float64         `json:"size"`
+	SizeLeft              float64         `json:"sizeleft"`
+	ErrorMessage          string          `json:"errorMessage"`
+	StatusMessages        []StatusMessage `json:"statusMessages"`
+}
+
+// StatusMessage is a warning or error Readarr attached to a queue item.
+type StatusMessage struct {
+	Title    string   `json:"title"`
+	Messages []string `json:"messages"`
+}
+
+// HistoryRecord is an event in a book's history, such as a grab or failed download.
+type HistoryRecord struct {
+	BookID    int               `json:"bookId"`
+	EventType string            `json:"eventType"`
+	Date      time.Time         `json:"date"`
+	Data      map[string]string `json:"data"`
+}
+
+// RootFolder is a library folder books are added to.
+type RootFolder struct {
+	ID   int    `json:"id"`
+	Path string `json:"path"`
+}
+
+// AddOptions set where and how a new book's author is added.
+type AddOptions struct {
+	QualityProfileID  int
+	MetadataProfileID int
+	RootFolderPath    string
+}
+
+// LookupBook searches Readarr's metadata for books. The term can be prefixed
+// with isbn:, asin: or goodreads: to look up a specific book.
+func (c *Client) LookupBook(ctx context.Context, term string) ([]Book, error) {
+	var books []Book
+	err := c.do(ctx, http.MethodGet, "/api/v1/book/lookup", url.Values{"term": {term}}, nil, &books)
+	return books, err
+}
+
+// GetBook returns the book with the Readarr ID.
+func (c *Client) GetBook(ctx context.Context, id int) (*Book, error) {
+	var book Book
+	if err := c.do(ctx, http.MethodGet, "/api/v1/book/"+strconv.Itoa(id), nil, nil, &book); err != nil {
+		return nil, err
+	}
+	return &book, nil
+}
+
+// AddBook adds a looked up book as monitored and asks Readarr to search for it.
+// The author is added too when Readarr doesn't have them, without monitoring
+// their other books.
+func (c *Client) AddBook(ctx context.Context, book Book, options AddOptions) (*Book, error) {
+	body := make(map[string]any)
+	if len(book.raw) > 0 {
+		if err := json.Unmarshal(book.raw, &body); err != nil {
+			return nil, err
+		}
+	} else {
+		body["title"] = book.Title
+		body["foreignBookId"] = book.ForeignBookID
+		body["author"] = book.Author
+	}
+
+	author, _ := body["author"].(map[string]any)
+	if author == nil {
+		author = make(map[string]any)
+		if book.Author != nil {
+			author["authorName"] = book.Author.AuthorName
+		}
+	}
+	author["qualityProfileId"] = options.QualityProfileID
+	author["metadataProfileId"] = options.MetadataProfileID
+	author["rootFolderPath"] = options.RootFolderPath
+	author["monitored"] = true
+	author["monitorNewItems"] = "none"
+	author["addOptions"] = map[string]any{"monitor": "none", "searchForMissingBooks": false}
+
+	body["author"] = author
+	body["monitored"] = true
+	body["addOptions"] = map[string]any{"searchForNewBook": true}
+
+	var added Book
+	if err := c.do(ctx, http.MethodPost, "/api/v1/book", nil, body, &added); err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+// MonitorBooks sets whether Readarr monitors the books.
+func (c *Client) MonitorBooks(ctx context.Context, monitored bool, ids ...int) error {
+	body := map[string]any{"bookIds": ids, "monitored": monitored}
+	return c.do(ctx, http.MethodPut, "/api/v1/book/monitor", nil, body, nil)
+}
+
+// SearchBooks starts a search for releases of the books.
+func (c *Client) SearchBooks(ctx context.Context, ids ...int) error {
+	body := map[string]any{"name": "BookSearch", "bookIds": ids}
+	return c.do(ctx, http.MethodPost, "/api/v1/command", nil, body, nil)
+}
+
+// Queue returns the book's downloads in Readarr's queue.
+func (c *Client) Queue(ctx context.Context, bookID int) ([]QueueItem, error) {
+	var items []QueueItem
+	err := c.do(ctx, http.MethodGet, "/api/v1/queue/details", url.Values{"bookIds": {strconv.Itoa(bookID)}}, nil, &items)
+	return items, err
+}
+
+// History returns the book's most recent history, newest first.
+func (c *Client) History(ctx context.Context, bookID int) ([]HistoryRecord, error) {
+	params := url.Values{
+		"bookId":        {strconv.Itoa(bookID)},
+		"page":          {"1"},
+		"pageSize":      {"20"},
+		"sortKey":       {"date"},
+		"sortDirection": {"descending"},
+	}
+
+	var page struct {
+		Records []HistoryRecord `json:"records"`
+	}
+	err := c.do(ctx, http.MethodGet, "/api/v1/history", params, nil, &page)
+	return page.Records, err
+}
+
+// RootFolders returns the configured library folders.
+func (c *Client) RootFolders(ctx context.Context) ([]RootFolder, error) {
+	var folders []RootFolder
+	err := c.do(ctx, http.MethodGet, "/api/v1/rootfolder", nil, nil, &folders)
+	return folders, err
+}
+
+func (c *Client) do(ctx context.Context, method, path string, params url.Values, body any, result any) error {
+	reqURL := c.BaseURL + path
+	if len(params) > 0 {
+		reqURL += "?" + params.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, reqURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Api-Key", c.APIKey)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &APIError{StatusCode: resp.StatusCode, Message: errorMessage(resp.Body)}
+	}
+
+	if result == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(result); err != nil {
+		return fmt.Errorf("decode readarr response: %w", err)
+	}
+	return nil
+}
+
+// errorMessage pulls the message out of a Readarr error body, which is either
+// an object or a list of validation failures.
+func errorMessage(body io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(body, 64<<10))
+
+	var single struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &single) == nil && single.Message != "" {
+		return single.Message
+	}
+
+	var failures []struct {
+		ErrorMessage string `json:"errorMessage"`
+	}
+	if json.Unmarshal(data, &failures) == nil {
+		messages := make([]string, 0, len(failures))
+		for _, failure := range failures {
+			messages = append(messages, failure.ErrorMessage)
+		}
+		return strings.Join(messages, "; ")
+	}
+
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,267 @@
+package readarr
+
+import (
+	"api/lib/download"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+func init() {
+	download.Register("READARR", func() (download.Downloader, error) {
+		baseURL := config.DefaultString("download::readarrurl", "")
+		if baseURL == "" {
+			return nil, errors.New("download::readarrurl is not configured")
+		}
+
+		downloader := NewDownloader(NewClient(baseURL, config.DefaultString("download::readarrapikey", "")))
+		downloader.Scorer = download.ConfiguredScorer()
+		downloader.Options = AddOptions{
+			QualityProfileID:  config.DefaultInt("download::readarrqualityprofile", 1),
+			MetadataProfileID: config.DefaultInt("download::readarrmetadataprofile", 1),
+			RootFolderPath:    config.DefaultString("download::readarrrootfolder", ""),
+		}
+		return downloader, nil
+	})
+}
+
+// Downloader adds requested books to Readarr as monitored and lets Readarr
+// search for and download them. It tracks the book until Readarr imports it.
+type Downloader struct {
+	Readarr *Client
+	Scorer  *download.Scorer
+	// Options are used when a book's author isn't in Readarr yet. The first
+	// root folder is used when RootFolderPath is empty.
+	Options AddOptions
+
+	mu    sync.Mutex
+	books map[string]Book
+}
+
+// NewDownloader returns a downloader adding books to the Readarr instance.
+func NewDownloader(readarr *Client) *Downloader {
+	return &Downloader{
+		Readarr: readarr,
+		Scorer:  download.NewScorer(),
+		Options: AddOptions{QualityProfileID: 1, MetadataProfileID: 1},
+		books:   make(map[string]Book),
+	}
+}
+
+func (d *Downloader) Name() string {
+	return "readarr"
+}
+
+// Query looks the book up by ISBN-13, ISBN-10 or Goodreads ID, falling back to
+// the title and author.
+func (d *Downloader) Query(request models.BookRequest) string {
+	switch {
+	case request.ISBN13 != nil && *request.ISBN13 != "":
+		return "isbn:" + *request.ISBN13
+	case request.ISBN10 != nil && *request.ISBN10 != "":
+		return "isbn:" + *request.ISBN10
+	case request.GoodreadsID != nil && *request.GoodreadsID != "":
+		return "goodreads:" + *request.GoodreadsID
+	}
+	return textQuery(request)
+}
+
+// Search looks the book up in Readarr's metadata. Identifier lookups that find
+// nothing are retried with the title and author.
+func (d *Downloader) Search(ctx context.Context, request models.BookRequest) ([]download.Release, error) {
+	query := d.Query(request)
+	logs.Info("Looking up book in Readarr; term=%s...", query)
+
+	books, err := d.Readarr.LookupBook(ctx, query)
+	if err == nil && len(books) == 0 && query != textQuery(request) {
+		logs.Info("No Readarr results; retrying with title and author...")
+		books, err = d.Readarr.LookupBook(ctx, textQuery(request))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	releases := make([]download.Release, 0, len(books))
+	for _, book := range books {
+		if book.ForeignBookID == "" {
+			continue
+		}
+		d.books[book.ForeignBookID] = book
+		releases = append(releases, download.Release{
+			ID:     book.ForeignBookID,
+			Title:  book.Title,
+			Author: book.AuthorName(),
+			Year:   book.Year(),
+		})
+	}
+	return releases, nil
+}
+
+// Rank returns the looked up books by how well their title and author match
+// the request, best first. Every candidate's score is logged.
+func (d *Downloader) Rank(request models.BookRequest, releases []download.Release) ([]download.Release, error) {
+	return d.Scorer.Rank(d.Name(), request, releases)
+}
+
+// Download monitors the book in Readarr, adding it if needed, and starts a
+// search. The Handoff's ID is Readarr's book ID.
+func (d *Downloader) Download(ctx context.Context, release download.Release) (*download.Handoff, error) {
+	d.mu.Lock()
+	book, ok := d.books[release.ID]
+	d.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("book %s wasn't found by a Readarr lookup", release.ID)
+	}
+
+	if book.ID == 0 {
+		options, err := d.addOptions(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		added, err := d.Readarr.AddBook(ctx, book, options)
+		if err != nil {
+			return nil, err
+		}
+		logs.Info("Added %s to Readarr; id=%d", book.Title, added.ID)
+		return d.handoff(added.ID), nil
+	}
+
+	// Readarr already has the book; make sure it's wanted and search again
+	if err := d.Readarr.MonitorBooks(ctx, true, book.ID); err != nil {
+		return nil, err
+	}
+	if err := d.Readarr.SearchBooks(ctx, book.ID); err != nil {
+		return nil, err
+	}
+	logs.Info("Started Readarr search for %s; id=%d", book.Title, book.ID)
+	return d.handoff(book.ID), nil
+}
+
+// Status reports the book's progress from Readarr. Books with a file are
+// complete; otherwise the queue, then the history, says where the book is.
+func (d *Downloader) Status(ctx context.Context, id string) (*download.Status, error) {
+	bookID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, fmt.Errorf("invalid readarr book id %q", id)
+	}
+
+	book, err := d.Readarr.GetBook(ctx, bookID)
+	if err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return &download.Status{State: download.StateFailed, Reason: "book is no longer in Readarr"}, nil
+		}
+		return nil, err
+	}
+	if book.Statistics != nil && book.Statistics.BookFileCount > 0 {
+		return &download.Status{State: download.StateCompleted, Progress: 1}, nil
+	}
+
+	items, err := d.Readarr.Queue(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		if item.BookID == bookID {
+			return queueStatus(item), nil
+		}
+	}
+
+	history, err := d.Readarr.History(ctx, bookID)
+	if err != nil {
+		return nil, err
+	}
+	return historyStatus(history), nil
+}
+
+func (d *Downloader) addOptions(ctx context.Context) (AddOptions, error) {
+	options := d.Options
+	if options.RootFolderPath != "" {
+		return options, nil
+	}
+
+	folders, err := d.Readarr.RootFolders(ctx)
+	if err != nil {
+		return options, err
+	}
+	if len(folders) == 0 {
+		return options, errors.New("readarr has no root folders")
+	}
+	options.RootFolderPath = folders[0].Path
+	return options, nil
+}
+
+func (d *Downloader) handoff(bookID int) *download.Handoff {
+	return &download.Handoff{Client: d.Name(), ID: strconv.Itoa(bookID)}
+}
+
+// queueStatus maps a Readarr queue item onto a download state.
+func queueStatus(item QueueItem) *download.Status {
+	status := &download.Status{State: download.StateDownloading}
+	if item.Size > 0 {
+		status.Progress = (item.Size - item.SizeLeft) / item.Size
+	}
+
+	switch {
+	case item.TrackedDownloadState == "failed" || item.TrackedDownloadState == "failedPending" ||
+		item.Status == "failed" || item.TrackedDownloadStatus == "error":
+		status.State = download.StateFailed
+		status.Reason = item.reason()
+	case item.TrackedDownloadState == "importPending" || item.TrackedDownloadState == "importing":
+		status.Progress = 1
+	case item.Status == "queued" || item.Status == "delay" || item.Status == "paused":
+		status.State = download.StateQueued
+	}
+
+	return status
+}
+
+// historyStatus reports a book that isn't downloading from its latest event.
+func historyStatus(history []HistoryRecord) *download.Status {
+	if len(history) == 0 {
+		// Readarr hasn't found a release yet and will keep looking
+		return &download.Status{State: download.StateQueued}
+	}
+
+	switch latest := history[0]; latest.EventType {
+	case "downloadFailed":
+		reason := latest.Data["message"]
+		if reason == "" {
+			reason = "Readarr reported the download failed"
+		}
+		return &download.Status{State: download.StateFailed, Reason: reason}
+	case "grabbed":
+		// Grabbed but not in the queue yet
+		return &download.Status{State: download.StateDownloading}
+	default:
+		return &download.Status{State: download.StateQueued}
+	}
+}
+
+func (item QueueItem) reason() string {
+	if item.ErrorMessage != "" {
+		return item.ErrorMessage
+	}
+	for _, message := range item.StatusMessages {
+		if len(message.Messages) > 0 {
+			return strings.Join(message.Messages, "; ")
+		}
+	}
+	return "Readarr reported the download failed"
+}
+
+func textQuery(request models.BookRequest) string {
+	return strings.TrimSpace(request.Title + " " + request.Author)
+}
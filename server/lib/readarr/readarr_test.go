@@ -0,0 +1,218 @@
+package readarr
+
+import (
+	"api/lib/download"
+	"api/models"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testAPIKey = "secret"
+
+// fakeReadarr is a stub of the Readarr API. Book 1 (Dune) is already in
+// Readarr; Project Hail Mary is only found by lookup and gets ID 2 when added.
+type fakeReadarr struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	added    map[string]any
+	monitor  map[string]any
+	commands []map[string]any
+}
+
+func newFakeReadarr() *fakeReadarr {
+	fake := &fakeReadarr{}
+	fake.Server = httptest.NewServer(http.HandlerFunc(fake.serve))
+	return fake
+}
+
+func (f *fakeReadarr) serve(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Api-Key") != testAPIKey {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	query := r.URL.Query()
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/book/lookup":
+		switch query.Get("term") {
+		case "isbn:9780441013593":
+			w.Write([]byte(`[{"id": 1, "title": "Dune", "foreignBookId": "234225", "authorId": 1, "author": {"id": 1, "authorName": "Frank Herbert"}}]`))
+		case "goodreads:54493401", "Project Hail Mary Andy Weir":
+			w.Write([]byte(`[{"id": 0, "title": "Project Hail Mary", "foreignBookId": "54493401", "releaseDate": "2021-05-04T00:00:00Z",
+				"anyEditionOk": true, "author": {"id": 0, "authorName": "Andy Weir", "foreignAuthorId": "6540057"}}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	case "GET /api/v1/rootfolder":
+		w.Write([]byte(`[{"id": 1, "path": "/books/"}]`))
+	case "POST /api/v1/book":
+		json.NewDecoder(r.Body).Decode(&f.added)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 2, "title": "Project Hail Mary", "foreignBookId": "54493401", "monitored": true}`))
+	case "PUT /api/v1/book/monitor":
+		json.NewDecoder(r.Body).Decode(&f.monitor)
+		w.WriteHeader(http.StatusAccepted)
+	case "POST /api/v1/command":
+		var command map[string]any
+		json.NewDecoder(r.Body).Decode(&command)
+		f.commands = append(f.commands, command)
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte(`{"id": 10, "name": "BookSearch"}`))
+	case "GET /api/v1/book/1":
+		w.Write([]byte(`{"id": 1, "title": "Dune", "statistics": {"bookFileCount": 1}}`))
+	case "GET /api/v1/book/2", "GET /api/v1/book/3", "GET /api/v1/book/4", "GET /api/v1/book/5":
+		w.Write([]byte(`{"id": 2, "title": "Project Hail Mary", "statistics": {"bookFileCount": 0}}`))
+	case "GET /api/v1/queue/details":
+		switch query.Get("bookIds") {
+		case "2":
+			w.Write([]byte(`[{"bookId": 2, "status": "downloading", "trackedDownloadStatus": "ok", "trackedDownloadState": "downloading", "size": 200, "sizeleft": 50}]`))
+		case "3":
+			w.Write([]byte(`[{"bookId": 3, "status": "completed", "trackedDownloadStatus": "error", "trackedDownloadState": "importPending",
+				"statusMessages": [{"title": "Project Hail Mary", "messages": ["No files found are eligible for import"]}]}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	case "GET /api/v1/history":
+		switch query.Get("bookId") {
+		case "4":
+			w.Write([]byte(`{"records": [{"bookId": 4, "eventType": "downloadFailed", "data": {"message": "Download client reported an error"}},
+				{"bookId": 4, "eventType": "grabbed", "data": {}}]}`))
+		default:
+			w.Write([]byte(`{"records": []}`))
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"message": "NotFound"}`))
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestDownloader(t *testing.T) {
+	fake := newFakeReadarr()
+	defer fake.Close()
+
+	ctx := context.Background()
+
+	Convey("Subject: Readarr downloader", t, func() {
+		downloader := NewDownloader(NewClient(fake.URL, testAPIKey))
+
+		Convey("Books are looked up by ISBN before Goodreads ID and title", func() {
+			request := models.BookRequest{Title: "Dune", Author: "Frank Herbert", ISBN13: strPtr("9780441013593"), GoodreadsID: strPtr("234225")}
+			So(downloader.Query(request), ShouldEqual, "isbn:9780441013593")
+
+			request.ISBN13 = nil
+			So(downloader.Query(request), ShouldEqual, "goodreads:234225")
+
+			request.GoodreadsID = nil
+			So(downloader.Query(request), ShouldEqual, "Dune Frank Herbert")
+		})
+
+		Convey("A book Readarr doesn't have is added as monitored and searched", func() {
+			request := models.BookRequest{Title: "Project Hail Mary", Author: "Andy Weir", GoodreadsID: strPtr("54493401")}
+
+			attempt, err := download.Run(ctx, request, []download.Downloader{downloader}, nil)
+			So(err, ShouldBeNil)
+			So(attempt.Release.Year, ShouldEqual, "2021")
+			So(*attempt.Handoff, ShouldResemble, download.Handoff{Client: "readarr", ID: "2"})
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			So(fake.added["monitored"], ShouldEqual, true)
+			So(fake.added["anyEditionOk"], ShouldEqual, true)
+			So(fake.added["addOptions"], ShouldResemble, map[string]any{"searchForNewBook": true})
+
+			author := fake.added["author"].(map[string]any)
+			So(author["foreignAuthorId"], ShouldEqual, "6540057")
+			So(author["rootFolderPath"], ShouldEqual, "/books/")
+			So(author["qualityProfileId"], ShouldEqual, 1)
+			So(author["monitorNewItems"], ShouldEqual, "none")
+		})
+
+		Convey("A book Readarr already has is monitored and searched again", func() {
+			request := models.BookRequest{Title: "Dune", Author: "Frank Herbert", ISBN13: strPtr("9780441013593")}
+
+			attempt, err := download.Run(ctx, request, []download.Downloader{downloader}, nil)
+			So(err, ShouldBeNil)
+			So(attempt.Handoff.ID, ShouldEqual, "1")
+
+			fake.mu.Lock()
+			defer fake.mu.Unlock()
+			So(fake.monitor, ShouldResemble, map[string]any{"bookIds": []any{1.0}, "monitored": true})
+			So(fake.commands[len(fake.commands)-1], ShouldResemble, map[string]any{"name": "BookSearch", "bookIds": []any{1.0}})
+		})
+
+		Convey("Identifier lookups that find nothing fall back to the title and author", func() {
+			request := models.BookRequest{Title: "Project Hail Mary", Author: "Andy Weir", ISBN13: strPtr("9780593135204")}
+
+			releases, err := downloader.Search(ctx, request)
+			So(err, ShouldBeNil)
+			So(releases, ShouldHaveLength, 1)
+			So(releases[0].ID, ShouldEqual, "54493401")
+		})
+
+		Convey("Books are ranked by their title and author", func() {
+			request := models.BookRequest{Title: "Dune", Author: "Frank Herbert"}
+
+			ranked, err := downloader.Rank(request, []download.Release{
+				{ID: "companion", Title: "The Dune Encyclopedia", Author: "Willis E. McNelly"},
+				{ID: "234225", Title: "Dune", Author: "Frank Herbert"},
+			})
+			So(err, ShouldBeNil)
+			So(ranked[0].ID, ShouldEqual, "234225")
+		})
+
+		Convey("Books with another title aren't picked", func() {
+			request := models.BookRequest{Title: "The Martian", Author: "Andy Weir"}
+
+			_, err := downloader.Rank(request, []download.Release{{ID: "54493401", Title: "Project Hail Mary"}})
+			So(err, ShouldEqual, download.ErrNoSuitableRelease)
+		})
+
+		Convey("Status follows the book through Readarr", func() {
+			status, err := downloader.Status(ctx, "1")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateCompleted)
+
+			status, err = downloader.Status(ctx, "2")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateDownloading)
+			So(status.Progress, ShouldEqual, 0.75)
+
+			status, err = downloader.Status(ctx, "3")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+			So(status.Reason, ShouldEqual, "No files found are eligible for import")
+
+			status, err = downloader.Status(ctx, "4")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+			So(status.Reason, ShouldEqual, "Download client reported an error")
+
+			status, err = downloader.Status(ctx, "5")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateQueued)
+
+			status, err = downloader.Status(ctx, "99")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+		})
+
+		Convey("A wrong API key is reported", func() {
+			_, err := NewClient(fake.URL, "wrong").LookupBook(ctx, "Dune")
+			So(err, ShouldHaveSameTypeAs, &APIError{})
+		})
+	})
+}
@@ -0,0 +1,220 @@
+// Package sabnzbd adds NZB releases to SABnzbd through its API and tracks them
+// until they finish downloading.
+package sabnzbd
+
+import (
+	"api/lib/download"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+func init() {
+	download.RegisterClient("SABNZBD", func() (download.Client, error) {
+		baseURL := config.DefaultString("download::sabnzbdurl", "")
+		if baseURL == "" {
+			return nil, errors.New("download::sabnzbdurl is not configured")
+		}
+
+		client := NewClient(baseURL, config.DefaultString("download::sabnzbdapikey", ""))
+		client.Category = config.DefaultString("download::sabnzbdcategory", "")
+		return client, nil
+	})
+}
+
+// Client talks to the SABnzbd API.
+type Client struct {
+	BaseURL string
+	APIKey  string
+	// Category is applied to every added NZB.
+	Category   string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the SABnzbd instance at baseURL.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is an error reported in a SABnzbd API response.
+type APIError struct {
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return "sabnzbd: " + e.Message
+}
+
+type response struct {
+	Status  *bool    `json:"status"`
+	Error   string   `json:"error"`
+	NzoIDs  []string `json:"nzo_ids"`
+	Queue   *queue   `json:"queue"`
+	History *history `json:"history"`
+}
+
+type queue struct {
+	Slots []QueueSlot `json:"slots"`
+}
+
+type history struct {
+	Slots []HistorySlot `json:"slots"`
+}
+
+// QueueSlot is a job still in the download queue.
+type QueueSlot struct {
+	NzoID      string `json:"nzo_id"`
+	Filename   string `json:"filename"`
+	Status     string `json:"status"`
+	Percentage string `json:"percentage"`
+}
+
+// HistorySlot is a job that finished downloading, successfully or not.
+type HistorySlot struct {
+	NzoID       string `json:"nzo_id"`
+	Name        string `json:"name"`
+	Status      string `json:"status"`
+	FailMessage string `json:"fail_message"`
+	Storage     string `json:"storage"`
+}
+
+func (c *Client) Name() string {
+	return "sabnzbd"
+}
+
+// Supports accepts NZBs only.
+func (c *Client) Supports(protocol download.Protocol) bool {
+	return protocol == download.ProtocolUsenet
+}
+
+// Add asks SABnzbd to fetch the release's NZB and returns its nzo_id.
+func (c *Client) Add(ctx context.Context, release download.Release) (string, error) {
+	if release.URL == "" {
+		return "", errors.New("release has no download URL")
+	}
+
+	params := url.Values{}
+	params.Set("mode", "addurl")
+	params.Set("name", release.URL)
+	params.Set("nzbname", release.Title)
+	if c.Category != "" {
+		params.Set("cat", c.Category)
+	}
+
+	resp, err := c.call(ctx, params)
+	if err != nil {
+		return "", err
+	}
+
+	if len(resp.NzoIDs) == 0 {
+		return "", &APIError{Message: "no job was created for the NZB"}
+	}
+
+	return resp.NzoIDs[0], nil
+}
+
+// Status checks the queue for the job, then its history once it has left the queue.
+func (c *Client) Status(ctx context.Context, id string) (*download.Status, error) {
+	resp, err := c.call(ctx, url.Values{"mode": {"queue"}, "nzo_ids": {id}})
+	if err != nil {
+		return nil, err
+	}
+	if resp.Queue != nil {
+		for _, slot := range resp.Queue.Slots {
+			if slot.NzoID == id {
+				return queueStatus(slot), nil
+			}
+		}
+	}
+
+	resp, err = c.call(ctx, url.Values{"mode": {"history"}, "nzo_ids": {id}})
+	if err != nil {
+		return nil, err
+	}
+	if resp.History != nil {
+		for _, slot := range resp.History.Slots {
+			if slot.NzoID == id {
+				return historyStatus(slot), nil
+			}
+		}
+	}
+
+	return &download.Status{State: download.StateFailed, Reason: "job is no longer in SABnzbd"}, nil
+}
+
+func queueStatus(slot QueueSlot) *download.Status {
+	status := &download.Status{State: download.StateDownloading}
+	if percentage, err := strconv.ParseFloat(slot.Percentage, 64); err == nil {
+		status.Progress = percentage / 100
+	}
+
+	switch slot.Status {
+	case "Queued", "Paused", "Fetching", "Grabbing", "Propagating":
+		status.State = download.StateQueued
+	}
+
+	return status
+}
+
+func historyStatus(slot HistorySlot) *download.Status {
+	switch slot.Status {
+	case "Completed":
+		return &download.Status{State: download.StateCompleted, Progress: 1}
+	case "Failed":
+		reason := slot.FailMessage
+		if reason == "" {
+			reason = "SABnzbd reported the download failed"
+		}
+		return &download.Status{State: download.StateFailed, Reason: reason}
+	default:
+		// Verifying, Repairing, Extracting, Moving, Running and other post-processing
+		return &download.Status{State: download.StateDownloading, Progress: 1}
+	}
+}
+
+func (c *Client) call(ctx context.Context, params url.Values) (*response, error) {
+	params.Set("output", "json")
+	params.Set("apikey", c.APIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("sabnzbd returned status code %d", resp.StatusCode)
+	}
+
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode sabnzbd response: %w", err)
+	}
+
+	if result.Error != "" || (result.Status != nil && !*result.Status) {
+		message := result.Error
+		if message == "" {
+			message = "request failed"
+		}
+		return nil, &APIError{Message: message}
+	}
+
+	return &result, nil
+}
@@ -0,0 +1,102 @@
+package sabnzbd
+
+import (
+	"api/lib/download"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testAPIKey = "secret"
+
+func newFakeSABnzbd() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("apikey") != testAPIKey {
+			w.Write([]byte(`{"status": false, "error": "API Key Incorrect"}`))
+			return
+		}
+
+		switch query.Get("mode") {
+		case "addurl":
+			w.Write([]byte(`{"status": true, "nzo_ids": ["SABnzbd_nzo_downloading"]}`))
+		case "queue":
+			if query.Get("nzo_ids") == "SABnzbd_nzo_downloading" {
+				w.Write([]byte(`{"queue": {"slots": [{"nzo_id": "SABnzbd_nzo_downloading", "status": "Downloading", "percentage": "42"}]}}`))
+				return
+			}
+			w.Write([]byte(`{"queue": {"slots": []}}`))
+		case "history":
+			switch id := query.Get("nzo_ids"); id {
+			case "SABnzbd_nzo_done":
+				fmt.Fprintf(w, `{"history": {"slots": [{"nzo_id": "%s", "status": "Completed", "storage": "/downloads/Dune"}]}}`, id)
+			case "SABnzbd_nzo_failed":
+				fmt.Fprintf(w, `{"history": {"slots": [{"nzo_id": "%s", "status": "Failed", "fail_message": "Out of retention"}]}}`, id)
+			case "SABnzbd_nzo_unpacking":
+				fmt.Fprintf(w, `{"history": {"slots": [{"nzo_id": "%s", "status": "Extracting"}]}}`, id)
+			default:
+				w.Write([]byte(`{"history": {"slots": []}}`))
+			}
+		default:
+			w.Write([]byte(`{"status": false, "error": "not implemented"}`))
+		}
+	}))
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeSABnzbd()
+	defer server.Close()
+
+	release := download.Release{Title: "Dune", URL: "https://indexer/nzb/1", Protocol: download.ProtocolUsenet}
+
+	Convey("Subject: SABnzbd download client", t, func() {
+		client := NewClient(server.URL, testAPIKey)
+
+		So(client.Supports(download.ProtocolUsenet), ShouldBeTrue)
+		So(client.Supports(download.ProtocolTorrent), ShouldBeFalse)
+
+		Convey("Adding an NZB returns its nzo_id", func() {
+			id, err := client.Add(context.Background(), release)
+			So(err, ShouldBeNil)
+			So(id, ShouldEqual, "SABnzbd_nzo_downloading")
+		})
+
+		Convey("Queued jobs report their progress", func() {
+			status, err := client.Status(context.Background(), "SABnzbd_nzo_downloading")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateDownloading)
+			So(status.Progress, ShouldEqual, 0.42)
+		})
+
+		Convey("Finished jobs are read from the history", func() {
+			status, err := client.Status(context.Background(), "SABnzbd_nzo_done")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateCompleted)
+
+			status, err = client.Status(context.Background(), "SABnzbd_nzo_failed")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+			So(status.Reason, ShouldEqual, "Out of retention")
+
+			status, err = client.Status(context.Background(), "SABnzbd_nzo_unpacking")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateDownloading)
+
+			status, err = client.Status(context.Background(), "SABnzbd_nzo_missing")
+			So(err, ShouldBeNil)
+			So(status.State, ShouldEqual, download.StateFailed)
+		})
+
+		Convey("API errors are returned", func() {
+			_, err := NewClient(server.URL, "wrong").Add(context.Background(), release)
+			var apiErr *APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.Message, ShouldEqual, "API Key Incorrect")
+		})
+	})
+}
@@ -0,0 +1,178 @@
+// Package torznab searches Torznab and Newznab indexers, such as Prowlarr and
+// Jackett, and hands the best release to a download client.
+package torznab
+
+import (
+	"api/lib/download"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Client queries a single Torznab or Newznab API endpoint.
+type Client struct {
+	// BaseURL is the full API endpoint, e.g. http://prowlarr:9696/1/api.
+	BaseURL    string
+	APIKey     string
+	HTTPClient *http.Client
+}
+
+// NewClient returns a client for the API endpoint at baseURL.
+func NewClient(baseURL, apiKey string) *Client {
+	return &Client{
+		BaseURL:    strings.TrimRight(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// APIError is an error document returned by the indexer.
+type APIError struct {
+	Code        int    `xml:"code,attr"`
+	Description string `xml:"description,attr"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("torznab error %d: %s", e.Code, e.Description)
+}
+
+type feed struct {
+	XMLName xml.Name
+	Code    int    `xml:"code,attr"`
+	Desc    string `xml:"description,attr"`
+	Items   []Item `xml:"channel>item"`
+}
+
+// Item is a single search result.
+type Item struct {
+	Title     string    `xml:"title"`
+	GUID      string    `xml:"guid"`
+	Link      string    `xml:"link"`
+	Size      int64     `xml:"size"`
+	PubDate   string    `xml:"pubDate"`
+	Indexer   string    `xml:"prowlarrindexer"`
+	Jackett   string    `xml:"jackettindexer"`
+	Enclosure Enclosure `xml:"enclosure"`
+	Attrs     []Attr    `xml:"attr"`
+}
+
+type Enclosure struct {
+	URL    string `xml:"url,attr"`
+	Length int64  `xml:"length,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+// Attr is a torznab:attr or newznab:attr element.
+type Attr struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+// Attr returns the first value of the named attribute.
+func (i Item) Attr(name string) string {
+	for _, attr := range i.Attrs {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// Categories returns every category the item is listed under.
+func (i Item) Categories() []int {
+	var categories []int
+	for _, attr := range i.Attrs {
+		if !strings.EqualFold(attr.Name, "category") {
+			continue
+		}
+		if category, err := strconv.Atoi(attr.Value); err == nil {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// DownloadURL returns the link used to fetch the release.
+func (i Item) DownloadURL() string {
+	if i.Enclosure.URL != "" {
+		return i.Enclosure.URL
+	}
+	if magnet := i.Attr("magneturl"); magnet != "" && i.Link == "" {
+		return magnet
+	}
+	return i.Link
+}
+
+// Bytes returns the release size from whichever field the indexer filled.
+func (i Item) Bytes() int64 {
+	if i.Size > 0 {
+		return i.Size
+	}
+	if size, err := download.ParseSize(i.Attr("size")); err == nil && size > 0 {
+		return size
+	}
+	return i.Enclosure.Length
+}
+
+// Seeders returns the seeder count, or -1 if the indexer didn't report one.
+func (i Item) Seeders() int {
+	seeders, err := strconv.Atoi(i.Attr("seeders"))
+	if err != nil {
+		return -1
+	}
+	return seeders
+}
+
+// IsUsenet reports whether the item is an NZB rather than a torrent.
+func (i Item) IsUsenet() bool {
+	return strings.Contains(i.Enclosure.Type, "nzb") ||
+		strings.HasSuffix(strings.ToLower(i.Link), ".nzb")
+}
+
+// Search runs a free text search limited to the given categories.
+func (c *Client) Search(ctx context.Context, query string, categories []int) ([]Item, error) {
+	params := url.Values{}
+	params.Set("t", "search")
+	params.Set("q", query)
+	if c.APIKey != "" {
+		params.Set("apikey", c.APIKey)
+	}
+	if len(categories) > 0 {
+		cats := make([]string, len(categories))
+		for i, category := range categories {
+			cats[i] = strconv.Itoa(category)
+		}
+		params.Set("cat", strings.Join(cats, ","))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result feed
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode torznab response: %w", err)
+	}
+
+	if result.XMLName.Local == "error" {
+		return nil, &APIError{Code: result.Code, Description: result.Desc}
+	}
+
+	return result.Items, nil
+}
@@ -0,0 +1,179 @@
+package torznab
+
+import (
+	"api/lib/download"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+)
+
+// Newznab categories searched by default: Books, Books/EBook and Audio/Audiobook.
+const defaultCategories = "7000,7020,3030"
+
+func init() {
+	download.Register("TORZNAB", func() (download.Downloader, error) {
+		baseURL := config.DefaultString("download::torznaburl", "")
+		if baseURL == "" {
+			return nil, errors.New("download::torznaburl is not configured")
+		}
+
+		clients, err := download.ConfiguredClients()
+		if err != nil {
+			return nil, err
+		}
+
+		categories, err := parseCategories(config.DefaultString("download::torznabcategories", defaultCategories))
+		if err != nil {
+			return nil, err
+		}
+
+		downloader := NewDownloader(NewClient(baseURL, config.DefaultString("download::torznabapikey", "")), clients...)
+		downloader.Categories = categories
+		downloader.Scorer = download.ConfiguredScorer()
+		return downloader, nil
+	})
+}
+
+// Downloader searches a Torznab or Newznab indexer and hands the best scoring
+// release to a download client.
+type Downloader struct {
+	Indexer *Client
+	// Clients receive releases; each release goes to the first that supports its protocol.
+	Clients    []download.Client
+	Categories []int
+	Scorer     *download.Scorer
+}
+
+// NewDownloader returns a downloader for the indexer that adds releases to clients.
+func NewDownloader(indexer *Client, clients ...download.Client) *Downloader {
+	categories, _ := parseCategories(defaultCategories)
+	return &Downloader{
+		Indexer:    indexer,
+		Clients:    clients,
+		Categories: categories,
+		Scorer:     download.NewScorer(),
+	}
+}
+
+func (d *Downloader) Name() string {
+	return "torznab"
+}
+
+// SupportsMedia reports whether the downloader searches categories with the
+// media type: audio categories for audiobooks, any other for ebooks.
+func (d *Downloader) SupportsMedia(mediaType models.MediaType) bool {
+	return len(d.categoriesFor(mediaType)) > 0
+}
+
+// categoriesFor narrows the searched categories to those with the media type.
+func (d *Downloader) categoriesFor(mediaType models.MediaType) []int {
+	var categories []int
+	for _, category := range d.Categories {
+		audio := category >= 3000 && category < 4000
+		if audio && mediaType.WantsAudiobook() || !audio && mediaType.WantsEbook() {
+			categories = append(categories, category)
+		}
+	}
+	return categories
+}
+
+// Query searches for the title followed by the author.
+func (d *Downloader) Query(request models.BookRequest) string {
+	return strings.TrimSpace(request.Title + " " + request.Author)
+}
+
+// Search queries the indexer with the title and author, falling back to the
+// title alone when that finds nothing.
+func (d *Downloader) Search(ctx context.Context, request models.BookRequest) ([]download.Release, error) {
+	query := d.Query(request)
+	categories := d.categoriesFor(request.MediaType)
+	logs.Info("Searching torznab indexer; query=%s, categories=%v...", query, categories)
+
+	items, err := d.Indexer.Search(ctx, query, categories)
+	if err == nil && len(items) == 0 && query != request.Title {
+		logs.Info("No torznab results; retrying with title=%s...", request.Title)
+		items, err = d.Indexer.Search(ctx, request.Title, categories)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	releases := make([]download.Release, 0, len(items))
+	for _, item := range items {
+		releases = append(releases, toRelease(item))
+	}
+	return releases, nil
+}
+
+// Rank returns the releases a configured client can download by score, best first.
+func (d *Downloader) Rank(request models.BookRequest, releases []download.Release) ([]download.Release, error) {
+	var candidates []download.Release
+	for _, release := range releases {
+		if download.ClientFor(d.Clients, release.Protocol) == nil {
+			logs.Info("No download client for %s release: %s", release.Protocol, release.Title)
+			continue
+		}
+		candidates = append(candidates, release)
+	}
+
+	return d.Scorer.Rank(d.Name(), request, candidates)
+}
+
+// Download hands the release to the download client.
+func (d *Downloader) Download(ctx context.Context, release download.Release) (*download.Handoff, error) {
+	return download.Hand(ctx, d.Clients, release)
+}
+
+func toRelease(item Item) download.Release {
+	protocol := download.ProtocolTorrent
+	if item.IsUsenet() {
+		protocol = download.ProtocolUsenet
+	}
+
+	indexer := item.Indexer
+	if indexer == "" {
+		indexer = item.Jackett
+	}
+
+	id := item.GUID
+	if id == "" {
+		id = item.DownloadURL()
+	}
+
+	bytes := item.Bytes()
+	return download.Release{
+		ID:         id,
+		Title:      item.Title,
+		Author:     item.Attr("author"),
+		Year:       item.Attr("year"),
+		Size:       download.FormatSize(bytes),
+		URL:        item.DownloadURL(),
+		Protocol:   protocol,
+		Bytes:      bytes,
+		Seeders:    item.Seeders(),
+		Categories: item.Categories(),
+		Indexer:    indexer,
+	}
+}
+
+func parseCategories(value string) ([]int, error) {
+	var categories []int
+	for _, part := range strings.Split(value, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		category, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid torznab category %q", part)
+		}
+		categories = append(categories, category)
+	}
+	return categories, nil
+}
@@ -0,0 +1,162 @@
+package torznab
+
+import (
+	"api/lib/download"
+	"api/models"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beego/beego/v2/core/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testAPIKey = "secret"
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "torznab")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := filepath.Join(dir, "test.conf")
+	if err := os.WriteFile(conf, []byte("[download]\nblockedterms=bundle,box set\n"), 0o644); err != nil {
+		panic(err)
+	}
+	if err := config.InitGlobalInstance("ini", conf); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+func newFakeIndexer() *httptest.Server {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		switch {
+		case r.URL.Path == "/download":
+			w.Write([]byte("d8:announce...torrent " + query.Get("id")))
+			return
+		case query.Get("apikey") != testAPIKey:
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><error code="100" description="Invalid API Key"/>`))
+			return
+		case query.Get("q") != "Dune Frank Herbert":
+			w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss version="2.0"><channel></channel></rss>`))
+			return
+		}
+
+		fmt.Fprintf(w, `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:torznab="http://torznab.com/schemas/2015/feed">
+<channel>
+  <item>
+    <title>Frank Herbert - Dune [EPUB]</title>
+    <guid>dune-epub</guid>
+    <prowlarrindexer id="1">Books Tracker</prowlarrindexer>
+    <size>1048576</size>
+    <enclosure url="%[1]s/download?id=dune-epub" length="1048576" type="application/x-bittorrent"/>
+    <torznab:attr name="category" value="7000"/>
+    <torznab:attr name="category" value="7020"/>
+    <torznab:attr name="seeders" value="40"/>
+  </item>
+  <item>
+    <title>Frank Herbert - Dune [EPUB] (dead)</title>
+    <guid>dune-dead</guid>
+    <size>1048576</size>
+    <enclosure url="%[1]s/download?id=dune-dead" length="1048576" type="application/x-bittorrent"/>
+    <torznab:attr name="seeders" value="0"/>
+  </item>
+  <item>
+    <title>Dune Chronicles Box Set</title>
+    <guid>dune-box</guid>
+    <size>5242880</size>
+    <enclosure url="%[1]s/download?id=dune-box" length="5242880" type="application/x-bittorrent"/>
+    <torznab:attr name="seeders" value="500"/>
+  </item>
+  <item>
+    <title>Dune</title>
+    <guid>dune-few</guid>
+    <size>1048576</size>
+    <enclosure url="%[1]s/download?id=dune-few" length="1048576" type="application/x-bittorrent"/>
+    <torznab:attr name="seeders" value="2"/>
+  </item>
+</channel>
+</rss>`, server.URL)
+	}))
+	return server
+}
+
+func TestClient(t *testing.T) {
+	server := newFakeIndexer()
+	defer server.Close()
+
+	Convey("Subject: Torznab API client", t, func() {
+		Convey("Search results are parsed with their attributes", func() {
+			items, err := NewClient(server.URL, testAPIKey).Search(context.Background(), "Dune Frank Herbert", []int{7000})
+			So(err, ShouldBeNil)
+			So(len(items), ShouldEqual, 4)
+			So(items[0].Title, ShouldEqual, "Frank Herbert - Dune [EPUB]")
+			So(items[0].Indexer, ShouldEqual, "Books Tracker")
+			So(items[0].Bytes(), ShouldEqual, 1048576)
+			So(items[0].Seeders(), ShouldEqual, 40)
+			So(items[0].Categories(), ShouldResemble, []int{7000, 7020})
+			So(items[0].IsUsenet(), ShouldBeFalse)
+		})
+
+		Convey("Error documents are returned as API errors", func() {
+			_, err := NewClient(server.URL, "wrong").Search(context.Background(), "Dune", nil)
+			var apiErr *APIError
+			So(errors.As(err, &apiErr), ShouldBeTrue)
+			So(apiErr.Code, ShouldEqual, 100)
+		})
+	})
+}
+
+func TestDownloader(t *testing.T) {
+	server := newFakeIndexer()
+	defer server.Close()
+
+	request := models.BookRequest{ID: 1, Title: "Dune", Author: "Frank Herbert"}
+
+	Convey("Subject: Torznab downloader", t, func() {
+		dir := t.TempDir()
+		downloader := NewDownloader(NewClient(server.URL, testAPIKey), download.NewBlackhole(dir))
+		downloader.Scorer.MinBytes, downloader.Scorer.MaxBytes = 100<<10, 25<<20
+		downloader.Scorer.BlockedTerms = []string{"bundle", "box set"}
+
+		Convey("The best scoring release is handed to the blackhole", func() {
+			attempt, err := download.Run(context.Background(), request, []download.Downloader{downloader}, nil)
+			So(err, ShouldBeNil)
+			So(attempt.Downloader, ShouldEqual, "torznab")
+			So(attempt.Query, ShouldEqual, "Dune Frank Herbert")
+			So(attempt.Release.ID, ShouldEqual, "dune-epub")
+
+			data, err := os.ReadFile(filepath.Join(dir, "Frank Herbert - Dune _EPUB_.torrent"))
+			So(err, ShouldBeNil)
+			So(string(data), ShouldEndWith, "dune-epub")
+		})
+
+		Convey("Categories are narrowed to the request's media type", func() {
+			So(downloader.categoriesFor(models.MTEbook), ShouldResemble, []int{7000, 7020})
+			So(downloader.categoriesFor(models.MTAudiobook), ShouldResemble, []int{3030})
+			So(downloader.categoriesFor(models.MTBoth), ShouldResemble, []int{7000, 7020, 3030})
+
+			downloader.Categories = []int{7020}
+			So(downloader.SupportsMedia(models.MTAudiobook), ShouldBeFalse)
+			So(downloader.SupportsMedia(models.MTBoth), ShouldBeTrue)
+		})
+
+		Convey("Nothing is downloaded when no release is suitable", func() {
+			attempt, err := download.Run(context.Background(), models.BookRequest{Title: "Unknown Book"},
+				[]download.Downloader{downloader}, nil)
+			So(attempt, ShouldBeNil)
+			So(errors.Is(err, download.ErrNoReleases), ShouldBeTrue)
+		})
+	})
+}
@@ -35,6 +35,11 @@ func init() {
 
 	database.Connect()
 
+	// Resume background downloads
+	if err := helpers.StartDownloadQueue(); err != nil {
+		logs.Error("Failed to start download queue: %v", err)
+	}
+
 	// Initialize OIDC if enabled
 	if err := middlewares.InitOIDC(); err != nil {
 		logs.Error("Failed to initialize OIDC: %v", err)
@@ -139,6 +139,8 @@ func validateOIDCTokenAndGetUser(tokenString string) (*models.User, error) {
 		LastSeen:    int(time.Now().Unix()),
 		CreatedAt:   int(time.Now().Unix()),
 		Permissions: permissions,
+		Groups:      claims.Groups,
+		Roles:       claims.Roles,
 	}
 
 	logs.Debug("Successfully validated OIDC token for user: %s (type: %s, admin: %t)", 
@@ -0,0 +1,173 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/beego/beego/v2/core/logs"
+	"gorm.io/gorm"
+)
+
+// DefaultApprovalRule is recorded on requests that no approval rule matched,
+// so they got db::defaultaprrovalstatus.
+const DefaultApprovalRule = "default"
+
+// ApprovalRule sets the approval status of new requests that meet its
+// condition. Rules are written as condition=status, where the condition is one
+// of:
+//
+//	group:<name>   the user is in the OIDC group or role, or of the user type
+//	source:<name>  the book was found with the metadata provider
+//	media:<type>   the request is for an ebook, audiobook or both
+//	libraryauthor  the library already has books by the author
+//	requests:<n>   the user already made n requests this quota period
+type ApprovalRule struct {
+	Condition string
+	Value     string
+	Status    ApprovalStatus
+}
+
+func (r ApprovalRule) String() string {
+	if r.Value == "" {
+		return fmt.Sprintf("%s=%s", r.Condition, r.Status)
+	}
+	return fmt.Sprintf("%s:%s=%s", r.Condition, r.Value, r.Status)
+}
+
+// ParseApprovalRules parses a comma-separated list of approval rules, such as
+// "requests:5=pending,group:family=approved,libraryauthor=approved".
+func ParseApprovalRules(text string) ([]ApprovalRule, error) {
+	var rules []ApprovalRule
+
+	for _, raw := range strings.Split(text, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+
+		i := strings.LastIndex(raw, "=")
+		if i < 0 {
+			return nil, fmt.Errorf("approval rule %q has no status", raw)
+		}
+		rule := ApprovalRule{Status: ApprovalStatus(strings.ToLower(strings.TrimSpace(raw[i+1:])))}
+		if rule.Status != ASApproved && rule.Status != ASPending {
+			return nil, fmt.Errorf("approval rule %q must set approved or pending", raw)
+		}
+
+		condition, value, _ := strings.Cut(raw[:i], ":")
+		rule.Condition = strings.ToLower(strings.TrimSpace(condition))
+		rule.Value = strings.TrimSpace(value)
+
+		switch rule.Condition {
+		case "group", "source":
+			if rule.Value == "" {
+				return nil, fmt.Errorf("approval rule %q needs a %s name", raw, rule.Condition)
+			}
+		case "media":
+			rule.Value = strings.ToLower(rule.Value)
+			if !MediaType(rule.Value).IsValid() {
+				return nil, fmt.Errorf("approval rule %q needs ebook, audiobook or both", raw)
+			}
+		case "libraryauthor":
+			if rule.Value != "" {
+				return nil, fmt.Errorf("approval rule %q doesn't take a value", raw)
+			}
+		case "requests":
+			if n, err := strconv.Atoi(rule.Value); err != nil || n < 0 {
+				return nil, fmt.Errorf("approval rule %q needs a number of requests", raw)
+			}
+		default:
+			return nil, fmt.Errorf("approval rule %q has unknown condition %q", raw, rule.Condition)
+		}
+
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// NeedsLibrary reports whether any of the rules checks the library, so
+// ApprovalContext.AuthorInLibrary has to be looked up.
+func NeedsLibrary(rules []ApprovalRule) bool {
+	for _, rule := range rules {
+		if rule.Condition == "libraryauthor" {
+			return true
+		}
+	}
+	return false
+}
+
+// ApprovalContext is what approval rules need to know about a new request
+// beyond its own fields. It's gathered before the request is stored, so
+// deciding the approval status doesn't wait on other services.
+type ApprovalContext struct {
+	// Requestor is the user making the request.
+	Requestor *User
+	// AuthorInLibrary is whether the library has books by the author. It's
+	// false when the library couldn't be checked.
+	AuthorInLibrary bool
+}
+
+// matches reports whether the request meets the rule's condition. Requests
+// without an approval context only match on their own fields.
+func (r ApprovalRule) matches(tx *gorm.DB, request *BookRequest, period QuotaPeriod) (bool, error) {
+	approval := request.Approval
+	if approval == nil {
+		approval = &ApprovalContext{}
+	}
+
+	switch r.Condition {
+	case "group":
+		return approval.Requestor != nil && approval.Requestor.InGroup(r.Value), nil
+	case "source":
+		return strings.EqualFold(request.Source, r.Value), nil
+	case "media":
+		return request.MediaType == MediaType(r.Value), nil
+	case "libraryauthor":
+		return approval.AuthorInLibrary, nil
+	case "requests":
+		limit, _ := strconv.Atoi(r.Value)
+		start, _ := period.Bounds(time.Now())
+		made, err := NewRequestRepository(tx).CountBookRequestsSince(request.RequestorID, start)
+		return made >= int64(limit), err
+	}
+	return false, nil
+}
+
+// decideApproval returns the status set by the first rule that matches the
+// request, and the rule, or the fallback status when none does. Rules that
+// can't be checked are skipped.
+func decideApproval(tx *gorm.DB, request *BookRequest, rules []ApprovalRule, period QuotaPeriod, fallback ApprovalStatus) (ApprovalStatus, string) {
+	for _, rule := range rules {
+		matched, err := rule.matches(tx, request, period)
+		if err != nil {
+			logs.Warn("Unable to check approval rule %s: %v", rule, err)
+			continue
+		}
+		if matched {
+			return rule.Status, rule.String()
+		}
+	}
+	return fallback, DefaultApprovalRule
+}
+
+// ConfiguredApprovalRules returns the rules in requests::approvalrules. When
+// they're invalid, the error is logged and no rules are used.
+func ConfiguredApprovalRules() []ApprovalRule {
+	rules, err := ParseApprovalRules(config.DefaultString("requests::approvalrules", ""))
+	if err != nil {
+		logs.Warn("Ignoring invalid approval rules: %v", err)
+		return nil
+	}
+	return rules
+}
+
+// configuredApproval decides the request's approval status with the configured
+// rules, falling back to db::defaultaprrovalstatus.
+func configuredApproval(tx *gorm.DB, request *BookRequest) (ApprovalStatus, string) {
+	fallback := ApprovalStatus(config.DefaultString("db::defaultaprrovalstatus", string(ASPending)))
+	return decideApproval(tx, request, ConfiguredApprovalRules(), ConfiguredQuotaPolicy().Period, fallback)
+}
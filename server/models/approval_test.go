@@ -0,0 +1,135 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/beego/beego/v2/core/config"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseApprovalRules(t *testing.T) {
+	Convey("Subject: Parsing approval rules", t, func() {
+		Convey("Rules are parsed in order", func() {
+			rules, err := ParseApprovalRules(" requests:5=pending, Group:Family=Approved,libraryauthor=approved,,")
+			So(err, ShouldBeNil)
+			So(rules, ShouldResemble, []ApprovalRule{
+				{Condition: "requests", Value: "5", Status: ASPending},
+				{Condition: "group", Value: "Family", Status: ASApproved},
+				{Condition: "libraryauthor", Status: ASApproved},
+			})
+			So(rules[1].String(), ShouldEqual, "group:Family=approved")
+			So(rules[2].String(), ShouldEqual, "libraryauthor=approved")
+			So(NeedsLibrary(rules), ShouldBeTrue)
+			So(NeedsLibrary(rules[:2]), ShouldBeFalse)
+		})
+
+		Convey("No rules is valid", func() {
+			rules, err := ParseApprovalRules("")
+			So(err, ShouldBeNil)
+			So(rules, ShouldBeEmpty)
+		})
+
+		Convey("Invalid rules are rejected", func() {
+			for _, text := range []string{
+				"group:family",
+				"group:family=denied",
+				"group=approved",
+				"media:video=approved",
+				"libraryauthor:yes=approved",
+				"requests:many=pending",
+				"weather:sunny=approved",
+			} {
+				_, err := ParseApprovalRules(text)
+				So(err, ShouldNotBeNil)
+			}
+		})
+	})
+}
+
+func TestDecideApproval(t *testing.T) {
+	Convey("Subject: Deciding the approval status of new requests", t, func() {
+		db := newTestDB()
+
+		rules, err := ParseApprovalRules("requests:1=pending,source:HARDCOVER=pending,group:family=approved,libraryauthor=approved")
+		So(err, ShouldBeNil)
+
+		request := &BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "GOOGLE", SourceID: "abc123",
+			RequestorID: "user-1", RequestorUsername: "user", MediaType: MTEbook}
+		request.Approval = &ApprovalContext{Requestor: &User{ID: "user-1", Type: "user", Groups: []string{"Family"}}}
+
+		decide := func() (ApprovalStatus, string) {
+			return decideApproval(db, request, rules, QPWeek, ASPending)
+		}
+
+		Convey("The first matching rule decides", func() {
+			status, rule := decide()
+			So(status, ShouldEqual, ASApproved)
+			So(rule, ShouldEqual, "group:family=approved")
+
+			request.Source = "HARDCOVER"
+			status, rule = decide()
+			So(status, ShouldEqual, ASPending)
+			So(rule, ShouldEqual, "source:HARDCOVER=pending")
+		})
+
+		Convey("Authors already in the library match libraryauthor rules", func() {
+			request.Approval = &ApprovalContext{Requestor: &User{ID: "user-1", Type: "user"}, AuthorInLibrary: true}
+			status, rule := decide()
+			So(status, ShouldEqual, ASApproved)
+			So(rule, ShouldEqual, "libraryauthor=approved")
+		})
+
+		Convey("Requests matching no rule get the fallback status", func() {
+			request.Approval = nil
+			status, rule := decide()
+			So(status, ShouldEqual, ASPending)
+			So(rule, ShouldEqual, DefaultApprovalRule)
+		})
+
+		Convey("Requests above the count need approval", func() {
+			requests := NewRequestRepository(withoutHooks(db))
+			_, err := requests.CreateBookRequest(&BookRequest{Title: "Emma", Author: "Jane Austen", Source: "GOOGLE",
+				SourceID: "def456", RequestorID: "user-1", RequestorUsername: "user",
+				ApprovalStatus: ASApproved, DownloadStatus: DSPending})
+			So(err, ShouldBeNil)
+
+			status, rule := decide()
+			So(status, ShouldEqual, ASPending)
+			So(rule, ShouldEqual, "requests:1=pending")
+		})
+	})
+}
+
+func TestCreateWithApprovalRules(t *testing.T) {
+	Convey("Subject: Approval rules on newly created requests", t, func() {
+		So(config.Set("requests::approvalrules", "source:HARDCOVER=pending,group:family=approved"), ShouldBeNil)
+		Reset(func() { config.Set("requests::approvalrules", "") })
+
+		requests := NewRequestRepository(newTestDB())
+		create := func(source string, groups ...string) *BookRequest {
+			request := &BookRequest{Title: "Dune", Author: "Frank Herbert", Source: source, SourceID: "abc123",
+				RequestorID: "user-1", RequestorUsername: "user"}
+			request.Approval = &ApprovalContext{Requestor: &User{ID: "user-1", Type: "user", Groups: groups}}
+			created, err := requests.CreateBookRequest(request)
+			So(err, ShouldBeNil)
+
+			stored, err := requests.GetBookRequest(strconv.FormatUint(uint64(created.ID), 10))
+			So(err, ShouldBeNil)
+			return stored
+		}
+
+		Convey("The matching rule is stored with the status it set", func() {
+			request := create("GOOGLE", "Family")
+			So(request.ApprovalStatus, ShouldEqual, ASApproved)
+			So(*request.ApprovalRule, ShouldEqual, "group:family=approved")
+			So(request.MediaType, ShouldEqual, MTEbook)
+		})
+
+		Convey("Requests no rule matches get the default status", func() {
+			request := create("GOOGLE")
+			So(request.ApprovalStatus, ShouldEqual, ASPending)
+			So(*request.ApprovalRule, ShouldEqual, DefaultApprovalRule)
+		})
+	})
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// CacheEntry is a cached search response stored by the SQLite cache backend.
+type CacheEntry struct {
+	Key       string    `json:"key" gorm:"primarykey;size:500"`
+	Value     []byte    `json:"-" gorm:"not null"`
+	ExpiresAt time.Time `json:"expires_at" gorm:"index;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
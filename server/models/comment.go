@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RequestComment is a message on a book request's thread between the people
+// who asked for the book and the admins.
+type RequestComment struct {
+	ID             uint   `json:"id" gorm:"primarykey"`
+	RequestID      uint   `json:"request_id" gorm:"not null;index"`
+	AuthorID       string `json:"author_id" gorm:"size:50;not null"`
+	AuthorUsername string `json:"author_username" gorm:"size:100;not null"`
+	// FromAdmin is set when an admin wrote the comment.
+	FromAdmin bool      `json:"from_admin"`
+	Body      string    `json:"body" gorm:"not null"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type RequestCommentUpdate struct {
+	Body string `json:"body"`
+}
+
+type CommentRepository interface {
+	CreateComment(comment *RequestComment) (*RequestComment, error)
+	GetComments(requestID uint) ([]RequestComment, error)
+	GetComment(requestID uint, id string) (*RequestComment, error)
+	UpdateComment(comment *RequestComment, update RequestCommentUpdate) (*RequestComment, error)
+	DeleteComment(comment *RequestComment) error
+}
+
+type commentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *gorm.DB) CommentRepository {
+	return &commentRepository{db: db}
+}
+
+func (r *commentRepository) CreateComment(comment *RequestComment) (*RequestComment, error) {
+	if err := r.db.Create(comment).Error; err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+// GetComments returns the request's comments, oldest first.
+func (r *commentRepository) GetComments(requestID uint) ([]RequestComment, error) {
+	var comments []RequestComment
+
+	if err := r.db.Where("request_id = ?", requestID).Order("id ASC").Find(&comments).Error; err != nil {
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+func (r *commentRepository) GetComment(requestID uint, id string) (*RequestComment, error) {
+	var comment RequestComment
+	if err := r.db.Where("request_id = ? AND id = ?", requestID, id).First(&comment).Error; err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) UpdateComment(comment *RequestComment, update RequestCommentUpdate) (*RequestComment, error) {
+	comment.Body = update.Body
+
+	if err := r.db.Model(comment).Update("body", comment.Body).Error; err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
+
+func (r *commentRepository) DeleteComment(comment *RequestComment) error {
+	return r.db.Delete(&RequestComment{}, comment.ID).Error
+}
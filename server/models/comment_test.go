@@ -0,0 +1,56 @@
+package models
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"gorm.io/gorm"
+)
+
+func TestCommentRepository(t *testing.T) {
+	Convey("Subject: Book request comments", t, func() {
+		db := newTestDB()
+
+		request := BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "GOOGLE", SourceID: "abc123",
+			RequestorID: "user-1", RequestorUsername: "user"}
+		So(db.Create(&request).Error, ShouldBeNil)
+
+		repo := NewCommentRepository(db)
+		first, err := repo.CreateComment(&RequestComment{RequestID: request.ID, AuthorID: "admin-1", AuthorUsername: "admin",
+			FromAdmin: true, Body: "Which edition would you like?"})
+		So(err, ShouldBeNil)
+		_, err = repo.CreateComment(&RequestComment{RequestID: request.ID, AuthorID: "user-1", AuthorUsername: "user",
+			Body: "The 40th anniversary one, please."})
+		So(err, ShouldBeNil)
+
+		Convey("Comments are listed oldest first", func() {
+			comments, err := repo.GetComments(request.ID)
+			So(err, ShouldBeNil)
+			So(comments, ShouldHaveLength, 2)
+			So(comments[0].FromAdmin, ShouldBeTrue)
+			So(comments[1].AuthorID, ShouldEqual, "user-1")
+		})
+
+		Convey("Comments are only found on their own request", func() {
+			_, err := repo.GetComment(request.ID+1, "1")
+			So(err, ShouldEqual, gorm.ErrRecordNotFound)
+		})
+
+		Convey("Comments can be edited", func() {
+			_, err := repo.UpdateComment(first, RequestCommentUpdate{Body: "Which edition?"})
+			So(err, ShouldBeNil)
+
+			comment, err := repo.GetComment(request.ID, "1")
+			So(err, ShouldBeNil)
+			So(comment.Body, ShouldEqual, "Which edition?")
+		})
+
+		Convey("Deleting the request deletes its comments", func() {
+			So(NewRequestRepository(db).DeleteBookRequest(&request), ShouldBeNil)
+
+			comments, err := repo.GetComments(request.ID)
+			So(err, ShouldBeNil)
+			So(comments, ShouldBeEmpty)
+		})
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Actor is who made a change. Changes made by Seeklit itself, such as the
+// download pipeline, are made by SystemActor.
+type Actor struct {
+	ID       string
+	Username string
+}
+
+var SystemActor = Actor{ID: "system", Username: "Seeklit"}
+
+// StatusEvent records a change to the status of a book request or an issue.
+// Events are only ever appended, so they're the history of who changed what
+// and when. The statuses that didn't change are nil.
+type StatusEvent struct {
+	ID            uint   `json:"id" gorm:"primarykey"`
+	RequestID     *uint  `json:"request_id" gorm:"index"`
+	IssueID       *uint  `json:"issue_id" gorm:"index"`
+	ActorID       string `json:"actor_id" gorm:"size:50;not null"`
+	ActorUsername string `json:"actor_username" gorm:"size:100;not null"`
+	// OldApprovalStatus and the other old statuses are nil when the request or
+	// issue was created.
+	OldApprovalStatus *ApprovalStatus `json:"old_approval_status" gorm:"size:50"`
+	NewApprovalStatus *ApprovalStatus `json:"new_approval_status" gorm:"size:50"`
+	OldDownloadStatus *DownloadStatus `json:"old_download_status" gorm:"size:50"`
+	NewDownloadStatus *DownloadStatus `json:"new_download_status" gorm:"size:50"`
+	OldIssueStatus    *IssueStatus    `json:"old_issue_status" gorm:"size:50"`
+	NewIssueStatus    *IssueStatus    `json:"new_issue_status" gorm:"size:50"`
+	CreatedAt         time.Time       `json:"created_at"`
+}
+
+// requestEvent describes the change to a request's statuses, or returns nil
+// when neither changed. A nil old request means the request was created.
+func requestEvent(actor Actor, old, updated *BookRequest) *StatusEvent {
+	event := &StatusEvent{RequestID: &updated.ID}
+	if old == nil {
+		event.NewApprovalStatus = &updated.ApprovalStatus
+		event.NewDownloadStatus = &updated.DownloadStatus
+		return event.by(actor)
+	}
+
+	if old.ApprovalStatus != updated.ApprovalStatus {
+		event.OldApprovalStatus, event.NewApprovalStatus = &old.ApprovalStatus, &updated.ApprovalStatus
+	}
+	if old.DownloadStatus != updated.DownloadStatus {
+		event.OldDownloadStatus, event.NewDownloadStatus = &old.DownloadStatus, &updated.DownloadStatus
+	}
+	if event.NewApprovalStatus == nil && event.NewDownloadStatus == nil {
+		return nil
+	}
+	return event.by(actor)
+}
+
+// issueEvent describes the change to an issue's status, or returns nil when it
+// didn't change. An empty old status means the issue was created.
+func issueEvent(actor Actor, old IssueStatus, updated *Issue) *StatusEvent {
+	if old == updated.Status {
+		return nil
+	}
+
+	event := &StatusEvent{IssueID: &updated.ID, NewIssueStatus: &updated.Status}
+	if old != "" {
+		event.OldIssueStatus = &old
+	}
+	return event.by(actor)
+}
+
+func (e *StatusEvent) by(actor Actor) *StatusEvent {
+	if actor.ID == "" {
+		actor = SystemActor
+	}
+	e.ActorID, e.ActorUsername = actor.ID, actor.Username
+	return e
+}
+
+type EventRepository interface {
+	GetRequestEvents(requestID uint) ([]StatusEvent, error)
+	GetIssueEvents(issueID uint) ([]StatusEvent, error)
+}
+
+type eventRepository struct {
+	db *gorm.DB
+}
+
+func NewEventRepository(db *gorm.DB) EventRepository {
+	return &eventRepository{db: db}
+}
+
+// GetRequestEvents returns the request's status history, oldest first.
+func (r *eventRepository) GetRequestEvents(requestID uint) ([]StatusEvent, error) {
+	var events []StatusEvent
+
+	if err := r.db.Where("request_id = ?", requestID).Order("id ASC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// GetIssueEvents returns the issue's status history, oldest first.
+func (r *eventRepository) GetIssueEvents(issueID uint) ([]StatusEvent, error) {
+	var events []StatusEvent
+
+	if err := r.db.Where("issue_id = ?", issueID).Order("id ASC").Find(&events).Error; err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// recordEvent appends the event unless there's nothing to record.
+func recordEvent(tx *gorm.DB, event *StatusEvent) error {
+	if event == nil {
+		return nil
+	}
+	return tx.Create(event).Error
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStatusEvents(t *testing.T) {
+	Convey("Subject: Status history of requests and issues", t, func() {
+		db := newTestDB()
+
+		events := NewEventRepository(db)
+		admin := Actor{ID: "admin-1", Username: "admin"}
+
+		Convey("Every status change of a request is recorded", func() {
+			requests := NewRequestRepository(db)
+			request := &BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "GOOGLE", SourceID: "abc123",
+				RequestorID: "user-1", RequestorUsername: "user", ApprovalStatus: ASPending, DownloadStatus: DSPending}
+			_, err := requests.CreateBookRequest(request)
+			So(err, ShouldBeNil)
+
+			approved, failure := ASApproved, DSFailure
+			_, err = requests.UpdateBookRequest(request, BookRequestUpdate{ApprovalStatus: &approved, Actor: admin})
+			So(err, ShouldBeNil)
+			_, err = requests.UpdateBookRequest(request, BookRequestUpdate{DownloadStatus: &failure})
+			So(err, ShouldBeNil)
+			// Updates that don't change a status aren't recorded
+			_, err = requests.UpdateBookRequest(request, BookRequestUpdate{ApprovalStatus: &approved, Actor: admin})
+			So(err, ShouldBeNil)
+
+			history, err := events.GetRequestEvents(request.ID)
+			So(err, ShouldBeNil)
+			So(history, ShouldHaveLength, 3)
+
+			So(history[0].ActorID, ShouldEqual, "user-1")
+			So(history[0].OldApprovalStatus, ShouldBeNil)
+			So(*history[0].NewDownloadStatus, ShouldEqual, DSPending)
+
+			So(history[1].ActorUsername, ShouldEqual, "admin")
+			So(*history[1].OldApprovalStatus, ShouldEqual, ASPending)
+			So(*history[1].NewApprovalStatus, ShouldEqual, ASApproved)
+			So(history[1].NewDownloadStatus, ShouldBeNil)
+
+			So(history[2].ActorID, ShouldEqual, SystemActor.ID)
+			So(*history[2].NewDownloadStatus, ShouldEqual, DSFailure)
+		})
+
+		Convey("Every status change of an issue is recorded", func() {
+			issues := NewIssueRepository(db)
+			issue := &Issue{BookID: "li1", BookTitle: "Dune", Description: "Missing chapter", Severity: Medium,
+				CreatorID: "user-1", CreatorUsername: "user"}
+			_, err := issues.CreateIssue(issue)
+			So(err, ShouldBeNil)
+
+			resolved := ISResolved
+			_, err = issues.UpdateIssue(issue, IssueUpdate{Status: &resolved, Actor: admin})
+			So(err, ShouldBeNil)
+
+			history, err := events.GetIssueEvents(issue.ID)
+			So(err, ShouldBeNil)
+			So(history, ShouldHaveLength, 2)
+			So(history[0].OldIssueStatus, ShouldBeNil)
+			So(*history[0].NewIssueStatus, ShouldEqual, ISPending)
+			So(*history[1].OldIssueStatus, ShouldEqual, ISPending)
+			So(*history[1].NewIssueStatus, ShouldEqual, ISResolved)
+			So(history[1].ActorID, ShouldEqual, "admin-1")
+		})
+	})
+}
@@ -0,0 +1,165 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+var (
+	// RequestSortFields are the fields book requests can be sorted by.
+	RequestSortFields = []string{"id", "created_at", "updated_at", "title", "author"}
+	// IssueSortFields are the fields issues can be sorted by.
+	IssueSortFields = []string{"id", "created_at", "updated_at", "book_title", "status"}
+)
+
+// Sort orders a listing by one of its fields, then by ID.
+type Sort struct {
+	Field     string
+	Ascending bool
+}
+
+// ParseSort checks the field against the allowed fields and parses the order,
+// asc or desc. Listings are newest first unless asked otherwise.
+func ParseSort(field, order string, allowed []string) (Sort, error) {
+	sort := Sort{Field: strings.ToLower(field)}
+	if sort.Field == "" {
+		sort.Field = "id"
+	}
+	if !slices.Contains(allowed, sort.Field) {
+		return sort, fmt.Errorf("can't sort by %q, only by %s", field, strings.Join(allowed, ", "))
+	}
+
+	switch strings.ToLower(order) {
+	case "asc":
+		sort.Ascending = true
+	case "", "desc":
+	default:
+		return sort, fmt.Errorf("sort order must be asc or desc, not %q", order)
+	}
+
+	return sort, nil
+}
+
+// field returns the sort field, which is the ID unless set.
+func (s Sort) field() string {
+	if s.Field == "" {
+		return "id"
+	}
+	return s.Field
+}
+
+func (s Sort) apply(query *gorm.DB) *gorm.DB {
+	direction := "DESC"
+	if s.Ascending {
+		direction = "ASC"
+	}
+
+	field := s.field()
+	query = query.Order(field + " " + direction)
+	if field != "id" {
+		query = query.Order("id " + direction)
+	}
+	return query
+}
+
+// RequestFilter narrows a listing of book requests. Empty fields don't filter.
+type RequestFilter struct {
+	// RequestorID limits the listing to requests the user made or follows.
+	RequestorID *string
+	// Requestor limits the listing to requests made by the username.
+	Requestor        string
+	ApprovalStatuses []ApprovalStatus
+	DownloadStatuses []DownloadStatus
+	// CreatedAfter and CreatedBefore limit when the requests were made.
+	// CreatedBefore is exclusive.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Search matches text in the title or author.
+	Search string
+	Sort   Sort
+}
+
+func (f RequestFilter) apply(db, query *gorm.DB) *gorm.DB {
+	if f.RequestorID != nil && *f.RequestorID != "" {
+		query = query.Where("requestor_id = ? OR id IN (?)", *f.RequestorID,
+			db.Model(&RequestFollower{}).Select("request_id").Where("user_id = ?", *f.RequestorID))
+	}
+	if f.Requestor != "" {
+		query = query.Where("LOWER(requestor_username) = ?", strings.ToLower(f.Requestor))
+	}
+	if len(f.ApprovalStatuses) > 0 {
+		query = query.Where("approval_status IN ?", f.ApprovalStatuses)
+	}
+	if len(f.DownloadStatuses) > 0 {
+		query = query.Where("download_status IN ?", f.DownloadStatuses)
+	}
+	query = createdBetween(query, f.CreatedAfter, f.CreatedBefore)
+	return search(query, f.Search, "title", "author")
+}
+
+// IssueFilter narrows a listing of issues. Empty fields don't filter.
+type IssueFilter struct {
+	// CreatorID limits the listing to issues the user reported.
+	CreatorID *string
+	// Creator limits the listing to issues reported by the username.
+	Creator    string
+	Statuses   []IssueStatus
+	Severities []IssueSeverity
+	// CreatedAfter and CreatedBefore limit when the issues were reported.
+	// CreatedBefore is exclusive.
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	// Search matches text in the book title or description.
+	Search string
+	Sort   Sort
+}
+
+func (f IssueFilter) apply(query *gorm.DB) *gorm.DB {
+	if f.CreatorID != nil && *f.CreatorID != "" {
+		query = query.Where("creator_id = ?", *f.CreatorID)
+	}
+	if f.Creator != "" {
+		query = query.Where("LOWER(creator_username) = ?", strings.ToLower(f.Creator))
+	}
+	if len(f.Statuses) > 0 {
+		query = query.Where("status IN ?", f.Statuses)
+	}
+	if len(f.Severities) > 0 {
+		query = query.Where("severity IN ?", f.Severities)
+	}
+	query = createdBetween(query, f.CreatedAfter, f.CreatedBefore)
+	return search(query, f.Search, "book_title", "description")
+}
+
+func createdBetween(query *gorm.DB, after, before *time.Time) *gorm.DB {
+	if after != nil {
+		query = query.Where("created_at >= ?", *after)
+	}
+	if before != nil {
+		query = query.Where("created_at < ?", *before)
+	}
+	return query
+}
+
+// search matches rows where any of the columns contains the text, ignoring case.
+func search(query *gorm.DB, text string, columns ...string) *gorm.DB {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return query
+	}
+
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(text))
+	pattern := "%" + escaped + "%"
+
+	conditions := make([]string, len(columns))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		conditions[i] = fmt.Sprintf(`LOWER(%s) LIKE ? ESCAPE '\'`, column)
+		args[i] = pattern
+	}
+	return query.Where(strings.Join(conditions, " OR "), args...)
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParseSort(t *testing.T) {
+	Convey("Subject: Parsing listing sort orders", t, func() {
+		sort, err := ParseSort("", "", RequestSortFields)
+		So(err, ShouldBeNil)
+		So(sort, ShouldResemble, Sort{Field: "id"})
+
+		sort, err = ParseSort("Title", "ASC", RequestSortFields)
+		So(err, ShouldBeNil)
+		So(sort, ShouldResemble, Sort{Field: "title", Ascending: true})
+
+		_, err = ParseSort("requestor_id; DROP TABLE book_requests", "", RequestSortFields)
+		So(err, ShouldNotBeNil)
+		_, err = ParseSort("title", "sideways", RequestSortFields)
+		So(err, ShouldNotBeNil)
+	})
+}
+
+func TestFindBookRequests(t *testing.T) {
+	Convey("Subject: Filtering book requests", t, func() {
+		db := newTestDB()
+
+		requests := NewRequestRepository(withoutHooks(db))
+		day := func(d int) time.Time { return time.Date(2025, time.January, d, 12, 0, 0, 0, time.UTC) }
+		for _, request := range []BookRequest{
+			{Title: "Dune", Author: "Frank Herbert", RequestorID: "alice", RequestorUsername: "Alice",
+				ApprovalStatus: ASApproved, DownloadStatus: DSFailure, CreatedAt: day(3)},
+			{Title: "Emma", Author: "Jane Austen", RequestorID: "bob", RequestorUsername: "bob",
+				ApprovalStatus: ASApproved, DownloadStatus: DSComplete, CreatedAt: day(10)},
+			{Title: "100%_Done", Author: "Someone", RequestorID: "bob", RequestorUsername: "bob",
+				ApprovalStatus: ASPending, DownloadStatus: DSFailure, CreatedAt: day(20)},
+		} {
+			request.Source, request.SourceID = "GOOGLE", request.Title
+			_, err := requests.CreateBookRequest(&request)
+			So(err, ShouldBeNil)
+		}
+
+		titles := func(filter RequestFilter) []string {
+			found, err := requests.FindBookRequests(filter, 20, 0)
+			So(err, ShouldBeNil)
+			titles := []string{}
+			for _, request := range found {
+				titles = append(titles, request.Title)
+			}
+			return titles
+		}
+
+		Convey("Without a filter every request is listed newest first", func() {
+			So(titles(RequestFilter{}), ShouldResemble, []string{"100%_Done", "Emma", "Dune"})
+		})
+
+		Convey("Statuses, requestor and dates narrow the listing", func() {
+			So(titles(RequestFilter{DownloadStatuses: []DownloadStatus{DSFailure}}), ShouldResemble, []string{"100%_Done", "Dune"})
+			So(titles(RequestFilter{Requestor: "alice"}), ShouldResemble, []string{"Dune"})
+
+			after, before := day(1), day(20)
+			So(titles(RequestFilter{CreatedAfter: &after, CreatedBefore: &before}), ShouldResemble, []string{"Emma", "Dune"})
+		})
+
+		Convey("Text search matches the title or author literally", func() {
+			So(titles(RequestFilter{Search: "austen"}), ShouldResemble, []string{"Emma"})
+			So(titles(RequestFilter{Search: "%_"}), ShouldResemble, []string{"100%_Done"})
+		})
+
+		Convey("Requests can be sorted by other fields", func() {
+			So(titles(RequestFilter{Sort: Sort{Field: "title", Ascending: true}}), ShouldResemble, []string{"100%_Done", "Dune", "Emma"})
+		})
+
+		Convey("Followed requests are listed for their followers", func() {
+			dune, err := requests.GetBookRequest("1")
+			So(err, ShouldBeNil)
+			_, err = requests.AddFollower(dune, "bob", "bob")
+			So(err, ShouldBeNil)
+
+			bob := "bob"
+			So(titles(RequestFilter{RequestorID: &bob}), ShouldResemble, []string{"100%_Done", "Emma", "Dune"})
+			So(titles(RequestFilter{RequestorID: &bob, ApprovalStatuses: []ApprovalStatus{ASApproved}}), ShouldResemble, []string{"Emma", "Dune"})
+		})
+	})
+}
+
+func TestFindIssues(t *testing.T) {
+	Convey("Subject: Filtering issues", t, func() {
+		db := newTestDB()
+
+		issues := NewIssueRepository(db)
+		for _, issue := range []Issue{
+			{BookID: "li1", BookTitle: "Dune", Description: "Missing chapter", Severity: High, CreatorID: "alice", CreatorUsername: "alice"},
+			{BookID: "li2", BookTitle: "Emma", Description: "Wrong cover", Severity: Low, CreatorID: "bob", CreatorUsername: "bob"},
+		} {
+			_, err := issues.CreateIssue(&issue)
+			So(err, ShouldBeNil)
+		}
+
+		found, err := issues.FindIssues(IssueFilter{Severities: []IssueSeverity{High, Critical}}, 20, 0)
+		So(err, ShouldBeNil)
+		So(found, ShouldHaveLength, 1)
+		So(found[0].BookTitle, ShouldEqual, "Dune")
+
+		found, err = issues.FindIssues(IssueFilter{Search: "COVER", Statuses: []IssueStatus{ISPending}}, 20, 0)
+		So(err, ShouldBeNil)
+		So(found, ShouldHaveLength, 1)
+		So(found[0].BookTitle, ShouldEqual, "Emma")
+
+		found, err = issues.FindIssues(IssueFilter{Creator: "carol"}, 20, 0)
+		So(err, ShouldBeNil)
+		So(found, ShouldBeEmpty)
+	})
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"strconv"
 	"time"
 
 	"gorm.io/gorm"
@@ -33,14 +34,35 @@ type Issue struct {
 	CreatedAt       time.Time     `json:"created_at"`
 }
 
+// cursor marks the issue's place in a listing sorted by the field.
+func (i *Issue) cursor(field string) Cursor {
+	cursor := Cursor{Field: field, ID: strconv.FormatUint(uint64(i.ID), 10)}
+	switch field {
+	case "created_at":
+		cursor.Value = sortTime(i.CreatedAt)
+	case "updated_at":
+		cursor.Value = sortTime(i.UpdatedAt)
+	case "book_title":
+		cursor.Value = i.BookTitle
+	case "status":
+		cursor.Value = string(i.Status)
+	}
+	return cursor
+}
+
 type IssueUpdate struct {
 	Status *IssueStatus `json:"status"`
+	// Actor is recorded in the issue's history as making the update.
+	Actor Actor `json:"-"`
 }
 
 type IssueRepository interface {
 	CreateIssue(issue *Issue) (*Issue, error)
 	GetAllIssues() ([]Issue, error)
 	GetIssues(limit, offset int, creatorID *string) ([]Issue, error)
+	FindIssues(filter IssueFilter, limit, offset int) ([]Issue, error)
+	CountIssues(filter IssueFilter) (int64, error)
+	ListIssues(filter IssueFilter, limit, offset int, after *Cursor) (*Page[Issue], error)
 	GetIssue(id string) (*Issue, error)
 	UpdateIssue(Issue *Issue, updateIssue IssueUpdate) (*Issue, error)
 	DeleteIssue(Issue *Issue) error
@@ -54,8 +76,18 @@ func NewIssueRepository(db *gorm.DB) IssueRepository {
 	return &issueRepository{db: db}
 }
 
+// CreateIssue stores the issue and starts its history, crediting the creator
+// with creating it.
 func (r *issueRepository) CreateIssue(issue *Issue) (*Issue, error) {
-	if err := r.db.Create(issue).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(issue).Error; err != nil {
+			return err
+		}
+
+		actor := Actor{ID: issue.CreatorID, Username: issue.CreatorUsername}
+		return recordEvent(tx, issueEvent(actor, "", issue))
+	})
+	if err != nil {
 		return nil, err
 	}
 	return issue, nil
@@ -72,27 +104,41 @@ func (r *issueRepository) GetAllIssues() ([]Issue, error) {
 }
 
 func (r *issueRepository) GetIssues(limit, offset int, creatorID *string) ([]Issue, error) {
-	var issues []Issue
-
-	// Start building the query
-	query := r.db.Order("id DESC")
+	return r.FindIssues(IssueFilter{CreatorID: creatorID}, limit, offset)
+}
 
-	// Apply filtering if CreatorID is provided
-	if creatorID != nil && *creatorID != "" {
-		query = query.Where("creator_id = ?", *creatorID)
-	}
+// FindIssues lists the issues that match the filter in its sort order.
+func (r *issueRepository) FindIssues(filter IssueFilter, limit, offset int) ([]Issue, error) {
+	var issues []Issue
 
-	// Apply Limit and Offset
-	query = query.Limit(limit).Offset(offset)
+	query := filter.Sort.apply(filter.apply(r.db))
 
-	// Execute the query
-	if err := query.Find(&issues).Error; err != nil {
+	if err := query.Limit(limit).Offset(offset).Find(&issues).Error; err != nil {
 		return nil, err
 	}
 
 	return issues, nil
 }
 
+// CountIssues counts the issues that match the filter.
+func (r *issueRepository) CountIssues(filter IssueFilter) (int64, error) {
+	var count int64
+
+	err := filter.apply(r.db.Model(&Issue{})).Count(&count).Error
+
+	return count, err
+}
+
+// ListIssues returns a page of the issues that match the filter, starting
+// after the cursor when there is one.
+func (r *issueRepository) ListIssues(filter IssueFilter, limit, offset int, after *Cursor) (*Page[Issue], error) {
+	query := filter.apply(r.db.Model(&Issue{}))
+
+	return paginate(query, filter.Sort, limit, offset, after, func(i *Issue) Cursor {
+		return i.cursor(filter.Sort.field())
+	})
+}
+
 func (r *issueRepository) GetIssue(id string) (*Issue, error) {
 	var issue Issue
 	if err := r.db.Model(Issue{}).Where("id = ?", id).First(&issue).Error; err != nil {
@@ -102,13 +148,21 @@ func (r *issueRepository) GetIssue(id string) (*Issue, error) {
 }
 
 func (r *issueRepository) UpdateIssue(issue *Issue, updateIssue IssueUpdate) (*Issue, error) {
+	oldStatus := issue.Status
+
 	// Edit the issue
 	if updateIssue.Status != nil {
 		issue.Status = *updateIssue.Status
 	}
 
 	// Save the changes
-	if err := r.db.Save(issue).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(issue).Error; err != nil {
+			return err
+		}
+		return recordEvent(tx, issueEvent(updateIssue.Actor, oldStatus, issue))
+	})
+	if err != nil {
 		return nil, err
 	}
 
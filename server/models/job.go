@@ -0,0 +1,255 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type JobStatus string
+type JobKind string
+
+const (
+	// JKDownload jobs search for and download the book.
+	JKDownload JobKind = "download"
+	// JKTrack jobs poll a download client until a handed off release finishes.
+	JKTrack JobKind = "track"
+
+	JSQueued    JobStatus = "queued"
+	JSRunning   JobStatus = "running"
+	JSSucceeded JobStatus = "succeeded"
+	JSFailed    JobStatus = "failed"
+)
+
+// DownloadJob is a queued attempt to download the book for a request. Jobs are
+// persisted so pending work survives a restart.
+type DownloadJob struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	RequestID uint      `json:"request_id" gorm:"not null;index"`
+	Status    JobStatus `json:"status" gorm:"size:50;not null;default:queued;index"`
+	Kind      JobKind   `json:"kind" gorm:"size:20;not null;default:download"`
+	// Attempt counts the retries of a download, starting at 1.
+	Attempt int `json:"attempt" gorm:"not null;default:1"`
+	// Client, ClientID and AttemptID identify the release a track job polls.
+	Client    *string `json:"client" gorm:"size:50"`
+	ClientID  *string `json:"client_id" gorm:"size:200"`
+	AttemptID *uint   `json:"attempt_id"`
+	// RunAfter delays a retry until its backoff has elapsed. Nil runs immediately.
+	RunAfter   *time.Time `json:"run_after" gorm:"index"`
+	Error      *string    `json:"error"`
+	StartedAt  *time.Time `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// DownloadAttempt records what a download job tried and why it failed.
+type DownloadAttempt struct {
+	ID        uint   `json:"id" gorm:"primarykey"`
+	RequestID uint   `json:"request_id" gorm:"not null;index"`
+	JobID     *uint  `json:"job_id"`
+	Attempt   int    `json:"attempt" gorm:"not null"`
+	Source    string `json:"source" gorm:"size:50;not null"`
+	Query     string `json:"query" gorm:"size:500"`
+	Succeeded bool   `json:"succeeded"`
+	// InProgress is set while a download client is still downloading the release.
+	InProgress bool      `json:"in_progress"`
+	Client     *string   `json:"client" gorm:"size:50"`
+	ClientID   *string   `json:"client_id" gorm:"size:200"`
+	Reason     *string   `json:"reason"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// IsActive reports whether the job is waiting for or being processed by a worker.
+func (j *DownloadJob) IsActive() bool {
+	return j.Status == JSQueued || j.Status == JSRunning
+}
+
+type JobRepository interface {
+	CreateJob(job *DownloadJob) (*DownloadJob, error)
+	GetJob(id uint) (*DownloadJob, error)
+	GetActiveJob(requestID uint) (*DownloadJob, error)
+	ClaimNextJob() (*DownloadJob, error)
+	FinishJob(job *DownloadJob, jobErr error) (*DownloadJob, error)
+	RequeueJob(job *DownloadJob, runAfter time.Time) error
+	RequeueRunningJobs() (int64, error)
+	RecordAttempt(attempt *DownloadAttempt) error
+	UpdateAttempt(attempt *DownloadAttempt) error
+	GetAttempt(id uint) (*DownloadAttempt, error)
+	GetAttempts(requestID uint) ([]DownloadAttempt, error)
+	FindRequestsToResearch(createdAfter, lastAttemptBefore time.Time) ([]uint, error)
+}
+
+type jobRepository struct {
+	db *gorm.DB
+}
+
+func NewJobRepository(db *gorm.DB) JobRepository {
+	return &jobRepository{db: db}
+}
+
+// CreateJob queues the job, defaulting to a first download attempt.
+func (r *jobRepository) CreateJob(job *DownloadJob) (*DownloadJob, error) {
+	job.Status = JSQueued
+	if job.Kind == "" {
+		job.Kind = JKDownload
+	}
+	if job.Attempt < 1 {
+		job.Attempt = 1
+	}
+
+	if err := r.db.Create(job).Error; err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+func (r *jobRepository) GetJob(id uint) (*DownloadJob, error) {
+	var job DownloadJob
+
+	if err := r.db.First(&job, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &job, nil
+}
+
+// GetActiveJob returns the queued or running job for the request, or nil if
+// there isn't one.
+func (r *jobRepository) GetActiveJob(requestID uint) (*DownloadJob, error) {
+	var jobs []DownloadJob
+
+	err := r.db.Where("request_id = ? AND status IN ?", requestID, []JobStatus{JSQueued, JSRunning}).
+		Order("id ASC").Limit(1).Find(&jobs).Error
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+// ClaimNextJob marks the oldest queued job that is due as running and returns
+// it, or nil if no job is due.
+func (r *jobRepository) ClaimNextJob() (*DownloadJob, error) {
+	var claimed *DownloadJob
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var jobs []DownloadJob
+		err := tx.Where("status = ?", JSQueued).
+			Where("run_after IS NULL OR run_after <= ?", time.Now()).
+			Order("id ASC").Limit(1).Find(&jobs).Error
+		if err != nil {
+			return err
+		}
+		if len(jobs) == 0 {
+			return nil
+		}
+
+		job := jobs[0]
+		now := time.Now()
+		result := tx.Model(&job).Where("status = ?", JSQueued).
+			Updates(map[string]interface{}{"status": JSRunning, "started_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			// Another worker claimed it first
+			return nil
+		}
+
+		job.Status = JSRunning
+		job.StartedAt = &now
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return claimed, nil
+}
+
+// FinishJob records the outcome of a running job.
+func (r *jobRepository) FinishJob(job *DownloadJob, jobErr error) (*DownloadJob, error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	job.Status = JSSucceeded
+	job.Error = nil
+
+	if jobErr != nil {
+		msg := jobErr.Error()
+		job.Status = JSFailed
+		job.Error = &msg
+	}
+
+	if err := r.db.Model(job).Select("status", "error", "finished_at").Updates(job).Error; err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// RequeueJob puts a running job back on the queue to run again after runAfter.
+func (r *jobRepository) RequeueJob(job *DownloadJob, runAfter time.Time) error {
+	job.Status = JSQueued
+	job.RunAfter = &runAfter
+	job.StartedAt = nil
+
+	return r.db.Model(job).Select("status", "run_after", "started_at").Updates(job).Error
+}
+
+// RequeueRunningJobs puts jobs that were interrupted by a shutdown back on the
+// queue.
+func (r *jobRepository) RequeueRunningJobs() (int64, error) {
+	result := r.db.Model(&DownloadJob{}).Where("status = ?", JSRunning).
+		Updates(map[string]interface{}{"status": JSQueued, "started_at": nil})
+	return result.RowsAffected, result.Error
+}
+
+func (r *jobRepository) RecordAttempt(attempt *DownloadAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+func (r *jobRepository) UpdateAttempt(attempt *DownloadAttempt) error {
+	return r.db.Model(attempt).Select("succeeded", "in_progress", "reason").Updates(attempt).Error
+}
+
+func (r *jobRepository) GetAttempt(id uint) (*DownloadAttempt, error) {
+	var attempt DownloadAttempt
+
+	if err := r.db.First(&attempt, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &attempt, nil
+}
+
+func (r *jobRepository) GetAttempts(requestID uint) ([]DownloadAttempt, error) {
+	var attempts []DownloadAttempt
+
+	if err := r.db.Where("request_id = ?", requestID).Order("id DESC").Find(&attempts).Error; err != nil {
+		return attempts, err
+	}
+
+	return attempts, nil
+}
+
+// FindRequestsToResearch returns approved requests that failed to download,
+// were created after createdAfter, have no active job and haven't been
+// attempted since lastAttemptBefore.
+func (r *jobRepository) FindRequestsToResearch(createdAfter, lastAttemptBefore time.Time) ([]uint, error) {
+	var ids []uint
+
+	err := r.db.Model(&BookRequest{}).
+		Where("approval_status = ? AND download_status = ?", ASApproved, DSFailure).
+		Where("created_at >= ?", createdAfter).
+		Where("id NOT IN (?)", r.db.Model(&DownloadJob{}).Select("request_id").
+			Where("status IN ?", []JobStatus{JSQueued, JSRunning})).
+		Where("id NOT IN (?)", r.db.Model(&DownloadAttempt{}).Select("request_id").
+			Where("created_at > ?", lastAttemptBefore)).
+		Order("id ASC").
+		Pluck("id", &ids).Error
+
+	return ids, err
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestFindRequestsToResearch(t *testing.T) {
+	Convey("Subject: Finding failed requests to re-search", t, func() {
+		db := newTestDB()
+
+		now := time.Now()
+		newRequest := func(title string, approval ApprovalStatus, download DownloadStatus, createdAt time.Time) BookRequest {
+			request := BookRequest{
+				Title:             title,
+				Author:            "Author",
+				Source:            "GOOGLE",
+				SourceID:          title,
+				RequestorID:       "user-1",
+				RequestorUsername: "user",
+				ApprovalStatus:    approval,
+				DownloadStatus:    download,
+				CreatedAt:         createdAt,
+			}
+			So(withoutHooks(db).Create(&request).Error, ShouldBeNil)
+			return request
+		}
+
+		due := newRequest("due", ASApproved, DSFailure, now.AddDate(0, 0, -2))
+		newRequest("too old", ASApproved, DSFailure, now.AddDate(0, 0, -40))
+		newRequest("complete", ASApproved, DSComplete, now)
+		newRequest("pending approval", ASPending, DSFailure, now)
+		recent := newRequest("recently attempted", ASApproved, DSFailure, now)
+		queued := newRequest("already queued", ASApproved, DSFailure, now)
+
+		repo := NewJobRepository(db)
+		So(repo.RecordAttempt(&DownloadAttempt{RequestID: due.ID, Attempt: 1, Source: "cwa", CreatedAt: now.Add(-48 * time.Hour)}), ShouldBeNil)
+		So(repo.RecordAttempt(&DownloadAttempt{RequestID: recent.ID, Attempt: 1, Source: "cwa", CreatedAt: now.Add(-time.Hour)}), ShouldBeNil)
+		_, err := repo.CreateJob(&DownloadJob{RequestID: queued.ID})
+		So(err, ShouldBeNil)
+
+		ids, err := repo.FindRequestsToResearch(now.AddDate(0, 0, -30), now.Add(-24*time.Hour))
+		So(err, ShouldBeNil)
+		So(ids, ShouldResemble, []uint{due.ID})
+	})
+}
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/beego/beego/v2/core/config"
+	"github.com/glebarez/sqlite"
+	. "github.com/smartystreets/goconvey/convey"
+	"gorm.io/gorm"
+)
+
+// TestMain gives the hooks an empty config to read, so they use the defaults.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "models")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	conf := filepath.Join(dir, "test.conf")
+	if err := os.WriteFile(conf, []byte("[requests]\n"), 0o644); err != nil {
+		panic(err)
+	}
+	if err := config.InitGlobalInstance("ini", conf); err != nil {
+		panic(err)
+	}
+
+	os.Exit(m.Run())
+}
+
+// newTestDB returns an empty in-memory database with every model migrated.
+func newTestDB() *gorm.DB {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	So(err, ShouldBeNil)
+	So(db.AutoMigrate(&BookRequest{}, &RequestFollower{}, &DownloadJob{}, &DownloadAttempt{},
+		&RequestComment{}, &StatusEvent{}, &Issue{}, &UserPreferences{}, &CacheEntry{}), ShouldBeNil)
+	return db
+}
+
+// withoutHooks stores fixtures as they're given, without BeforeCreate deciding
+// their approval status.
+func withoutHooks(db *gorm.DB) *gorm.DB {
+	return db.Session(&gorm.Session{SkipHooks: true})
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Page is one page of a listing. Total counts every item that matches the
+// listing's filters, not just those on the page.
+type Page[T any] struct {
+	Items  []T   `json:"items"`
+	Total  int64 `json:"total"`
+	Limit  int   `json:"limit"`
+	Offset int   `json:"offset"`
+	// NextCursor fetches the page after this one, or is nil on the last page.
+	NextCursor *string `json:"next_cursor"`
+}
+
+// Cursor marks the last item of a page, so the next page starts right after it
+// even when items are added or removed in between. It's only valid for the sort
+// field it was made with.
+type Cursor struct {
+	Field string `json:"f"`
+	Value string `json:"v"`
+	ID    string `json:"id"`
+}
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Encode returns the cursor as an opaque string for clients.
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor made by Encode.
+func DecodeCursor(text string) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(text)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var cursor Cursor
+	if err := json.Unmarshal(data, &cursor); err != nil || cursor.Field == "" || cursor.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &cursor, nil
+}
+
+// sortTime formats a timestamp sort field for a cursor.
+func sortTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// after limits the query to the rows after the cursor in the sort order.
+func (s Sort) after(query *gorm.DB, cursor *Cursor) (*gorm.DB, error) {
+	field := s.field()
+	if cursor.Field != field {
+		return nil, ErrInvalidCursor
+	}
+
+	id, err := strconv.ParseUint(cursor.ID, 10, 64)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	operator := "<"
+	if s.Ascending {
+		operator = ">"
+	}
+	if field == "id" {
+		return query.Where("id "+operator+" ?", id), nil
+	}
+
+	var value any = cursor.Value
+	if field == "created_at" || field == "updated_at" {
+		if value, err = time.Parse(time.RFC3339Nano, cursor.Value); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return query.Where("("+field+" "+operator+" ? OR ("+field+" = ? AND id "+operator+" ?))", value, value, id), nil
+}
+
+// paginate counts the rows matching the query and fetches a page of them,
+// starting after the cursor when there is one and at the offset otherwise.
+// cursorFor makes the cursor of a fetched row.
+func paginate[T any](query *gorm.DB, sort Sort, limit, offset int, after *Cursor, cursorFor func(*T) Cursor) (*Page[T], error) {
+	page := &Page[T]{Items: []T{}, Limit: limit, Offset: offset}
+
+	if err := query.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, err
+	}
+
+	if after != nil {
+		var err error
+		if query, err = sort.after(query, after); err != nil {
+			return nil, err
+		}
+		page.Offset = 0
+	}
+
+	// Fetch one more row than asked for to find out whether there's a next page
+	if err := sort.apply(query).Limit(limit + 1).Offset(page.Offset).Find(&page.Items).Error; err != nil {
+		return nil, err
+	}
+
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		next := cursorFor(&page.Items[limit-1]).Encode()
+		page.NextCursor = &next
+	}
+
+	return page, nil
+}
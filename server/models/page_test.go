@@ -0,0 +1,84 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCursor(t *testing.T) {
+	Convey("Subject: Listing cursors", t, func() {
+		cursor := Cursor{Field: "title", Value: "Dune", ID: "3"}
+		decoded, err := DecodeCursor(cursor.Encode())
+		So(err, ShouldBeNil)
+		So(*decoded, ShouldResemble, cursor)
+
+		for _, text := range []string{"", "not a cursor!", Cursor{Field: "title"}.Encode()} {
+			_, err := DecodeCursor(text)
+			So(err, ShouldEqual, ErrInvalidCursor)
+		}
+	})
+}
+
+func TestListBookRequests(t *testing.T) {
+	Convey("Subject: Paginating book requests", t, func() {
+		db := newTestDB()
+
+		requests := NewRequestRepository(withoutHooks(db))
+		created := time.Date(2025, time.January, 1, 12, 0, 0, 0, time.UTC)
+		for i, title := range []string{"Emma", "Dune", "Beloved", "Carrie", "Atonement"} {
+			_, err := requests.CreateBookRequest(&BookRequest{Title: title, Author: "Someone", Source: "GOOGLE",
+				SourceID: title, RequestorID: "alice", RequestorUsername: "alice", ApprovalStatus: ASPending,
+				DownloadStatus: DSPending, CreatedAt: created.Add(time.Duration(i%2) * time.Hour)})
+			So(err, ShouldBeNil)
+		}
+
+		// titles follows the cursors through every page of the listing
+		titles := func(filter RequestFilter) []string {
+			titles := []string{}
+			var after *Cursor
+			for {
+				page, err := requests.ListBookRequests(filter, 2, 0, after)
+				So(err, ShouldBeNil)
+				So(page.Total, ShouldEqual, 5)
+				for _, request := range page.Items {
+					titles = append(titles, request.Title)
+				}
+				if page.NextCursor == nil {
+					return titles
+				}
+				after, err = DecodeCursor(*page.NextCursor)
+				So(err, ShouldBeNil)
+			}
+		}
+
+		Convey("Offset pages report the total and whether there's a next page", func() {
+			page, err := requests.ListBookRequests(RequestFilter{}, 2, 4, nil)
+			So(err, ShouldBeNil)
+			So(page.Total, ShouldEqual, 5)
+			So(page.Items, ShouldHaveLength, 1)
+			So(page.Items[0].Title, ShouldEqual, "Emma")
+			So(page.NextCursor, ShouldBeNil)
+
+			count, err := requests.CountBookRequests(RequestFilter{Search: "carrie"})
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+
+		Convey("Cursors page through every sort order", func() {
+			So(titles(RequestFilter{}), ShouldResemble, []string{"Atonement", "Carrie", "Beloved", "Dune", "Emma"})
+			So(titles(RequestFilter{Sort: Sort{Field: "title", Ascending: true}}),
+				ShouldResemble, []string{"Atonement", "Beloved", "Carrie", "Dune", "Emma"})
+			// Ties on the sort field are broken by ID
+			So(titles(RequestFilter{Sort: Sort{Field: "created_at"}}),
+				ShouldResemble, []string{"Carrie", "Dune", "Atonement", "Beloved", "Emma"})
+		})
+
+		Convey("A cursor for another sort order is rejected", func() {
+			after := &Cursor{Field: "id", ID: "3"}
+			_, err := requests.ListBookRequests(RequestFilter{Sort: Sort{Field: "title"}}, 2, 0, after)
+			So(err, ShouldEqual, ErrInvalidCursor)
+		})
+	})
+}
@@ -0,0 +1,124 @@
+package models
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/beego/beego/v2/core/config"
+)
+
+type QuotaPeriod string
+
+const (
+	QPDay   QuotaPeriod = "day"
+	QPWeek  QuotaPeriod = "week"
+	QPMonth QuotaPeriod = "month"
+)
+
+// Bounds returns the start of the calendar period containing now and when the
+// next one starts. Weeks start on Monday.
+func (p QuotaPeriod) Bounds(now time.Time) (start, reset time.Time) {
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch p {
+	case QPDay:
+		return day, day.AddDate(0, 0, 1)
+	case QPMonth:
+		start = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return start, start.AddDate(0, 1, 0)
+	default:
+		start = day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+		return start, start.AddDate(0, 0, 7)
+	}
+}
+
+// QuotaPolicy limits how many books each user can request per period.
+type QuotaPolicy struct {
+	// Limit is the number of requests per period. Zero is unlimited.
+	Limit  int
+	Period QuotaPeriod
+	// Overrides replace Limit for users in an OIDC group or role, or of a user
+	// type, keyed by its lowercase name. Zero is unlimited.
+	Overrides map[string]int
+}
+
+// ConfiguredQuotaPolicy reads requests::quota, requests::quotaperiod and
+// requests::quotaoverrides, a comma-separated list of name:limit pairs.
+func ConfiguredQuotaPolicy() QuotaPolicy {
+	policy := QuotaPolicy{
+		Limit:     config.DefaultInt("requests::quota", 0),
+		Period:    QuotaPeriod(strings.ToLower(config.DefaultString("requests::quotaperiod", string(QPWeek)))),
+		Overrides: make(map[string]int),
+	}
+
+	for _, pair := range strings.Split(config.DefaultString("requests::quotaoverrides", ""), ",") {
+		name, value, ok := strings.Cut(pair, ":")
+		limit, err := strconv.Atoi(strings.TrimSpace(value))
+		if !ok || err != nil || limit < 0 {
+			continue
+		}
+		policy.Overrides[strings.ToLower(strings.TrimSpace(name))] = limit
+	}
+
+	return policy
+}
+
+// LimitFor returns the user's limit, or 0 when they're unlimited. When several
+// of the user's groups and roles have overrides, the most generous applies.
+// Admins without an override are unlimited.
+func (p QuotaPolicy) LimitFor(user *User) int {
+	limit, overridden := 0, false
+	for _, name := range user.Memberships() {
+		override, ok := p.Overrides[strings.ToLower(name)]
+		if !ok {
+			continue
+		}
+		if override == 0 {
+			return 0
+		}
+		limit, overridden = max(limit, override), true
+	}
+
+	switch {
+	case overridden:
+		return limit
+	case user.IsAdmin():
+		return 0
+	default:
+		return p.Limit
+	}
+}
+
+// Quota is a user's request allowance in the current period.
+type Quota struct {
+	Unlimited bool        `json:"unlimited"`
+	Limit     int         `json:"limit"`
+	Used      int         `json:"used"`
+	Remaining int         `json:"remaining"`
+	Period    QuotaPeriod `json:"period"`
+	ResetsAt  time.Time   `json:"resets_at"`
+}
+
+// Exceeded reports whether the user can't make another request this period.
+func (q *Quota) Exceeded() bool {
+	return !q.Unlimited && q.Remaining <= 0
+}
+
+// QuotaFor counts the user's requests in the current period against their limit.
+func (p QuotaPolicy) QuotaFor(user *User, requests RequestRepository, now time.Time) (*Quota, error) {
+	start, reset := p.Period.Bounds(now)
+	quota := &Quota{Limit: p.LimitFor(user), Period: p.Period, ResetsAt: reset}
+	quota.Unlimited = quota.Limit == 0
+
+	used, err := requests.CountBookRequestsSince(user.ID, start)
+	if err != nil {
+		return nil, err
+	}
+	quota.Used = int(used)
+
+	if !quota.Unlimited {
+		quota.Remaining = max(quota.Limit-quota.Used, 0)
+	}
+	return quota, nil
+}
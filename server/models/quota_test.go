@@ -0,0 +1,97 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestQuotaPeriodBounds(t *testing.T) {
+	Convey("Subject: Calendar quota periods", t, func() {
+		// A Wednesday afternoon
+		now := time.Date(2025, time.January, 15, 14, 30, 0, 0, time.UTC)
+
+		Convey("A day resets at midnight", func() {
+			start, reset := QPDay.Bounds(now)
+			So(start, ShouldEqual, time.Date(2025, time.January, 15, 0, 0, 0, 0, time.UTC))
+			So(reset, ShouldEqual, time.Date(2025, time.January, 16, 0, 0, 0, 0, time.UTC))
+		})
+
+		Convey("A week runs from Monday to Monday", func() {
+			start, reset := QPWeek.Bounds(now)
+			So(start, ShouldEqual, time.Date(2025, time.January, 13, 0, 0, 0, 0, time.UTC))
+			So(reset, ShouldEqual, time.Date(2025, time.January, 20, 0, 0, 0, 0, time.UTC))
+
+			sunday := time.Date(2025, time.January, 19, 23, 0, 0, 0, time.UTC)
+			start, _ = QPWeek.Bounds(sunday)
+			So(start, ShouldEqual, time.Date(2025, time.January, 13, 0, 0, 0, 0, time.UTC))
+		})
+
+		Convey("A month resets on the first", func() {
+			start, reset := QPMonth.Bounds(now)
+			So(start, ShouldEqual, time.Date(2025, time.January, 1, 0, 0, 0, 0, time.UTC))
+			So(reset, ShouldEqual, time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC))
+		})
+	})
+}
+
+func TestQuotaPolicy(t *testing.T) {
+	Convey("Subject: Request quotas", t, func() {
+		policy := QuotaPolicy{Limit: 3, Period: QPWeek, Overrides: map[string]int{"family": 20, "guests": 1, "vip": 0}}
+
+		Convey("Users get the default limit", func() {
+			So(policy.LimitFor(&User{Type: "user"}), ShouldEqual, 3)
+		})
+
+		Convey("Admins are unlimited unless overridden", func() {
+			So(policy.LimitFor(&User{Type: "admin"}), ShouldEqual, 0)
+			So(policy.LimitFor(&User{Type: "admin", Groups: []string{"guests"}}), ShouldEqual, 1)
+		})
+
+		Convey("The most generous override applies", func() {
+			So(policy.LimitFor(&User{Type: "user", Groups: []string{"Guests", "family"}}), ShouldEqual, 20)
+			So(policy.LimitFor(&User{Type: "user", Groups: []string{"family"}, Roles: []string{"vip"}}), ShouldEqual, 0)
+		})
+
+		Convey("Requests in the current period count against the quota", func() {
+			db := newTestDB()
+			requests := NewRequestRepository(withoutHooks(db))
+
+			user := &User{ID: "user-1", Username: "user", Type: "user"}
+			for _, title := range []string{"Dune", "Emma"} {
+				_, err := requests.CreateBookRequest(&BookRequest{Title: title, Author: "Someone", Source: "GOOGLE",
+					SourceID: title, RequestorID: user.ID, RequestorUsername: user.Username,
+					ApprovalStatus: ASPending, DownloadStatus: DSPending})
+				So(err, ShouldBeNil)
+			}
+
+			now := time.Now()
+			quota, err := policy.QuotaFor(user, requests, now)
+			So(err, ShouldBeNil)
+			So(quota.Used, ShouldEqual, 2)
+			So(quota.Remaining, ShouldEqual, 1)
+			So(quota.Exceeded(), ShouldBeFalse)
+			_, reset := QPWeek.Bounds(now)
+			So(quota.ResetsAt, ShouldEqual, reset)
+
+			// Deleting a request doesn't give it back
+			request, err := requests.GetBookRequest("1")
+			So(err, ShouldBeNil)
+			So(requests.DeleteBookRequest(request), ShouldBeNil)
+
+			policy.Limit = 2
+			quota, err = policy.QuotaFor(user, requests, now)
+			So(err, ShouldBeNil)
+			So(quota.Used, ShouldEqual, 2)
+			So(quota.Exceeded(), ShouldBeTrue)
+
+			// Requests made before their history was recorded count by when they were made
+			So(withoutHooks(db).Create(&BookRequest{Title: "Carrie", Author: "Stephen King", Source: "GOOGLE",
+				SourceID: "Carrie", RequestorID: user.ID, RequestorUsername: user.Username}).Error, ShouldBeNil)
+			quota, err = policy.QuotaFor(user, requests, now)
+			So(err, ShouldBeNil)
+			So(quota.Used, ShouldEqual, 3)
+		})
+	})
+}
@@ -1,14 +1,18 @@
 package models
 
 import (
+	"api/lib/match"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
 
-	"github.com/beego/beego/v2/core/config"
 	"gorm.io/gorm"
 )
 
 type ApprovalStatus string
 type DownloadStatus string
+type MediaType string
 
 const (
 	ASPending  ApprovalStatus = "pending"
@@ -19,47 +23,239 @@ const (
 	DSCancelled DownloadStatus = "cancelled"
 	DSFailure   DownloadStatus = "failure"
 	DSComplete  DownloadStatus = "complete"
+
+	MTEbook     MediaType = "ebook"
+	MTAudiobook MediaType = "audiobook"
+	MTBoth      MediaType = "both"
 )
 
+// IsValid reports whether the media type is one a request can ask for.
+func (m MediaType) IsValid() bool {
+	return m == MTEbook || m == MTAudiobook || m == MTBoth
+}
+
+// WantsEbook reports whether an ebook satisfies the media type. Requests made
+// before media types existed have none and are for ebooks.
+func (m MediaType) WantsEbook() bool {
+	return m != MTAudiobook
+}
+
+// WantsAudiobook reports whether an audiobook satisfies the media type.
+func (m MediaType) WantsAudiobook() bool {
+	return m == MTAudiobook || m == MTBoth
+}
+
+// Covers reports whether fulfilling m also fulfills other.
+func (m MediaType) Covers(other MediaType) bool {
+	return m == MTBoth || m.WantsEbook() == other.WantsEbook() && m.WantsAudiobook() == other.WantsAudiobook()
+}
+
 type BookRequest struct {
-	ID                uint           `json:"id" gorm:"primarykey"`
-	Title             string         `json:"title" gorm:"not null"`
-	Author            string         `json:"author" gorm:"not null"`
-	Source            string         `json:"source" gorm:"size:50;not null"`
-	SourceID          string         `json:"source_id" gorm:"size:100;not null"`
-	ISBN10            *string        `json:"isbn_10" gorm:"size:10"`
-	ISBN13            *string        `json:"isbn_13" gorm:"size:13"`
-	Cover             *string        `json:"cover" gorm:"size:500"`
-	ApprovalStatus    ApprovalStatus `json:"approval_status" gorm:"size:50;not null;default:pending"`
-	DownloadStatus    DownloadStatus `json:"download_status" gorm:"size:50;not null;default:pending"`
-	DownloadSource    *string        `json:"download_source" gorm:"size:50"`
-	RequestorID       string         `json:"requestor_id" gorm:"size:50;not null"`
-	RequestorUsername string         `json:"requestor_username" gorm:"size:100;not null"`
-	CreatedAt         time.Time      `json:"created_at"`
-	UpdatedAt         time.Time      `json:"updated_at"`
+	ID                uint              `json:"id" gorm:"primarykey"`
+	Title             string            `json:"title" gorm:"not null"`
+	Author            string            `json:"author" gorm:"not null"`
+	Source            string            `json:"source" gorm:"size:50;not null"`
+	SourceID          string            `json:"source_id" gorm:"size:100;not null"`
+	ISBN10            *string           `json:"isbn_10" gorm:"size:10"`
+	ISBN13            *string           `json:"isbn_13" gorm:"size:13"`
+	ASIN              *string           `json:"asin" gorm:"size:10"`
+	GoodreadsID       *string           `json:"goodreads_id" gorm:"size:20"`
+	PublishYear       *int              `json:"publish_year"`
+	Cover             *string           `json:"cover" gorm:"size:500"`
+	MediaType         MediaType         `json:"media_type" gorm:"size:20;not null;default:ebook"`
+	ApprovalStatus    ApprovalStatus    `json:"approval_status" gorm:"size:50;not null;default:pending"`
+	DownloadStatus    DownloadStatus    `json:"download_status" gorm:"size:50;not null;default:pending"`
+	DownloadSource    *string           `json:"download_source" gorm:"size:50"`
+	RequestorID       string            `json:"requestor_id" gorm:"size:50;not null"`
+	RequestorUsername string            `json:"requestor_username" gorm:"size:100;not null"`
+	CreatedAt         time.Time         `json:"created_at"`
+	UpdatedAt         time.Time         `json:"updated_at"`
+	Followers         []RequestFollower `json:"followers" gorm:"foreignKey:RequestID"`
+	// PreferredFormats and Languages override download::preferredformats and
+	// download::languages for this request. Both are comma-separated lists.
+	PreferredFormats *string `json:"preferred_formats" gorm:"size:100"`
+	Languages        *string `json:"languages" gorm:"size:100"`
+	// DenialReason tells the requestor why the request was denied.
+	DenialReason *string `json:"denial_reason" gorm:"size:1000"`
+	// AdminNote is private to admins; see HideAdminNote.
+	AdminNote *string `json:"admin_note,omitempty" gorm:"size:2000"`
+	// ApprovalRule is the approval rule that set the status the request was
+	// created with, or DefaultApprovalRule.
+	ApprovalRule *string `json:"approval_rule" gorm:"size:200"`
+	// DownloadJob is the queued download for the request, when one was just started.
+	DownloadJob *DownloadJob `json:"download_job,omitempty" gorm:"-"`
+	// Warnings are returned with a newly created request and aren't stored.
+	Warnings []string `json:"warnings,omitempty" gorm:"-"`
+	// Approval is used by the approval rules when the request is created.
+	Approval *ApprovalContext `json:"-" gorm:"-"`
+	// MatchISBN and MatchKey are the normalized ISBN-13 and title and author
+	// duplicate requests are looked up by.
+	MatchISBN string `json:"-" gorm:"size:13;index"`
+	MatchKey  string `json:"-" gorm:"size:500;index"`
+}
+
+// RequestFollower is a user who asked for a book that had already been
+// requested. Followers are notified alongside the original requestor.
+type RequestFollower struct {
+	ID        uint      `json:"id" gorm:"primarykey"`
+	RequestID uint      `json:"request_id" gorm:"not null;uniqueIndex:idx_request_follower"`
+	UserID    string    `json:"user_id" gorm:"size:50;not null;uniqueIndex:idx_request_follower"`
+	Username  string    `json:"username" gorm:"size:100;not null"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// cursor marks the request's place in a listing sorted by the field.
+func (b *BookRequest) cursor(field string) Cursor {
+	cursor := Cursor{Field: field, ID: strconv.FormatUint(uint64(b.ID), 10)}
+	switch field {
+	case "created_at":
+		cursor.Value = sortTime(b.CreatedAt)
+	case "updated_at":
+		cursor.Value = sortTime(b.UpdatedAt)
+	case "title":
+		cursor.Value = b.Title
+	case "author":
+		cursor.Value = b.Author
+	}
+	return cursor
+}
+
+// HideAdminNote removes the admin note before the request is shown to someone
+// who isn't an admin.
+func (b *BookRequest) HideAdminNote() {
+	b.AdminNote = nil
+}
+
+// IsOpen reports whether the request is still waiting to be fulfilled.
+func (b *BookRequest) IsOpen() bool {
+	return b.ApprovalStatus != ASDenied &&
+		(b.DownloadStatus == DSPending || b.DownloadStatus == DSFailure)
+}
+
+// HasFollower reports whether the user requested or follows the request.
+func (b *BookRequest) HasFollower(userID string) bool {
+	if b.RequestorID == userID {
+		return true
+	}
+	for _, follower := range b.Followers {
+		if follower.UserID == userID {
+			return true
+		}
+	}
+	return false
+}
+
+// IsDuplicateOf reports whether other is for the same book, matching on source
+// ID, ISBN and finally normalized title and author, in a media type that covers
+// this request's. Requests that both have ISBNs only match on them.
+func (b *BookRequest) IsDuplicateOf(other *BookRequest) bool {
+	if !other.MediaType.Covers(b.MediaType) {
+		return false
+	}
+
+	if b.Source == other.Source && b.SourceID != "" && b.SourceID == other.SourceID {
+		return true
+	}
+
+	// Different ISBNs are different editions, however alike the titles are
+	if isbn, otherISBN := b.isbn13(), other.isbn13(); isbn != "" && otherISBN != "" {
+		return isbn == otherISBN
+	}
+
+	return match.Key(b.Title, b.Author) == match.Key(other.Title, other.Author)
+}
+
+func (b *BookRequest) isbn13() string {
+	if b.ISBN13 != nil {
+		return match.ISBN13(*b.ISBN13)
+	}
+	if b.ISBN10 != nil {
+		return match.ISBN13(*b.ISBN10)
+	}
+	return ""
+}
+
+// setMatchKeys sets the keys duplicate requests are looked up by.
+func (b *BookRequest) setMatchKeys() {
+	b.MatchISBN = b.isbn13()
+	b.MatchKey = match.Key(b.Title, b.Author)
 }
 
 func (b *BookRequest) BeforeCreate(tx *gorm.DB) (err error) {
-	// Set default approval status from config
-	b.ApprovalStatus = ApprovalStatus(config.DefaultString(
-		"db::defaultaprrovalstatus", string(ASPending)))
+	if b.MediaType == "" {
+		b.MediaType = MTEbook
+	}
+	b.setMatchKeys()
+
+	// Set the approval status from the approval rules
+	status, rule := configuredApproval(tx, b)
+	b.ApprovalStatus, b.ApprovalRule = status, &rule
+
+	b.PreferredFormats = normalizeList(b.PreferredFormats)
+	b.Languages = normalizeList(b.Languages)
 
 	return
 }
 
+// normalizeList lowercases and trims a comma-separated list, dropping leading
+// dots from formats. Empty lists become nil so the configured defaults apply.
+func normalizeList(list *string) *string {
+	if list == nil {
+		return nil
+	}
+
+	var items []string
+	for _, item := range strings.Split(*list, ",") {
+		item = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(item)), ".")
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	if len(items) == 0 {
+		return nil
+	}
+
+	normalized := strings.Join(items, ",")
+	return &normalized
+}
+
+// emptyToNil trims text and returns nil when nothing is left.
+func emptyToNil(text string) *string {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	return &text
+}
+
 type BookRequestUpdate struct {
 	ApprovalStatus *ApprovalStatus `json:"approval_status"`
 	DownloadStatus *DownloadStatus `json:"download_status"`
 	DownloadSource *string         `json:"download_source"`
+	MediaType      *MediaType      `json:"media_type"`
+	// An empty PreferredFormats or Languages clears the request's override.
+	PreferredFormats *string `json:"preferred_formats"`
+	Languages        *string `json:"languages"`
+	// DenialReason and AdminNote can only be set by admins. Empty clears them.
+	DenialReason *string `json:"denial_reason"`
+	AdminNote    *string `json:"admin_note"`
+	// Actor is recorded in the request's history as making the update.
+	Actor Actor `json:"-"`
 }
 
 type RequestRepository interface {
 	CreateBookRequest(request *BookRequest) (*BookRequest, error)
 	GetBookRequests(limit, offset int, requestorID *string) ([]BookRequest, error)
+	FindBookRequests(filter RequestFilter, limit, offset int) ([]BookRequest, error)
+	CountBookRequests(filter RequestFilter) (int64, error)
+	ListBookRequests(filter RequestFilter, limit, offset int, after *Cursor) (*Page[BookRequest], error)
 	GetAllBookRequests() ([]BookRequest, error)
 	GetBookRequest(id string) (*BookRequest, error)
 	UpdateBookRequest(bookRequest *BookRequest, updateBookRequest BookRequestUpdate) (*BookRequest, error)
 	DeleteBookRequest(bookRequest *BookRequest) error
+	FindDuplicateBookRequest(request *BookRequest) (*BookRequest, error)
+	AddFollower(bookRequest *BookRequest, userID, username string) (*BookRequest, error)
+	CountBookRequestsSince(requestorID string, since time.Time) (int64, error)
 }
 
 type requestRepository struct {
@@ -70,8 +266,19 @@ func NewRequestRepository(db *gorm.DB) RequestRepository {
 	return &requestRepository{db: db}
 }
 
+// CreateBookRequest stores the request and starts its history, crediting the
+// requestor with creating it. Followers aren't stored; they're added with
+// AddFollower.
 func (r *requestRepository) CreateBookRequest(request *BookRequest) (*BookRequest, error) {
-	if err := r.db.Create(request).Error; err != nil {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Omit("Followers").Create(request).Error; err != nil {
+			return err
+		}
+
+		actor := Actor{ID: request.RequestorID, Username: request.RequestorUsername}
+		return recordEvent(tx, requestEvent(actor, nil, request))
+	})
+	if err != nil {
 		return nil, err
 	}
 	return request, nil
@@ -80,7 +287,7 @@ func (r *requestRepository) CreateBookRequest(request *BookRequest) (*BookReques
 func (r *requestRepository) GetAllBookRequests() ([]BookRequest, error) {
 	var bookRequest []BookRequest
 
-	if err := r.db.Order("id DESC").Find(&bookRequest).Error; err != nil {
+	if err := r.db.Preload("Followers").Order("id DESC").Find(&bookRequest).Error; err != nil {
 		return bookRequest, err
 	}
 
@@ -88,61 +295,220 @@ func (r *requestRepository) GetAllBookRequests() ([]BookRequest, error) {
 }
 
 func (r *requestRepository) GetBookRequests(limit, offset int, requestorID *string) ([]BookRequest, error) {
-	var bookRequests []BookRequest
-
-	// Start building the query
-	query := r.db.Order("id DESC")
+	return r.FindBookRequests(RequestFilter{RequestorID: requestorID}, limit, offset)
+}
 
-	// Apply filtering if RequestorID is provided
-	if requestorID != nil && *requestorID != "" {
-		query = query.Where("requestor_id = ?", *requestorID)
-	}
+// FindBookRequests lists the requests that match the filter in its sort order.
+func (r *requestRepository) FindBookRequests(filter RequestFilter, limit, offset int) ([]BookRequest, error) {
+	var bookRequests []BookRequest
 
-	// Apply Limit and Offset
-	query = query.Limit(limit).Offset(offset)
+	query := filter.Sort.apply(filter.apply(r.db, r.db.Preload("Followers")))
 
-	// Execute the query
-	if err := query.Find(&bookRequests).Error; err != nil {
+	if err := query.Limit(limit).Offset(offset).Find(&bookRequests).Error; err != nil {
 		return nil, err
 	}
 
 	return bookRequests, nil
 }
 
+// CountBookRequests counts the requests that match the filter.
+func (r *requestRepository) CountBookRequests(filter RequestFilter) (int64, error) {
+	var count int64
+
+	err := filter.apply(r.db, r.db.Model(&BookRequest{})).Count(&count).Error
+
+	return count, err
+}
+
+// ListBookRequests returns a page of the requests that match the filter,
+// starting after the cursor when there is one.
+func (r *requestRepository) ListBookRequests(filter RequestFilter, limit, offset int, after *Cursor) (*Page[BookRequest], error) {
+	query := filter.apply(r.db, r.db.Model(&BookRequest{}).Preload("Followers"))
+
+	return paginate(query, filter.Sort, limit, offset, after, func(b *BookRequest) Cursor {
+		return b.cursor(filter.Sort.field())
+	})
+}
+
 func (r *requestRepository) GetBookRequest(id string) (*BookRequest, error) {
 	var bookRequest BookRequest
-	if err := r.db.Model(BookRequest{}).Where("id = ?", id).First(&bookRequest).Error; err != nil {
+	if err := r.db.Model(BookRequest{}).Preload("Followers").Where("id = ?", id).First(&bookRequest).Error; err != nil {
 		return nil, err
 	}
 	return &bookRequest, nil
 }
 
 func (r *requestRepository) UpdateBookRequest(bookRequest *BookRequest, updateBookRequest BookRequestUpdate) (*BookRequest, error) {
+	old := *bookRequest
+
 	// Edit the bookRequest
 	if updateBookRequest.ApprovalStatus != nil {
 		bookRequest.ApprovalStatus = *updateBookRequest.ApprovalStatus
 	}
+	if bookRequest.ApprovalStatus != ASDenied {
+		// A reason only explains a denial
+		bookRequest.DenialReason = nil
+	} else if updateBookRequest.DenialReason != nil {
+		bookRequest.DenialReason = emptyToNil(*updateBookRequest.DenialReason)
+	}
+	if updateBookRequest.AdminNote != nil {
+		bookRequest.AdminNote = emptyToNil(*updateBookRequest.AdminNote)
+	}
 	if updateBookRequest.DownloadStatus != nil {
 		bookRequest.DownloadStatus = *updateBookRequest.DownloadStatus
-		if bookRequest.DownloadStatus == "complete" {
-			bookRequest.DownloadSource = nil
-		}
 	}
-	if bookRequest.DownloadStatus == "complete" {
+	// Only updates to the download say where a complete request came from
+	downloadUpdated := updateBookRequest.DownloadStatus != nil || updateBookRequest.DownloadSource != nil
+	if downloadUpdated && bookRequest.DownloadStatus == "complete" {
 		bookRequest.DownloadSource = updateBookRequest.DownloadSource
 	}
+	if updateBookRequest.MediaType != nil {
+		bookRequest.MediaType = *updateBookRequest.MediaType
+	}
+	if updateBookRequest.PreferredFormats != nil {
+		bookRequest.PreferredFormats = normalizeList(updateBookRequest.PreferredFormats)
+	}
+	if updateBookRequest.Languages != nil {
+		bookRequest.Languages = normalizeList(updateBookRequest.Languages)
+	}
 
-	err := r.db.Model(bookRequest).
-		Updates(map[string]any{
-			"approval_status": bookRequest.ApprovalStatus,
-			"download_status": bookRequest.DownloadStatus,
-			"download_source": bookRequest.DownloadSource,
-		}).Error
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(bookRequest).
+			Updates(map[string]any{
+				"approval_status":   bookRequest.ApprovalStatus,
+				"download_status":   bookRequest.DownloadStatus,
+				"download_source":   bookRequest.DownloadSource,
+				"media_type":        bookRequest.MediaType,
+				"preferred_formats": bookRequest.PreferredFormats,
+				"languages":         bookRequest.Languages,
+				"denial_reason":     bookRequest.DenialReason,
+				"admin_note":        bookRequest.AdminNote,
+			}).Error
+		if err != nil {
+			return err
+		}
+
+		return recordEvent(tx, requestEvent(updateBookRequest.Actor, &old, bookRequest))
+	})
 
 	// Return the updated bookRequest
 	return bookRequest, err
 }
 
 func (r *requestRepository) DeleteBookRequest(bookRequest *BookRequest) error {
-	return r.db.Unscoped().Delete(&bookRequest, bookRequest.ID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("request_id = ?", bookRequest.ID).Delete(&RequestFollower{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("request_id = ?", bookRequest.ID).Delete(&DownloadJob{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("request_id = ?", bookRequest.ID).Delete(&DownloadAttempt{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("request_id = ?", bookRequest.ID).Delete(&RequestComment{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&bookRequest, bookRequest.ID).Error
+	})
+}
+
+// FindDuplicateBookRequest returns the oldest open request the request is a
+// duplicate of, or nil if there isn't one. Only requests sharing the source ID,
+// ISBN or title and author key are loaded and checked.
+func (r *requestRepository) FindDuplicateBookRequest(request *BookRequest) (*BookRequest, error) {
+	matches := r.db.Where("match_key = ?", match.Key(request.Title, request.Author))
+	if request.SourceID != "" {
+		matches = matches.Or("source = ? AND source_id = ?", request.Source, request.SourceID)
+	}
+	if isbn := request.isbn13(); isbn != "" {
+		matches = matches.Or("match_isbn = ?", isbn)
+	}
+
+	var candidates []BookRequest
+	err := r.db.Preload("Followers").
+		Where("approval_status <> ?", ASDenied).
+		Where("download_status IN ?", []DownloadStatus{DSPending, DSFailure}).
+		Where(matches).
+		Order("id ASC").
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range candidates {
+		if request.IsDuplicateOf(&candidates[i]) {
+			return &candidates[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// BackfillMatchKeys sets the duplicate lookup keys of requests stored before
+// they existed.
+func BackfillMatchKeys(db *gorm.DB) error {
+	var requests []BookRequest
+	if err := db.Where("match_key = '' OR match_key IS NULL").Find(&requests).Error; err != nil {
+		return err
+	}
+
+	for i := range requests {
+		requests[i].setMatchKeys()
+		err := db.Model(&requests[i]).UpdateColumns(map[string]any{
+			"match_isbn": requests[i].MatchISBN,
+			"match_key":  requests[i].MatchKey,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountBookRequestsSince counts the requests the user has made since the given
+// time. It counts their creation events, so deleting a request doesn't give the
+// user another one. Requests made before their history was recorded have no
+// creation event and are counted by when they were created.
+func (r *requestRepository) CountBookRequestsSince(requestorID string, since time.Time) (int64, error) {
+	creations := r.db.Model(&StatusEvent{}).
+		Where("request_id IS NOT NULL AND old_approval_status IS NULL AND old_download_status IS NULL")
+
+	var count int64
+	err := creations.Session(&gorm.Session{}).
+		Where("actor_id = ? AND created_at >= ?", requestorID, since).
+		Count(&count).Error
+	if err != nil {
+		return 0, err
+	}
+
+	var untracked int64
+	err = r.db.Model(&BookRequest{}).
+		Where("requestor_id = ? AND created_at >= ?", requestorID, since).
+		Where("id NOT IN (?)", creations.Select("request_id")).
+		Count(&untracked).Error
+
+	return count + untracked, err
+}
+
+func (r *requestRepository) AddFollower(bookRequest *BookRequest, userID, username string) (*BookRequest, error) {
+	if bookRequest.HasFollower(userID) {
+		return bookRequest, nil
+	}
+
+	follower := RequestFollower{
+		RequestID: bookRequest.ID,
+		UserID:    userID,
+		Username:  username,
+	}
+
+	if err := r.db.Create(&follower).Error; err != nil {
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return bookRequest, nil
+		}
+		return nil, err
+	}
+
+	bookRequest.Followers = append(bookRequest.Followers, follower)
+	return bookRequest, nil
 }
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBookRequestIsDuplicateOf(t *testing.T) {
+	isbn10 := "0441013597"
+	isbn13 := "978-0-441-01359-3"
+
+	Convey("Subject: Duplicate book request detection", t, func() {
+		existing := &BookRequest{
+			Title:    "Dune",
+			Author:   "Frank Herbert",
+			Source:   "GOOGLE",
+			SourceID: "abc123",
+			ISBN13:   &isbn13,
+		}
+
+		Convey("Matching source IDs are duplicates", func() {
+			other := &BookRequest{Title: "Something Else", Author: "Someone", Source: "GOOGLE", SourceID: "abc123"}
+			So(other.IsDuplicateOf(existing), ShouldBeTrue)
+		})
+
+		Convey("The same source ID from another provider is not a duplicate", func() {
+			other := &BookRequest{Title: "Something Else", Author: "Someone", Source: "HARDCOVER", SourceID: "abc123"}
+			So(other.IsDuplicateOf(existing), ShouldBeFalse)
+		})
+
+		Convey("An ISBN-10 matches the equivalent ISBN-13", func() {
+			other := &BookRequest{Title: "Dune (Deluxe)", Author: "Someone", Source: "OPENLIBRARY", SourceID: "OL1", ISBN10: &isbn10}
+			So(other.IsDuplicateOf(existing), ShouldBeTrue)
+		})
+
+		Convey("Normalized title and author match", func() {
+			other := &BookRequest{Title: "Dune: Deluxe Edition", Author: "Herbert, Frank", Source: "OPENLIBRARY", SourceID: "OL2"}
+			So(other.IsDuplicateOf(existing), ShouldBeTrue)
+		})
+
+		Convey("Matching titles with different ISBNs are not duplicates", func() {
+			otherISBN := "9780593098233"
+			other := &BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "OPENLIBRARY", SourceID: "OL4", ISBN13: &otherISBN}
+			So(other.IsDuplicateOf(existing), ShouldBeFalse)
+		})
+
+		Convey("Different books are not duplicates", func() {
+			other := &BookRequest{Title: "Dune Messiah", Author: "Frank Herbert", Source: "OPENLIBRARY", SourceID: "OL3"}
+			So(other.IsDuplicateOf(existing), ShouldBeFalse)
+		})
+
+		Convey("An ebook request doesn't cover an audiobook request", func() {
+			other := &BookRequest{Title: "Dune", Author: "Frank Herbert", MediaType: MTAudiobook}
+			So(other.IsDuplicateOf(existing), ShouldBeFalse)
+
+			existing.MediaType = MTBoth
+			So(other.IsDuplicateOf(existing), ShouldBeTrue)
+
+			other.MediaType = MTEbook
+			So(other.IsDuplicateOf(existing), ShouldBeTrue)
+		})
+	})
+
+	Convey("Subject: Book request followers", t, func() {
+		request := &BookRequest{
+			RequestorID:    "user-1",
+			ApprovalStatus: ASPending,
+			DownloadStatus: DSPending,
+			Followers:      []RequestFollower{{UserID: "user-2"}},
+		}
+
+		So(request.HasFollower("user-1"), ShouldBeTrue)
+		So(request.HasFollower("user-2"), ShouldBeTrue)
+		So(request.HasFollower("user-3"), ShouldBeFalse)
+		So(request.IsOpen(), ShouldBeTrue)
+
+		request.ApprovalStatus = ASDenied
+		So(request.IsOpen(), ShouldBeFalse)
+	})
+}
+
+func TestNormalizeList(t *testing.T) {
+	Convey("Subject: Normalizing format and language preferences", t, func() {
+		list := " EPUB, .azw3 ,,mobi "
+		So(*normalizeList(&list), ShouldEqual, "epub,azw3,mobi")
+
+		empty := " , "
+		So(normalizeList(&empty), ShouldBeNil)
+		So(normalizeList(nil), ShouldBeNil)
+	})
+}
+
+func TestUpdateBookRequestDenial(t *testing.T) {
+	Convey("Subject: Denial reasons and admin notes", t, func() {
+		db := newTestDB()
+
+		request := &BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "GOOGLE", SourceID: "abc123",
+			RequestorID: "user-1", RequestorUsername: "user"}
+		So(db.Create(request).Error, ShouldBeNil)
+
+		repo := NewRequestRepository(db)
+		denied, reason, note := ASDenied, "  Already in the library as an omnibus.  ", "Asked twice this month"
+		_, err := repo.UpdateBookRequest(request, BookRequestUpdate{ApprovalStatus: &denied, DenialReason: &reason, AdminNote: &note})
+		So(err, ShouldBeNil)
+
+		stored, err := repo.GetBookRequest("1")
+		So(err, ShouldBeNil)
+		So(*stored.DenialReason, ShouldEqual, "Already in the library as an omnibus.")
+		So(*stored.AdminNote, ShouldEqual, note)
+
+		Convey("Approving the request clears the reason but keeps the note", func() {
+			approved := ASApproved
+			_, err := repo.UpdateBookRequest(stored, BookRequestUpdate{ApprovalStatus: &approved})
+			So(err, ShouldBeNil)
+
+			stored, err := repo.GetBookRequest("1")
+			So(err, ShouldBeNil)
+			So(stored.DenialReason, ShouldBeNil)
+			So(stored.AdminNote, ShouldNotBeNil)
+		})
+
+		Convey("An empty note clears it", func() {
+			empty := ""
+			_, err := repo.UpdateBookRequest(stored, BookRequestUpdate{AdminNote: &empty})
+			So(err, ShouldBeNil)
+
+			stored, err := repo.GetBookRequest("1")
+			So(err, ShouldBeNil)
+			So(stored.AdminNote, ShouldBeNil)
+			So(stored.DenialReason, ShouldNotBeNil)
+		})
+
+		Convey("Updates that don't touch the download keep its source", func() {
+			complete, source := DSComplete, "CWA"
+			_, err := repo.UpdateBookRequest(stored, BookRequestUpdate{DownloadStatus: &complete, DownloadSource: &source})
+			So(err, ShouldBeNil)
+
+			note := "Downloaded overnight"
+			_, err = repo.UpdateBookRequest(stored, BookRequestUpdate{AdminNote: &note})
+			So(err, ShouldBeNil)
+
+			stored, err := repo.GetBookRequest("1")
+			So(err, ShouldBeNil)
+			So(*stored.DownloadSource, ShouldEqual, "CWA")
+		})
+
+		Convey("The note can be hidden from requestors", func() {
+			stored.HideAdminNote()
+			So(stored.AdminNote, ShouldBeNil)
+		})
+	})
+}
+
+func TestFindDuplicateBookRequest(t *testing.T) {
+	Convey("Subject: Finding open duplicate requests", t, func() {
+		db := newTestDB()
+
+		requests := NewRequestRepository(withoutHooks(db))
+		isbn13 := "978-0-441-01359-3"
+		for _, request := range []*BookRequest{
+			{Title: "Emma", Author: "Jane Austen", SourceID: "emma", ApprovalStatus: ASDenied, DownloadStatus: DSPending},
+			{Title: "Dune", Author: "Frank Herbert", SourceID: "dune", ISBN13: &isbn13, ApprovalStatus: ASPending, DownloadStatus: DSPending},
+			{Title: "Dune Messiah", Author: "Frank Herbert", SourceID: "messiah", ApprovalStatus: ASApproved, DownloadStatus: DSComplete},
+		} {
+			request.Source, request.RequestorID, request.RequestorUsername = "GOOGLE", "user-1", "user"
+			request.setMatchKeys()
+			_, err := requests.CreateBookRequest(request)
+			So(err, ShouldBeNil)
+		}
+
+		find := func(request *BookRequest) *BookRequest {
+			duplicate, err := requests.FindDuplicateBookRequest(request)
+			So(err, ShouldBeNil)
+			return duplicate
+		}
+
+		isbn10 := "0441013597"
+		So(find(&BookRequest{Title: "Dune (Deluxe)", Author: "Someone", Source: "OPENLIBRARY", ISBN10: &isbn10}).SourceID, ShouldEqual, "dune")
+		So(find(&BookRequest{Title: "Something Else", Author: "Someone", Source: "GOOGLE", SourceID: "dune"}).SourceID, ShouldEqual, "dune")
+		So(find(&BookRequest{Title: "Dune: Deluxe Edition", Author: "Herbert, Frank", Source: "OPENLIBRARY"}).SourceID, ShouldEqual, "dune")
+
+		// Closed requests aren't duplicates
+		So(find(&BookRequest{Title: "Emma", Author: "Jane Austen", Source: "GOOGLE", SourceID: "emma"}), ShouldBeNil)
+		So(find(&BookRequest{Title: "Dune Messiah", Author: "Frank Herbert", Source: "GOOGLE", SourceID: "messiah"}), ShouldBeNil)
+
+		Convey("Requests stored before the keys existed are backfilled", func() {
+			So(db.Model(&BookRequest{}).Where("1 = 1").UpdateColumn("match_key", "").Error, ShouldBeNil)
+			So(find(&BookRequest{Title: "Dune: Deluxe Edition", Author: "Herbert, Frank", Source: "OPENLIBRARY"}), ShouldBeNil)
+
+			So(BackfillMatchKeys(db), ShouldBeNil)
+			So(find(&BookRequest{Title: "Dune: Deluxe Edition", Author: "Herbert, Frank", Source: "OPENLIBRARY"}).SourceID, ShouldEqual, "dune")
+		})
+	})
+}
+
+func TestCreateBookRequestFollowers(t *testing.T) {
+	Convey("Subject: Followers of new requests", t, func() {
+		requests := NewRequestRepository(newTestDB())
+		request, err := requests.CreateBookRequest(&BookRequest{Title: "Dune", Author: "Frank Herbert", Source: "GOOGLE",
+			SourceID: "abc123", RequestorID: "user-1", RequestorUsername: "user",
+			Followers: []RequestFollower{{UserID: "user-2", Username: "stranger"}}})
+		So(err, ShouldBeNil)
+
+		stored, err := requests.GetBookRequest(strconv.FormatUint(uint64(request.ID), 10))
+		So(err, ShouldBeNil)
+		So(stored.Followers, ShouldBeEmpty)
+	})
+}
@@ -4,19 +4,89 @@ import "fmt"
 
 type Search struct {
 	Query string `json:"query"`
+	// MediaType limits library matches to items with that kind of media.
+	MediaType MediaType `json:"media_type"`
+}
+
+// BookResult is the provider-agnostic search result returned by every metadata
+// provider. Its JSON shape matches BookRequest so a result can be submitted as a
+// request without any client-side mapping.
+type BookResult struct {
+	Title       string       `json:"title"`
+	Author      string       `json:"author"`
+	Authors     []string     `json:"authors"`
+	Source      string       `json:"source"`
+	SourceID    string       `json:"source_id"`
+	ISBN10      *string      `json:"isbn_10"`
+	ISBN13      *string      `json:"isbn_13"`
+	ASIN        *string      `json:"asin"`
+	GoodreadsID *string      `json:"goodreads_id"`
+	Cover       *string      `json:"cover"`
+	Covers      *CoverImages `json:"covers"`
+	Description *string      `json:"description"`
+	PublishYear *int         `json:"publish_year"`
+	Series      *string      `json:"series"`
+	Language    *string      `json:"language"`
+	InfoLink    *string      `json:"info_link"`
+	Providers   []string     `json:"providers"`
+	// InLibrary is set when the book is already in the Audiobookshelf library.
+	InLibrary     bool    `json:"in_library"`
+	LibraryItemID *string `json:"library_item_id"`
+}
+
+type CoverImages struct {
+	Small  string `json:"small"`
+	Medium string `json:"medium"`
+	Large  string `json:"large"`
 }
 
 type GoogleBooksResponse struct {
-	Items []any `json:"items"`
+	Items []GoogleBook `json:"items"`
+}
+
+type GoogleBook struct {
+	ID         string           `json:"id"`
+	VolumeInfo GoogleVolumeInfo `json:"volumeInfo"`
+}
+
+type GoogleVolumeInfo struct {
+	Title               string                     `json:"title"`
+	Subtitle            string                     `json:"subtitle"`
+	Authors             []string                   `json:"authors"`
+	Description         string                     `json:"description"`
+	PublishedDate       string                     `json:"publishedDate"`
+	Language            string                     `json:"language"`
+	InfoLink            string                     `json:"infoLink"`
+	IndustryIdentifiers []GoogleIndustryIdentifier `json:"industryIdentifiers"`
+	ImageLinks          *GoogleImageLinks          `json:"imageLinks"`
+}
+
+type GoogleIndustryIdentifier struct {
+	Type       string `json:"type"`
+	Identifier string `json:"identifier"`
+}
+
+type GoogleImageLinks struct {
+	SmallThumbnail string `json:"smallThumbnail"`
+	Thumbnail      string `json:"thumbnail"`
+	Small          string `json:"small"`
+	Medium         string `json:"medium"`
+	Large          string `json:"large"`
 }
 
 type OpenLibraryBook struct {
-	Title       string              `json:"title"`
-	AuthorName  []string            `json:"author_name"`
-	CoverImages []OpenLibCoverImage `json:"cover_images"`
-	CoverID     *int                `json:"cover_i"`
-	Seed        []string            `json:"seed"`
-	InfoLink    *string             `json:"info_link"`
+	Key              string              `json:"key"`
+	Title            string              `json:"title"`
+	AuthorName       []string            `json:"author_name"`
+	CoverImages      []OpenLibCoverImage `json:"cover_images"`
+	CoverID          *int                `json:"cover_i"`
+	Seed             []string            `json:"seed"`
+	InfoLink         *string             `json:"info_link"`
+	ISBN             []string            `json:"isbn"`
+	GoodreadsIDs     []string            `json:"id_goodreads"`
+	FirstPublishYear *int                `json:"first_publish_year"`
+	Language         []string            `json:"language"`
+	FirstSentence    []string            `json:"first_sentence"`
 }
 
 type OpenLibCoverImage struct {
@@ -38,7 +108,10 @@ func (o *OpenLibraryBook) SetCoverImage() {
 }
 
 func (o *OpenLibraryBook) SetInfoLink() {
-	if len(o.Seed) > 0 {
+	if o.Key != "" {
+		link := "https://openlibrary.org" + o.Key
+		o.InfoLink = &link
+	} else if len(o.Seed) > 0 {
 		link := "https://openlibrary.org" + o.Seed[0]
 		o.InfoLink = &link
 	}
@@ -69,8 +142,11 @@ type HardcoverBook struct {
 		URL string `json:"url"`
 	} `json:"cached_image"`
 	Description            *string                 `json:"description"`
+	ReleaseYear            *int                    `json:"release_year"`
 	Contributions          []HardcoverContribution `json:"contributions"`
+	BookSeries             []HardcoverBookSeries   `json:"book_series"`
 	DefaultPhysicalEdition *HardcoverIdentifiers   `json:"default_physical_edition"`
+	DefaultAudioEdition    *HardcoverIdentifiers   `json:"default_audio_edition"`
 }
 
 type HardcoverImage struct {
@@ -86,7 +162,15 @@ type HardcoverAuthor struct {
 	ID   int    `json:"id"`
 }
 
+type HardcoverBookSeries struct {
+	Position *float64 `json:"position"`
+	Series   struct {
+		Name string `json:"name"`
+	} `json:"series"`
+}
+
 type HardcoverIdentifiers struct {
 	ISBN10 *string `json:"isbn_10"`
 	ISBN13 *string `json:"isbn_13"`
+	ASIN   *string `json:"asin"`
 }
@@ -1,5 +1,7 @@
 package models
 
+import "strings"
+
 var (
 	UserList map[string]*User
 )
@@ -14,6 +16,9 @@ type User struct {
 	LastSeen    int             `json:"lastSeen"`
 	CreatedAt   int             `json:"createdAt"`
 	Permissions UserPermissions `json:"permissions"`
+	// Groups and Roles come from the OIDC token's claims.
+	Groups []string `json:"groups,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
 }
 
 type UserPermissions struct {
@@ -29,3 +34,19 @@ type UserPermissions struct {
 func (u *User) IsAdmin() bool {
 	return u.Type == "admin" || u.Type == "root"
 }
+
+// Memberships lists the user's type, OIDC groups and OIDC roles.
+func (u *User) Memberships() []string {
+	return append(append([]string{u.Type}, u.Groups...), u.Roles...)
+}
+
+// InGroup reports whether the user is in the OIDC group or role, or of the
+// user type, ignoring case.
+func (u *User) InGroup(name string) bool {
+	for _, membership := range u.Memberships() {
+		if strings.EqualFold(membership, name) {
+			return true
+		}
+	}
+	return false
+}
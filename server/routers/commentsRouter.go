@@ -61,6 +61,24 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["api/controllers:CacheController"] = append(beego.GlobalControllerRouter["api/controllers:CacheController"],
+        beego.ControllerComments{
+            Method: "Get",
+            Router: `/`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:CacheController"] = append(beego.GlobalControllerRouter["api/controllers:CacheController"],
+        beego.ControllerComments{
+            Method: "Delete",
+            Router: `/`,
+            AllowHTTPMethods: []string{"delete"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["api/controllers:ConfigController"] = append(beego.GlobalControllerRouter["api/controllers:ConfigController"],
         beego.ControllerComments{
             Method: "Get",
@@ -124,6 +142,69 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["api/controllers:IssueController"] = append(beego.GlobalControllerRouter["api/controllers:IssueController"],
+        beego.ControllerComments{
+            Method: "GetHistory",
+            Router: `/:id/history`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "GetAttempts",
+            Router: `/:id/attempts`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "GetHistory",
+            Router: `/:id/history`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "GetComments",
+            Router: `/:id/comments`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "PostComment",
+            Router: `/:id/comments`,
+            AllowHTTPMethods: []string{"post"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "UpdateComment",
+            Router: `/:id/comments/:commentId`,
+            AllowHTTPMethods: []string{"patch"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "DeleteComment",
+            Router: `/:id/comments/:commentId`,
+            AllowHTTPMethods: []string{"delete"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["api/controllers:MonitoringController"] = append(beego.GlobalControllerRouter["api/controllers:MonitoringController"],
         beego.ControllerComments{
             Method: "Get",
@@ -196,6 +277,15 @@ func init() {
             Filters: nil,
             Params: nil})
 
+    beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
+        beego.ControllerComments{
+            Method: "GetQuota",
+            Router: `/quota`,
+            AllowHTTPMethods: []string{"get"},
+            MethodParams: param.Make(),
+            Filters: nil,
+            Params: nil})
+
     beego.GlobalControllerRouter["api/controllers:RequestController"] = append(beego.GlobalControllerRouter["api/controllers:RequestController"],
         beego.ControllerComments{
             Method: "Get",
@@ -225,26 +315,8 @@ func init() {
 
     beego.GlobalControllerRouter["api/controllers:SearchController"] = append(beego.GlobalControllerRouter["api/controllers:SearchController"],
         beego.ControllerComments{
-            Method: "GoogleSearch",
-            Router: `/google`,
-            AllowHTTPMethods: []string{"post"},
-            MethodParams: param.Make(),
-            Filters: nil,
-            Params: nil})
-
-    beego.GlobalControllerRouter["api/controllers:SearchController"] = append(beego.GlobalControllerRouter["api/controllers:SearchController"],
-        beego.ControllerComments{
-            Method: "HardcoverSearch",
-            Router: `/hardcover`,
-            AllowHTTPMethods: []string{"post"},
-            MethodParams: param.Make(),
-            Filters: nil,
-            Params: nil})
-
-    beego.GlobalControllerRouter["api/controllers:SearchController"] = append(beego.GlobalControllerRouter["api/controllers:SearchController"],
-        beego.ControllerComments{
-            Method: "OpenLibrarySearch",
-            Router: `/openlib`,
+            Method: "Search",
+            Router: `/`,
             AllowHTTPMethods: []string{"post"},
             MethodParams: param.Make(),
             Filters: nil,
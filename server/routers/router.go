@@ -37,6 +37,12 @@ func init() {
 						&controllers.ConfigController{},
 					),
 				),
+				beego.NSNamespace("/cache",
+					beego.NSBefore(middlewares.AuthMiddleware),
+					beego.NSInclude(
+						&controllers.CacheController{},
+					),
+				),
 			),
 			beego.NSNamespace("/requests",
 				beego.NSBefore(middlewares.AuthMiddleware),